@@ -2,25 +2,37 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/graph-gophers/graphql-transport-ws/graphqlws"
 	"google.golang.org/grpc"
 
+	"github.com/suyashXD/DNDS/internal/auth"
+	"github.com/suyashXD/DNDS/internal/delivery"
+	"github.com/suyashXD/DNDS/internal/graphql/loader"
+	"github.com/suyashXD/DNDS/internal/graphql/resolver"
 	"github.com/suyashXD/DNDS/internal/grpc/proto"
 	"github.com/suyashXD/DNDS/internal/grpc/service"
-	"github.com/suyashXD/DNDS/internal/graphql/resolver"
 	"github.com/suyashXD/DNDS/internal/queue"
+	"github.com/suyashXD/DNDS/internal/realtime"
 	"github.com/suyashXD/DNDS/internal/store"
 )
 
@@ -31,121 +43,346 @@ const (
 	shutdownTimeout = 10 * time.Second
 )
 
+var (
+	storeBackend = flag.String("store", "memory", "storage backend: memory, file, bolt or sqlite")
+	dataDir      = flag.String("data-dir", "data", "directory for persistent store backends")
+
+	queueOverflow = flag.String("queue-overflow", "block", "what to do when the notification queue is full: block, reject or spill")
+	spillLimit    = flag.Int("queue-spill-limit", 10000, "how many notifications the spill policy parks before rejecting the rest")
+	retryPolicies = flag.String("retry-policies", "", "JSON file with retry policies per channel, notification type and error class; built-in policy when empty")
+	drainTimeout  = flag.Duration("drain-timeout", shutdownTimeout, "how long to keep delivering buffered notifications on shutdown")
+
+	delivererKinds = flag.String("deliverer", "simulated", "comma-separated delivery channels: simulated, webhook, email")
+
+	webhookURL       = flag.String("webhook-url", "", "tenant-wide webhook URL for users without their own")
+	webhookEndpoints = flag.String("webhook-endpoints", "", "JSON file mapping user IDs to webhook URLs")
+	webhookSecret    = flag.String("webhook-secret", os.Getenv("DNDS_WEBHOOK_SECRET"), "HMAC-SHA256 key for webhook signatures")
+	webhookTimeout   = flag.Duration("webhook-timeout", 5*time.Second, "timeout for a single webhook request")
+
+	smtpHost       = flag.String("smtp-host", "localhost", "SMTP server host")
+	smtpPort       = flag.Int("smtp-port", 587, "SMTP server port")
+	smtpUsername   = flag.String("smtp-username", "", "SMTP AUTH username; AUTH is skipped when empty")
+	smtpPassword   = flag.String("smtp-password", os.Getenv("DNDS_SMTP_PASSWORD"), "SMTP AUTH password")
+	smtpFrom       = flag.String("smtp-from", "notifications@localhost", "sender address of notification emails")
+	smtpStartTLS   = flag.Bool("smtp-starttls", true, "require STARTTLS before sending")
+	emailAddresses = flag.String("email-addresses", "", "JSON file mapping user IDs to email addresses of users who opted in")
+	emailTemplates = flag.String("email-templates", "", "directory with subject.tmpl, body.txt.tmpl and body.html.tmpl; built-in templates when empty")
+
+	authSecret = flag.String("auth-secret", os.Getenv("DNDS_AUTH_SECRET"), "HMAC key for client auth tokens")
+	issueToken = flag.String("issue-token", "", "print an auth token for this user ID and exit")
+
+	simFailureRate = flag.Float64("sim-failure-rate", 0.1, "failure rate of the simulated deliverer")
+	simMinLatency  = flag.Duration("sim-min-latency", 10*time.Millisecond, "minimum latency of the simulated deliverer")
+	simMaxLatency  = flag.Duration("sim-max-latency", 50*time.Millisecond, "maximum latency of the simulated deliverer")
+)
+
 func main() {
+	flag.Parse()
+
+	if *issueToken != "" {
+		if *authSecret == "" {
+			log.Fatal("-issue-token needs -auth-secret or DNDS_AUTH_SECRET")
+		}
+		fmt.Println(auth.NewTokenAuthenticator(*authSecret).Token(*issueToken))
+		return
+	}
+	if *authSecret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("Failed to generate auth secret: %v", err)
+		}
+		*authSecret = hex.EncodeToString(secret)
+		log.Println("No -auth-secret set, using an ephemeral one; clients cannot authenticate until a secret is configured")
+	}
+	authenticator := auth.NewTokenAuthenticator(*authSecret)
+
 	// Create store with sample data
-	memoryStore := store.NewMemoryStore(true)
-	
+	dataStore, err := openStore(*storeBackend, *dataDir)
+	if err != nil {
+		log.Fatalf("Failed to open %s store: %v", *storeBackend, err)
+	}
+
 	// Create notification queue
-	notificationQueue := queue.NewNotificationQueue(memoryStore, workerCount)
+	deliverer, err := newDeliverers(*delivererKinds, dataStore)
+	if err != nil {
+		log.Fatalf("Failed to create deliverer: %v", err)
+	}
+	notificationQueue := queue.NewNotificationQueue(dataStore, deliverer, workerCount)
+	overflow, err := queue.ParseOverflowPolicy(*queueOverflow)
+	if err != nil {
+		log.Fatalf("Invalid -queue-overflow: %v", err)
+	}
+	notificationQueue.SetOverflowPolicy(overflow)
+	notificationQueue.SetSpillLimit(*spillLimit)
+	if *retryPolicies != "" {
+		policies, err := delivery.LoadRetryPolicies(*retryPolicies)
+		if err != nil {
+			log.Fatalf("Failed to load retry policies: %v", err)
+		}
+		notificationQueue.SetRetryPolicies(policies)
+	}
+
+	// Push delivered notifications to WebSocket and Server-Sent Events clients
+	hub := realtime.NewHub(authenticator, notificationQueue.Events())
+	events := realtime.NewSSEHandler(authenticator, dataStore, notificationQueue.Events())
+
 	notificationQueue.Start()
-	
+
 	// Set up graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	
+
+	// Both servers mark servers done once they have shut down
+	var servers sync.WaitGroup
+	servers.Add(2)
+
 	// Create gRPC server
-	go serveGRPC(ctx, memoryStore, notificationQueue)
-	
+	go serveGRPC(ctx, &servers, dataStore, notificationQueue)
+
 	// Create HTTP/GraphQL server
-	go serveHTTP(ctx, memoryStore, notificationQueue)
-	
+	go serveHTTP(ctx, &servers, dataStore, notificationQueue, hub, events)
+
 	// Wait for shutdown signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	
+
 	log.Println("Shutting down servers...")
 	cancel()
-	
-	// Shutdown notification queue
-	notificationQueue.Stop()
-	
+
+	// Let in-flight requests finish before the queue stops taking their
+	// notifications
+	servers.Wait()
+
+	// Shutdown notification queue, delivering what is already buffered;
+	// anything left over is recovered on the next start
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), *drainTimeout)
+	if err := notificationQueue.Shutdown(drainCtx); err != nil {
+		log.Printf("Notification queue not drained: %v", err)
+	}
+	drainCancel()
+
+	// Flush persistent stores
+	if closer, ok := dataStore.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("Failed to close store: %v", err)
+		}
+	}
+
 	log.Println("Server gracefully stopped")
 }
 
-func serveGRPC(ctx context.Context, store *store.MemoryStore, queue *queue.NotificationQueue) {
+// openStore creates the storage backend selected on the command line
+func openStore(backend, dir string) (store.Store, error) {
+	switch backend {
+	case "memory":
+		return store.NewMemoryStore(true), nil
+	case "file":
+		return store.NewFileStore(dir, true)
+	case "bolt":
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+		return store.NewBoltStore(filepath.Join(dir, "dnds.db"), true)
+	case "sqlite":
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+		return store.OpenSQLite(filepath.Join(dir, "dnds.sqlite"), true)
+	default:
+		return nil, fmt.Errorf("unknown store backend %q", backend)
+	}
+}
+
+// newDeliverers creates the delivery channels selected on the command line
+func newDeliverers(kinds string, dataStore store.Store) (delivery.Deliverer, error) {
+	var deliverers []delivery.Deliverer
+	for _, kind := range strings.Split(kinds, ",") {
+		kind = strings.TrimSpace(kind)
+		deliverer, err := newDeliverer(kind, dataStore)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", kind, err)
+		}
+		// Named so retry policies can be chosen per channel
+		deliverers = append(deliverers, delivery.WithChannel(kind, deliverer))
+	}
+
+	if len(deliverers) == 1 {
+		return deliverers[0], nil
+	}
+	return delivery.NewMultiDeliverer(deliverers...), nil
+}
+
+// newDeliverer creates a single delivery channel
+func newDeliverer(kind string, dataStore store.Store) (delivery.Deliverer, error) {
+	switch kind {
+	case "simulated":
+		return delivery.NewSimulatedDeliverer(*simMinLatency, *simMaxLatency, *simFailureRate), nil
+	case "webhook":
+		perUser, err := readUserMap(*webhookEndpoints)
+		if err != nil {
+			return nil, err
+		}
+		if *webhookSecret == "" {
+			return nil, fmt.Errorf("-webhook-secret or DNDS_WEBHOOK_SECRET is required")
+		}
+		registry := delivery.NewStaticWebhookRegistry(*webhookURL, perUser)
+		return delivery.NewWebhookDeliverer(registry, *webhookSecret, *webhookTimeout), nil
+	case "email":
+		addresses, err := readUserMap(*emailAddresses)
+		if err != nil {
+			return nil, err
+		}
+		templates, err := delivery.DefaultEmailTemplates()
+		if *emailTemplates != "" {
+			templates, err = delivery.LoadEmailTemplates(*emailTemplates)
+		}
+		if err != nil {
+			return nil, err
+		}
+		config := delivery.SMTPConfig{
+			Host:     *smtpHost,
+			Port:     *smtpPort,
+			Username: *smtpUsername,
+			Password: *smtpPassword,
+			From:     *smtpFrom,
+			StartTLS: *smtpStartTLS,
+			Timeout:  30 * time.Second,
+		}
+		return delivery.NewEmailDeliverer(config, dataStore, delivery.NewStaticEmailDirectory(addresses), templates), nil
+	default:
+		return nil, fmt.Errorf("unknown deliverer %q", kind)
+	}
+}
+
+// readUserMap reads a JSON object keyed by user ID; an empty path yields an empty map
+func readUserMap(path string) (map[string]string, error) {
+	m := map[string]string{}
+	if path == "" {
+		return m, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return m, nil
+}
+
+func serveGRPC(ctx context.Context, done *sync.WaitGroup, store store.Store, queue *queue.NotificationQueue) {
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
 	if err != nil {
 		log.Fatalf("Failed to listen on port %d: %v", grpcPort, err)
 	}
-	
+
 	notificationService := service.NewNotificationService(store, queue)
-	
+
 	grpcServer := grpc.NewServer()
 	proto.RegisterNotificationServiceServer(grpcServer, notificationService)
-	
+
 	log.Printf("gRPC server started on port %d", grpcPort)
-	
+
 	go func() {
+		defer done.Done()
 		<-ctx.Done()
 		log.Println("Stopping gRPC server...")
-		grpcServer.GracefulStop()
+
+		// Notification streams stay open until their clients leave, so
+		// they are cut off once the shutdown timeout is up
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(shutdownTimeout):
+			grpcServer.Stop()
+		}
 	}()
-	
+
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("Failed to serve gRPC: %v", err)
 	}
 }
 
-func serveHTTP(ctx context.Context, store *store.MemoryStore, queue *queue.NotificationQueue) {
+func serveHTTP(ctx context.Context, done *sync.WaitGroup, store store.Store, queue *queue.NotificationQueue, hub *realtime.Hub, events *realtime.SSEHandler) {
 	// Load GraphQL schema
 	schemaContent, err := ioutil.ReadFile("internal/graphql/schema/schema.graphql")
 	if err != nil {
 		log.Fatalf("Failed to read schema: %v", err)
 	}
-	
+
 	// Create resolver
 	r := resolver.NewResolver(store, queue)
-	
+
 	// Parse schema
 	schema := graphql.MustParseSchema(string(schemaContent), r)
-	
-	// Create GraphQL handler
-	graphqlHandler := &relay.Handler{Schema: schema}
-	
+
+	// Create GraphQL handler; every query gets its own batching loaders
+	graphqlHandler := loader.Middleware(store, &relay.Handler{Schema: schema})
+
 	// Setup HTTP server
 	mux := http.NewServeMux()
-	
-	// GraphQL endpoint
-	mux.Handle("/graphql", graphqlHandler)
-	
+
+	// GraphQL endpoint; subscriptions are served over the graphql-ws
+	// WebSocket subprotocol on the same path
+	mux.Handle("/graphql", graphqlws.NewHandlerFunc(schema, graphqlHandler))
+
+	// WebSocket push endpoint
+	mux.Handle("/ws", hub)
+
+	// Server-Sent Events endpoint
+	mux.Handle("/events", events)
+
 	// Metrics endpoint
 	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
 		metrics := queue.GetMetrics()
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(metrics)
 	})
-	
+
 	// Simple health check
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
-	
+
 	// Create server
 	httpServer := &http.Server{
 		Addr:    fmt.Sprintf(":%d", httpPort),
 		Handler: mux,
 	}
-	
+
 	// Start server
 	log.Printf("HTTP server started on port %d", httpPort)
 	log.Printf("GraphQL endpoint available at http://localhost:%d/graphql", httpPort)
+	log.Printf("WebSocket notifications available at ws://localhost:%d/ws", httpPort)
+	log.Printf("Server-Sent Events available at http://localhost:%d/events?token=<token>", httpPort)
 	log.Printf("Metrics available at http://localhost:%d/metrics", httpPort)
-	
+
 	go func() {
+		defer done.Done()
 		<-ctx.Done()
 		log.Println("Stopping HTTP server...")
-		
+
+		// Shutdown neither closes hijacked WebSocket connections nor waits
+		// out long-lived event streams on its own
+		hub.Close()
+		events.Close()
+
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 		defer cancel()
-		
+
 		if err := httpServer.Shutdown(shutdownCtx); err != nil {
-			log.Fatalf("HTTP server shutdown error: %v", err)
+			log.Printf("HTTP server shutdown error: %v", err)
 		}
 	}()
-	
+
 	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
 		log.Fatalf("HTTP server error: %v", err)
 	}
-}
\ No newline at end of file
+}
@@ -10,6 +10,23 @@ require (
 	google.golang.org/protobuf v1.36.6
 )
 
+require (
+	github.com/gorilla/websocket v1.5.3
+	github.com/graph-gophers/graphql-transport-ws v0.0.2
+	go.etcd.io/bbolt v1.3.11
+	modernc.org/sqlite v1.34.5
+)
+
+require (
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	modernc.org/libc v1.55.3 // indirect
+	modernc.org/mathutil v1.6.0 // indirect
+	modernc.org/memory v1.8.0 // indirect
+)
+
 require (
 	github.com/graph-gophers/graphql-go v1.6.0
 	golang.org/x/net v0.35.0 // indirect
@@ -0,0 +1,68 @@
+// Package auth identifies the user behind an HTTP request.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrMissingToken = errors.New("missing auth token")
+	ErrInvalidToken = errors.New("invalid auth token")
+)
+
+// Authenticator returns the ID of the user a request was made by
+type Authenticator interface {
+	Authenticate(r *http.Request) (string, error)
+}
+
+// TokenAuthenticator accepts tokens of the form "<userID>.<signature>", where
+// the signature is the hex HMAC-SHA256 of the user ID keyed with a server
+// secret. Tokens are read from an "Authorization: Bearer" header or, for
+// browser WebSocket and EventSource clients that cannot set headers, from the
+// "token" query parameter.
+type TokenAuthenticator struct {
+	secret []byte
+}
+
+// NewTokenAuthenticator creates an authenticator for tokens signed with secret
+func NewTokenAuthenticator(secret string) *TokenAuthenticator {
+	return &TokenAuthenticator{secret: []byte(secret)}
+}
+
+// Token issues a token for a user
+func (a *TokenAuthenticator) Token(userID string) string {
+	return userID + "." + a.sign(userID)
+}
+
+// Authenticate validates the request's token and returns its user ID
+func (a *TokenAuthenticator) Authenticate(r *http.Request) (string, error) {
+	token := r.URL.Query().Get("token")
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		token = strings.TrimPrefix(header, "Bearer ")
+	}
+	if token == "" {
+		return "", ErrMissingToken
+	}
+
+	// User IDs may contain dots, the signature never does
+	i := strings.LastIndexByte(token, '.')
+	if i <= 0 {
+		return "", ErrInvalidToken
+	}
+	userID, signature := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(signature), []byte(a.sign(userID))) {
+		return "", ErrInvalidToken
+	}
+	return userID, nil
+}
+
+func (a *TokenAuthenticator) sign(userID string) string {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(userID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,44 @@
+package delivery
+
+import (
+	"context"
+	"sync"
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+// AttemptRecorder collects the channel attempts made during one delivery.
+// Deliverers named with WithChannel record into the recorder carried by the
+// context they are called with; the caller fills in the outcome and backoff,
+// which only it decides.
+type AttemptRecorder struct {
+	mu       sync.Mutex
+	attempts []models.DeliveryAttempt
+}
+
+type attemptRecorderKey struct{}
+
+// WithAttemptRecorder returns a context in which named channels record their
+// attempts in r
+func WithAttemptRecorder(ctx context.Context, r *AttemptRecorder) context.Context {
+	return context.WithValue(ctx, attemptRecorderKey{}, r)
+}
+
+// attemptRecorderFrom returns the recorder carried by ctx, if any
+func attemptRecorderFrom(ctx context.Context) *AttemptRecorder {
+	r, _ := ctx.Value(attemptRecorderKey{}).(*AttemptRecorder)
+	return r
+}
+
+func (r *AttemptRecorder) record(attempt models.DeliveryAttempt) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts = append(r.attempts, attempt)
+}
+
+// Attempts returns the attempts recorded so far, in the order they finished
+func (r *AttemptRecorder) Attempts() []models.DeliveryAttempt {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.DeliveryAttempt(nil), r.attempts...)
+}
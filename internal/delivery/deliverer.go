@@ -0,0 +1,113 @@
+// Package delivery contains the channels notifications are delivered over.
+package delivery
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+// Deliverer sends a single notification to its recipient. The queue calls
+// Deliver from its workers, so implementations must be safe for concurrent use.
+type Deliverer interface {
+	Deliver(ctx context.Context, notification *models.Notification) error
+}
+
+// PermanentError wraps a delivery failure that retrying will not fix, such as
+// a recipient with no registered endpoint
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return "permanent delivery failure: " + e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent marks err as not worth retrying
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsRetryable reports whether a failed delivery should be attempted again.
+// Errors are retryable unless they are marked with Permanent.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var permanent *PermanentError
+	return !errors.As(err, &permanent)
+}
+
+// ChannelError records which delivery channel a failure came from, so retry
+// policies can be chosen per channel
+type ChannelError struct {
+	Channel string
+	Err     error
+}
+
+func (e *ChannelError) Error() string {
+	return e.Channel + ": " + e.Err.Error()
+}
+
+func (e *ChannelError) Unwrap() error {
+	return e.Err
+}
+
+// WithChannel names a deliverer's channel, e.g. "webhook"; its errors are
+// wrapped in a ChannelError, and its attempts are recorded in the context's
+// AttemptRecorder
+func WithChannel(channel string, deliverer Deliverer) Deliverer {
+	return &channelDeliverer{channel: channel, deliverer: deliverer}
+}
+
+type channelDeliverer struct {
+	channel   string
+	deliverer Deliverer
+}
+
+func (d *channelDeliverer) Deliver(ctx context.Context, notification *models.Notification) error {
+	startedAt := time.Now()
+	err := d.deliverer.Deliver(ctx, notification)
+	if recorder := attemptRecorderFrom(ctx); recorder != nil {
+		attempt := models.DeliveryAttempt{Channel: d.channel, StartedAt: startedAt, FinishedAt: time.Now()}
+		if err != nil {
+			attempt.Error = err.Error()
+			attempt.ErrorClass = string(Classify(err))
+		}
+		recorder.record(attempt)
+	}
+	if err != nil {
+		return &ChannelError{Channel: d.channel, Err: err}
+	}
+	return nil
+}
+
+// channelErrors returns the channel errors err is made of, including the
+// ones joined by MultiDeliverer
+func channelErrors(err error) []*ChannelError {
+	switch wrapped := err.(type) {
+	case nil:
+		return nil
+	case *ChannelError:
+		return []*ChannelError{wrapped}
+	case interface{ Unwrap() []error }:
+		var found []*ChannelError
+		for _, e := range wrapped.Unwrap() {
+			found = append(found, channelErrors(e)...)
+		}
+		return found
+	case interface{ Unwrap() error }:
+		return channelErrors(wrapped.Unwrap())
+	default:
+		return nil
+	}
+}
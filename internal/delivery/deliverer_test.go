@@ -0,0 +1,181 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+// deliverFunc delivers with a function and counts its calls
+type deliverFunc struct {
+	err   error
+	calls int
+}
+
+func (d *deliverFunc) Deliver(ctx context.Context, notification *models.Notification) error {
+	d.calls++
+	return d.err
+}
+
+func TestIsRetryable(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain error", boom, true},
+		{"permanent", Permanent(boom), false},
+		{"wrapped permanent", fmt.Errorf("webhook: %w", Permanent(boom)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) != nil")
+	}
+	if !errors.Is(Permanent(boom), boom) {
+		t.Error("Permanent does not unwrap to the error it marks")
+	}
+}
+
+func TestSimulatedDeliverer(t *testing.T) {
+	n := &models.Notification{ID: "n1"}
+
+	if err := NewSimulatedDeliverer(0, 0, 0).Deliver(context.Background(), n); err != nil {
+		t.Errorf("Deliver with failure rate 0 = %v", err)
+	}
+	err := NewSimulatedDeliverer(0, 0, 1).Deliver(context.Background(), n)
+	if !errors.Is(err, ErrSimulatedFailure) || !IsRetryable(err) {
+		t.Errorf("Deliver with failure rate 1 = %v, want retryable ErrSimulatedFailure", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if err := NewSimulatedDeliverer(time.Minute, time.Minute, 0).Deliver(ctx, n); err != context.Canceled {
+		t.Errorf("Deliver after cancellation = %v, want %v", err, context.Canceled)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Deliver waited out its latency after cancellation")
+	}
+}
+
+func TestChannelErrorUnwraps(t *testing.T) {
+	statusErr := &HTTPStatusError{URL: "http://example.com", StatusCode: 503}
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"retryable", statusErr, true},
+		{"permanent inside the channel", Permanent(statusErr), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := WithChannel("webhook", &deliverFunc{err: tt.err}).Deliver(context.Background(), &models.Notification{})
+
+			var channelErr *ChannelError
+			if !errors.As(err, &channelErr) || channelErr.Channel != "webhook" {
+				t.Fatalf("Deliver = %v, want a webhook ChannelError", err)
+			}
+			var gotStatus *HTTPStatusError
+			if !errors.As(err, &gotStatus) || gotStatus != statusErr {
+				t.Errorf("errors.As did not reach the HTTPStatusError through %v", err)
+			}
+			if IsRetryable(err) != tt.retryable {
+				t.Errorf("IsRetryable = %v, want %v", !tt.retryable, tt.retryable)
+			}
+			if !strings.HasPrefix(err.Error(), "webhook: ") {
+				t.Errorf("Error() = %q, want the channel first", err.Error())
+			}
+		})
+	}
+
+	if err := WithChannel("webhook", &deliverFunc{}).Deliver(context.Background(), &models.Notification{}); err != nil {
+		t.Errorf("Deliver = %v, want nil for a successful channel", err)
+	}
+}
+
+func TestMultiDelivererPartialFailure(t *testing.T) {
+	retryable := &HTTPStatusError{URL: "http://example.com", StatusCode: 503}
+	permanent := Permanent(errors.New("no address"))
+
+	tests := []struct {
+		name      string
+		errs      []error // per channel
+		wantErr   bool
+		retryable bool
+		reported  []string // channels named in the error; errors.As finds the first
+	}{
+		{"all delivered", []error{nil, nil}, false, false, nil},
+		{"one retryable", []error{nil, retryable}, true, true, []string{"email"}},
+		{"one permanent", []error{permanent, nil}, true, false, []string{"webhook"}},
+		{"retryable wins over permanent", []error{permanent, retryable}, true, true, []string{"email"}},
+		{"all permanent", []error{permanent, permanent}, true, false, []string{"webhook", "email"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook, email := &deliverFunc{err: tt.errs[0]}, &deliverFunc{err: tt.errs[1]}
+			m := NewMultiDeliverer(WithChannel("webhook", webhook), WithChannel("email", email))
+
+			err := m.Deliver(context.Background(), &models.Notification{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Deliver = %v, want error %v", err, tt.wantErr)
+			}
+			// A failing channel does not stop the others
+			if webhook.calls != 1 || email.calls != 1 {
+				t.Errorf("channels called %d and %d times, want once each", webhook.calls, email.calls)
+			}
+			if err == nil {
+				return
+			}
+			if IsRetryable(err) != tt.retryable {
+				t.Errorf("IsRetryable(%v) = %v, want %v", err, !tt.retryable, tt.retryable)
+			}
+			for _, channel := range tt.reported {
+				if !strings.Contains(err.Error(), channel+": ") {
+					t.Errorf("Deliver = %v, want it to report %s", err, channel)
+				}
+			}
+			var channelErr *ChannelError
+			if !errors.As(err, &channelErr) || channelErr.Channel != tt.reported[0] {
+				t.Errorf("errors.As found %v, want the %s ChannelError", channelErr, tt.reported[0])
+			}
+			if tt.retryable {
+				var statusErr *HTTPStatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != 503 {
+					t.Errorf("errors.As did not reach the retryable HTTPStatusError through %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestMultiDelivererRetriesOnlyFailedChannels(t *testing.T) {
+	webhook, email, unnamed := &deliverFunc{}, &deliverFunc{}, &deliverFunc{}
+	m := NewMultiDeliverer(WithChannel("webhook", webhook), WithChannel("email", email), unnamed)
+
+	// The webhook got through on the first attempt, the email did not
+	notification := &models.Notification{DeliveryAttempts: []models.DeliveryAttempt{
+		{Attempt: 1, Channel: "webhook", Outcome: models.OutcomeDelivered},
+		{Attempt: 1, Channel: "email", Outcome: models.OutcomeRetrying, Error: "421 try again later"},
+	}}
+	if err := m.Deliver(context.Background(), notification); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if webhook.calls != 0 || email.calls != 1 || unnamed.calls != 1 {
+		t.Errorf("webhook, email and unnamed channel called %d, %d and %d times, want 0, 1 and 1", webhook.calls, email.calls, unnamed.calls)
+	}
+}
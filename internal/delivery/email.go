@@ -0,0 +1,355 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+// Template file names, looked up in the directory passed to LoadEmailTemplates
+const (
+	subjectTemplateFile  = "subject.tmpl"
+	textBodyTemplateFile = "body.txt.tmpl"
+	htmlBodyTemplateFile = "body.html.tmpl"
+)
+
+//go:embed templates/email/*.tmpl
+var defaultEmailTemplates embed.FS
+
+// SMTPConfig describes how to reach the outgoing mail server
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // AUTH is skipped when empty
+	Password string
+	From     string
+
+	// StartTLS requires the connection to be upgraded before AUTH or MAIL
+	StartTLS bool
+	// TLSConfig is used for STARTTLS; nil means verify against Host
+	TLSConfig *tls.Config
+
+	// Timeout bounds a whole SMTP conversation; zero means no limit beyond
+	// the delivery context
+	Timeout time.Duration
+}
+
+// EmailDirectory resolves the address a user receives notification emails at.
+// Users without an address have not opted in to email.
+type EmailDirectory interface {
+	EmailAddress(userID string) (string, bool)
+}
+
+// StaticEmailDirectory is an in-memory EmailDirectory
+type StaticEmailDirectory struct {
+	mu        sync.RWMutex
+	addresses map[string]string
+}
+
+// NewStaticEmailDirectory creates a directory from a user ID to address map
+func NewStaticEmailDirectory(addresses map[string]string) *StaticEmailDirectory {
+	d := &StaticEmailDirectory{addresses: make(map[string]string, len(addresses))}
+	for userID, address := range addresses {
+		d.addresses[userID] = address
+	}
+	return d
+}
+
+// Subscribe opts a user in to notification emails
+func (d *StaticEmailDirectory) Subscribe(userID, address string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.addresses[userID] = address
+}
+
+// Unsubscribe opts a user out of notification emails
+func (d *StaticEmailDirectory) Unsubscribe(userID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.addresses, userID)
+}
+
+// EmailAddress returns the address a user opted in with
+func (d *StaticEmailDirectory) EmailAddress(userID string) (string, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	address, ok := d.addresses[userID]
+	return address, ok
+}
+
+// EmailData is what the email templates are executed with
+type EmailData struct {
+	Notification *models.Notification
+	Post         *models.Post
+	Author       *models.User
+	Recipient    *models.User
+}
+
+// EmailTemplates renders the subject and the plain-text and HTML bodies
+type EmailTemplates struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// DefaultEmailTemplates returns the templates built into the binary
+func DefaultEmailTemplates() (*EmailTemplates, error) {
+	sub, err := fs.Sub(defaultEmailTemplates, "templates/email")
+	if err != nil {
+		return nil, err
+	}
+	return parseEmailTemplates(sub)
+}
+
+// LoadEmailTemplates reads subject.tmpl, body.txt.tmpl and body.html.tmpl
+// from dir
+func LoadEmailTemplates(dir string) (*EmailTemplates, error) {
+	return parseEmailTemplates(os.DirFS(dir))
+}
+
+func parseEmailTemplates(fsys fs.FS) (*EmailTemplates, error) {
+	subject, err := texttemplate.ParseFS(fsys, subjectTemplateFile)
+	if err != nil {
+		return nil, fmt.Errorf("parse subject template: %w", err)
+	}
+	text, err := texttemplate.ParseFS(fsys, textBodyTemplateFile)
+	if err != nil {
+		return nil, fmt.Errorf("parse text body template: %w", err)
+	}
+	html, err := htmltemplate.ParseFS(fsys, htmlBodyTemplateFile)
+	if err != nil {
+		return nil, fmt.Errorf("parse html body template: %w", err)
+	}
+	return &EmailTemplates{subject: subject, text: text, html: html}, nil
+}
+
+// Render executes the templates for one email
+func (t *EmailTemplates) Render(data *EmailData) (subject, text, html string, err error) {
+	var buf bytes.Buffer
+	if err := t.subject.Execute(&buf, data); err != nil {
+		return "", "", "", fmt.Errorf("render subject: %w", err)
+	}
+	// Header values must be a single line
+	subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	if err := t.text.Execute(&buf, data); err != nil {
+		return "", "", "", fmt.Errorf("render text body: %w", err)
+	}
+	text = buf.String()
+
+	buf.Reset()
+	if err := t.html.Execute(&buf, data); err != nil {
+		return "", "", "", fmt.Errorf("render html body: %w", err)
+	}
+	html = buf.String()
+
+	return subject, text, html, nil
+}
+
+// EmailDeliverer emails followers who opted in about new posts. Recipients
+// without an address are skipped, SMTP 5xx replies and rendering problems are
+// permanent, and everything else is retried.
+type EmailDeliverer struct {
+	config    SMTPConfig
+	store     store.Store
+	directory EmailDirectory
+	templates *EmailTemplates
+}
+
+// NewEmailDeliverer creates an email deliverer. The store is used to look up
+// the post, its author and the recipient for the templates.
+func NewEmailDeliverer(config SMTPConfig, store store.Store, directory EmailDirectory, templates *EmailTemplates) *EmailDeliverer {
+	return &EmailDeliverer{
+		config:    config,
+		store:     store,
+		directory: directory,
+		templates: templates,
+	}
+}
+
+// Deliver renders and sends the notification email
+func (d *EmailDeliverer) Deliver(ctx context.Context, notification *models.Notification) error {
+	to, ok := d.directory.EmailAddress(notification.UserID)
+	if !ok {
+		return nil
+	}
+
+	data, err := d.emailData(notification)
+	if errors.Is(err, store.ErrUserNotFound) || errors.Is(err, store.ErrPostNotFound) {
+		// Deleted since the notification was queued, so a retry cannot help
+		return Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	subject, text, html, err := d.templates.Render(data)
+	if err != nil {
+		return Permanent(err)
+	}
+	msg, err := d.buildMessage(notification, to, subject, text, html)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	return classifySMTPError(d.send(ctx, to, msg))
+}
+
+// emailData loads everything the templates refer to
+func (d *EmailDeliverer) emailData(notification *models.Notification) (*EmailData, error) {
+	post, err := d.store.GetPost(notification.PostID)
+	if err != nil {
+		return nil, fmt.Errorf("load post %s: %w", notification.PostID, err)
+	}
+	author, err := d.store.GetUser(notification.AuthorID)
+	if err != nil {
+		return nil, fmt.Errorf("load author %s: %w", notification.AuthorID, err)
+	}
+	recipient, err := d.store.GetUser(notification.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("load recipient %s: %w", notification.UserID, err)
+	}
+	return &EmailData{Notification: notification, Post: post, Author: author, Recipient: recipient}, nil
+}
+
+// buildMessage assembles a multipart/alternative RFC 5322 message
+func (d *EmailDeliverer) buildMessage(notification *models.Notification, to, subject, text, html string) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", html},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(normalizeCRLF(part.content))); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	headers := []struct{ key, value string }{
+		{"From", d.config.From},
+		{"To", to},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", notification.ID, d.config.Host)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + parts.Boundary()},
+	}
+	for _, h := range headers {
+		fmt.Fprintf(&msg, "%s: %s\r\n", h.key, h.value)
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
+
+// send runs one SMTP conversation
+func (d *EmailDeliverer) send(ctx context.Context, to string, msg []byte) error {
+	if d.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.config.Timeout)
+		defer cancel()
+	}
+
+	addr := net.JoinHostPort(d.config.Host, strconv.Itoa(d.config.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("dial smtp %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, d.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake: %w", err)
+	}
+	defer client.Close()
+
+	if d.config.StartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return Permanent(fmt.Errorf("smtp server %s does not support STARTTLS", addr))
+		}
+		tlsConfig := d.config.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: d.config.Host}
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+
+	if d.config.Username != "" {
+		auth := smtp.PlainAuth("", d.config.Username, d.config.Password, d.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(d.config.From); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("smtp rcpt to: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("smtp write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	return client.Quit()
+}
+
+// classifySMTPError marks permanent (5xx) SMTP replies as not retryable
+func classifySMTPError(err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return Permanent(err)
+	}
+	return err
+}
+
+// normalizeCRLF converts bare line feeds to the CRLF line endings SMTP requires
+func normalizeCRLF(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+}
@@ -0,0 +1,209 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/delivery/smtptest"
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+// newTestEmailDeliverer emails user2 at bob@example.com through server
+func newTestEmailDeliverer(t *testing.T, server *smtptest.Server, st store.Store, configure func(*SMTPConfig)) *EmailDeliverer {
+	t.Helper()
+	templates, err := DefaultEmailTemplates()
+	if err != nil {
+		t.Fatalf("DefaultEmailTemplates: %v", err)
+	}
+	config := SMTPConfig{
+		Host:    server.Host,
+		Port:    server.Port,
+		From:    "notifications@example.com",
+		Timeout: 5 * time.Second,
+	}
+	if configure != nil {
+		configure(&config)
+	}
+	directory := NewStaticEmailDirectory(map[string]string{"user2": "bob@example.com"})
+	return NewEmailDeliverer(config, st, directory, templates)
+}
+
+// newPostNotification returns a notification of post1 by user1 for userID
+func newPostNotification(t *testing.T, st store.Store, userID string) *models.Notification {
+	t.Helper()
+	post, err := st.GetPost("post1")
+	if err != nil {
+		t.Fatalf("GetPost: %v", err)
+	}
+	return models.NewNotification(userID, post)
+}
+
+func TestEmailDelivererSends(t *testing.T) {
+	server := smtptest.NewServer()
+	defer server.Close()
+	st := store.NewMemoryStore(true)
+	d := newTestEmailDeliverer(t, server, st, nil)
+
+	if err := d.Deliver(context.Background(), newPostNotification(t, st, "user2")); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	// Users without an address are skipped
+	if err := d.Deliver(context.Background(), newPostNotification(t, st, "user3")); err != nil {
+		t.Fatalf("Deliver to a user without an address: %v", err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("server got %d messages, want 1", len(messages))
+	}
+	msg := messages[0]
+	if msg.From != "notifications@example.com" || len(msg.To) != 1 || msg.To[0] != "bob@example.com" {
+		t.Errorf("message from %s to %v", msg.From, msg.To)
+	}
+	if !strings.Contains(string(msg.Data), "multipart/alternative") || !strings.Contains(string(msg.Data), "Hello world from Alice!") {
+		t.Errorf("message does not carry the post in both bodies:\n%s", msg.Data)
+	}
+}
+
+func TestEmailDelivererStartTLSAndAuth(t *testing.T) {
+	server := smtptest.NewStartTLSServer()
+	defer server.Close()
+	server.Username, server.Password = "dnds", "secret"
+	st := store.NewMemoryStore(true)
+
+	d := newTestEmailDeliverer(t, server, st, func(c *SMTPConfig) {
+		c.StartTLS = true
+		c.TLSConfig = server.ClientTLSConfig()
+		c.Username, c.Password = "dnds", "secret"
+	})
+	if err := d.Deliver(context.Background(), newPostNotification(t, st, "user2")); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	messages := server.Messages()
+	if len(messages) != 1 || !messages[0].TLS || messages[0].Username != "dnds" {
+		t.Fatalf("messages = %+v, want one sent over TLS as dnds", messages)
+	}
+
+	tests := []struct {
+		name      string
+		configure func(*SMTPConfig)
+		retryable bool
+	}{
+		// 535 is a permanent reply
+		{"wrong password", func(c *SMTPConfig) {
+			c.StartTLS = true
+			c.TLSConfig = server.ClientTLSConfig()
+			c.Username, c.Password = "dnds", "wrong"
+		}, false},
+		// Without trusting the certificate the handshake fails
+		{"untrusted certificate", func(c *SMTPConfig) {
+			c.StartTLS = true
+			c.Username, c.Password = "dnds", "secret"
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestEmailDeliverer(t, server, st, tt.configure)
+			err := d.Deliver(context.Background(), newPostNotification(t, st, "user2"))
+			if err == nil {
+				t.Fatal("Deliver succeeded")
+			}
+			if IsRetryable(err) != tt.retryable {
+				t.Errorf("IsRetryable(%v) = %v, want %v", err, !tt.retryable, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestEmailDelivererRequiresStartTLS(t *testing.T) {
+	server := smtptest.NewServer()
+	defer server.Close()
+	st := store.NewMemoryStore(true)
+
+	d := newTestEmailDeliverer(t, server, st, func(c *SMTPConfig) { c.StartTLS = true })
+	err := d.Deliver(context.Background(), newPostNotification(t, st, "user2"))
+	if err == nil || IsRetryable(err) {
+		t.Fatalf("Deliver = %v, want a permanent error from a server without STARTTLS", err)
+	}
+	if len(server.Messages()) != 0 {
+		t.Error("message sent in the clear")
+	}
+}
+
+func TestEmailDelivererClassifiesReplies(t *testing.T) {
+	server := smtptest.NewServer()
+	defer server.Close()
+	st := store.NewMemoryStore(true)
+	d := newTestEmailDeliverer(t, server, st, nil)
+
+	for _, tt := range []struct {
+		code      int
+		retryable bool
+	}{
+		{421, true},
+		{451, true},
+		{550, false},
+		{553, false},
+	} {
+		server.RejectRecipients = func(string) int { return tt.code }
+		err := d.Deliver(context.Background(), newPostNotification(t, st, "user2"))
+		var protoErr *textproto.Error
+		if !errors.As(err, &protoErr) || protoErr.Code != tt.code {
+			t.Fatalf("Deliver = %v, want the %d reply", err, tt.code)
+		}
+		if IsRetryable(err) != tt.retryable {
+			t.Errorf("%d reply: IsRetryable = %v, want %v", tt.code, !tt.retryable, tt.retryable)
+		}
+	}
+}
+
+func TestEmailDelivererDeletedAuthorIsPermanent(t *testing.T) {
+	server := smtptest.NewServer()
+	defer server.Close()
+	st := store.NewMemoryStore(true)
+	d := newTestEmailDeliverer(t, server, st, nil)
+
+	notification := newPostNotification(t, st, "user2")
+	if err := st.DeleteUser("user1"); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	err := d.Deliver(context.Background(), notification)
+	if err == nil || IsRetryable(err) {
+		t.Fatalf("Deliver = %v, want a permanent error", err)
+	}
+	if !errors.Is(err, store.ErrUserNotFound) {
+		t.Errorf("Deliver = %v, want it to wrap ErrUserNotFound", err)
+	}
+	if len(server.Messages()) != 0 {
+		t.Error("message sent for a deleted author")
+	}
+}
+
+// unavailableStore fails every lookup of a post as if the database were down
+type unavailableStore struct {
+	store.Store
+}
+
+func (unavailableStore) GetPost(id string) (*models.Post, error) {
+	return nil, errors.New("database is locked")
+}
+
+func TestEmailDelivererRetriesStoreErrors(t *testing.T) {
+	server := smtptest.NewServer()
+	defer server.Close()
+	st := store.NewMemoryStore(true)
+	d := newTestEmailDeliverer(t, server, unavailableStore{st}, nil)
+
+	err := d.Deliver(context.Background(), newPostNotification(t, st, "user2"))
+	if err == nil || !IsRetryable(err) {
+		t.Fatalf("Deliver = %v, want a retryable error", err)
+	}
+	if len(server.Messages()) != 0 {
+		t.Error("message sent without its post")
+	}
+}
@@ -0,0 +1,69 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+// MultiDeliverer fans a notification out to several channels, e.g. a webhook
+// and an email. A retry only attempts the channels that have not delivered
+// the notification yet according to its DeliveryAttempts; deliverers not
+// named with WithChannel leave no record there and are attempted every time.
+type MultiDeliverer struct {
+	deliverers []Deliverer
+}
+
+// NewMultiDeliverer creates a deliverer that sends through all of deliverers
+func NewMultiDeliverer(deliverers ...Deliverer) *MultiDeliverer {
+	return &MultiDeliverer{deliverers: deliverers}
+}
+
+// Deliver sends through every channel that has not delivered the notification
+// yet. The result is retryable if any channel failed with a retryable error,
+// and permanent if every failure was permanent.
+func (m *MultiDeliverer) Deliver(ctx context.Context, notification *models.Notification) error {
+	delivered := deliveredChannels(notification)
+	var retryable, permanent []error
+	for _, d := range m.deliverers {
+		if named, ok := d.(*channelDeliverer); ok && delivered[named.channel] {
+			continue
+		}
+		err := d.Deliver(ctx, notification)
+		switch {
+		case err == nil:
+		case IsRetryable(err):
+			retryable = append(retryable, err)
+		default:
+			permanent = append(permanent, err)
+		}
+	}
+
+	switch {
+	case len(retryable) > 0:
+		// Permanent failures are reported but must not stop the retry
+		err := errors.Join(retryable...)
+		if len(permanent) > 0 {
+			err = fmt.Errorf("%w; also failed permanently: %v", err, errors.Join(permanent...))
+		}
+		return err
+	case len(permanent) > 0:
+		return Permanent(errors.Join(permanent...))
+	default:
+		return nil
+	}
+}
+
+// deliveredChannels returns the channels a notification was delivered over in
+// earlier attempts
+func deliveredChannels(notification *models.Notification) map[string]bool {
+	delivered := make(map[string]bool)
+	for _, attempt := range notification.DeliveryAttempts {
+		if attempt.Channel != "" && attempt.Outcome == models.OutcomeDelivered {
+			delivered[attempt.Channel] = true
+		}
+	}
+	return delivered
+}
@@ -0,0 +1,376 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+// Jitter spreads retries of notifications that failed together, so a
+// recovering downstream is not hit by all of them at once
+type Jitter string
+
+const (
+	// JitterNone waits exactly the exponential backoff
+	JitterNone Jitter = "none"
+	// JitterFull waits a random time between zero and the backoff
+	JitterFull Jitter = "full"
+	// JitterEqual waits half the backoff plus a random time up to the other half
+	JitterEqual Jitter = "equal"
+	// JitterDecorrelated waits a random time between the base delay and three
+	// times the previous backoff
+	JitterDecorrelated Jitter = "decorrelated"
+)
+
+// ErrorClass groups delivery errors that share a retry decision
+type ErrorClass string
+
+const (
+	ErrorClassPermanent ErrorClass = "permanent"
+	ErrorClassTimeout   ErrorClass = "timeout"
+	ErrorClassHTTP429   ErrorClass = "http_429"
+	ErrorClassHTTP4xx   ErrorClass = "http_4xx"
+	ErrorClassHTTP5xx   ErrorClass = "http_5xx"
+	ErrorClassOther     ErrorClass = "other"
+)
+
+// Classify returns the class of a delivery error. Errors marked with
+// Permanent are ErrorClassPermanent whatever they wrap, and an HTTP 408
+// counts as a timeout.
+func Classify(err error) ErrorClass {
+	if !IsRetryable(err) {
+		return ErrorClassPermanent
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == 429:
+			return ErrorClassHTTP429
+		case statusErr.StatusCode == 408:
+			return ErrorClassTimeout
+		case statusErr.StatusCode >= 400 && statusErr.StatusCode < 500:
+			return ErrorClassHTTP4xx
+		case statusErr.StatusCode >= 500:
+			return ErrorClassHTTP5xx
+		}
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorClassTimeout
+	}
+	return ErrorClassOther
+}
+
+// RetryPolicy decides whether and when a failed delivery is attempted again.
+// Zero fields are inherited from the policy it overrides.
+type RetryPolicy struct {
+	// MaxAttempts counts every delivery, the first one included; 1 means
+	// never retry
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Jitter      Jitter
+	// Errors overrides the policy for some error classes, e.g. to not retry
+	// ErrorClassHTTP4xx
+	Errors map[ErrorClass]RetryPolicy
+}
+
+// DefaultRetryPolicy makes up to four attempts, doubling a 100ms backoff
+// with equal jitter. HTTP 4xx responses other than 408 and 429 are not
+// retried, since the request itself was refused.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+		Jitter:      JitterEqual,
+		Errors: map[ErrorClass]RetryPolicy{
+			ErrorClassHTTP4xx: {MaxAttempts: 1},
+		},
+	}
+}
+
+// inherit fills p's zero fields from parent. Error overrides are merged,
+// with p's own taking precedence.
+func (p RetryPolicy) inherit(parent RetryPolicy) RetryPolicy {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = parent.MaxAttempts
+	}
+	if p.BaseDelay == 0 {
+		p.BaseDelay = parent.BaseDelay
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = parent.MaxDelay
+	}
+	if p.Jitter == "" {
+		p.Jitter = parent.Jitter
+	}
+	if len(parent.Errors) > 0 {
+		errs := make(map[ErrorClass]RetryPolicy, len(parent.Errors)+len(p.Errors))
+		for class, override := range parent.Errors {
+			errs[class] = override
+		}
+		for class, override := range p.Errors {
+			errs[class] = override
+		}
+		p.Errors = errs
+	}
+	return p
+}
+
+// ForError returns the policy that applies to err, taking error class
+// overrides into account
+func (p RetryPolicy) ForError(err error) RetryPolicy {
+	if override, ok := p.Errors[Classify(err)]; ok {
+		return override.inherit(p)
+	}
+	return p
+}
+
+// ShouldRetry reports whether a delivery that failed with err after attempts
+// attempts gets another one
+func (p RetryPolicy) ShouldRetry(err error, attempts int) bool {
+	return IsRetryable(err) && attempts < p.MaxAttempts
+}
+
+// Backoff returns how long to wait after the given number of failed
+// attempts. Decorrelated jitter takes the previous backoff to be the
+// un-jittered one, so the policy needs no state.
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	backoff := p.exponential(attempts)
+	switch p.Jitter {
+	case JitterFull:
+		return randomBetween(0, backoff)
+	case JitterEqual:
+		return backoff/2 + randomBetween(0, backoff-backoff/2)
+	case JitterDecorrelated:
+		upper := 3 * p.exponential(attempts-1)
+		if p.MaxDelay > 0 && upper > p.MaxDelay {
+			upper = p.MaxDelay
+		}
+		return randomBetween(p.BaseDelay, upper)
+	default:
+		return backoff
+	}
+}
+
+// exponential returns BaseDelay doubled for every failed attempt after the
+// first, capped at MaxDelay
+func (p RetryPolicy) exponential(attempts int) time.Duration {
+	backoff := p.BaseDelay
+	for i := 1; i < attempts; i++ {
+		if p.MaxDelay > 0 && backoff >= p.MaxDelay {
+			break
+		}
+		backoff *= 2
+	}
+	if p.MaxDelay > 0 && backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+	return backoff
+}
+
+// randomBetween returns a random duration in [min, max]
+func randomBetween(min, max time.Duration) time.Duration {
+	if max <= min {
+		return min
+	}
+	return min + time.Duration(rand.Int63n(int64(max-min)+1))
+}
+
+// Validate reports settings that cannot work
+func (p RetryPolicy) Validate() error {
+	if p.MaxAttempts < 1 {
+		return fmt.Errorf("max attempts must be at least 1, got %d", p.MaxAttempts)
+	}
+	if p.BaseDelay < 0 || p.MaxDelay < 0 {
+		return errors.New("delays must not be negative")
+	}
+	switch p.Jitter {
+	case JitterNone, JitterFull, JitterEqual, JitterDecorrelated:
+	default:
+		return fmt.Errorf("unknown jitter %q", p.Jitter)
+	}
+	for class, override := range p.Errors {
+		override = override.inherit(p)
+		// Overrides are not nested, so only their own settings matter
+		override.Errors = nil
+		if err := override.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", class, err)
+		}
+	}
+	return nil
+}
+
+// RetryPolicies selects the retry policy for a failed delivery. Policies are
+// layered from the most specific: the notification's type over the channel
+// that failed over Default. Error class overrides from every layer apply, the
+// more specific layer winning for the same class.
+type RetryPolicies struct {
+	Default  RetryPolicy
+	Channels map[string]RetryPolicy
+	Types    map[models.NotificationType]RetryPolicy
+}
+
+// DefaultRetryPolicies applies DefaultRetryPolicy to every delivery
+func DefaultRetryPolicies() *RetryPolicies {
+	return &RetryPolicies{Default: DefaultRetryPolicy()}
+}
+
+// For returns the policy for a delivery of notification that failed with
+// err. When several channels failed, the policy allowing the most attempts
+// is used, since the channels are retried together.
+func (ps *RetryPolicies) For(notification *models.Notification, err error) RetryPolicy {
+	notificationType := notification.Type
+	if notificationType == "" {
+		// Stored before notifications had a type
+		notificationType = models.TypeNewPost
+	}
+	typePolicy, hasTypePolicy := ps.Types[notificationType]
+	layer := func(policy RetryPolicy) RetryPolicy {
+		if hasTypePolicy {
+			return typePolicy.inherit(policy)
+		}
+		return policy
+	}
+
+	var chosen *RetryPolicy
+	for _, channelErr := range channelErrors(err) {
+		if !IsRetryable(channelErr.Err) {
+			continue
+		}
+		policy := ps.Default
+		if channelPolicy, ok := ps.Channels[channelErr.Channel]; ok {
+			policy = channelPolicy.inherit(ps.Default)
+		}
+		policy = layer(policy).ForError(channelErr.Err)
+		if chosen == nil || policy.MaxAttempts > chosen.MaxAttempts {
+			chosen = &policy
+		}
+	}
+	if chosen != nil {
+		return *chosen
+	}
+	return layer(ps.Default).ForError(err)
+}
+
+// Validate reports settings that cannot work
+func (ps *RetryPolicies) Validate() error {
+	if err := ps.Default.Validate(); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	for channel, policy := range ps.Channels {
+		if err := policy.inherit(ps.Default).Validate(); err != nil {
+			return fmt.Errorf("channel %s: %w", channel, err)
+		}
+	}
+	for notificationType, policy := range ps.Types {
+		if err := policy.inherit(ps.Default).Validate(); err != nil {
+			return fmt.Errorf("type %s: %w", notificationType, err)
+		}
+	}
+	return nil
+}
+
+// retryPolicyFile is the JSON form of a RetryPolicy
+type retryPolicyFile struct {
+	MaxAttempts int                            `json:"max_attempts"`
+	BaseDelay   string                         `json:"base_delay"`
+	MaxDelay    string                         `json:"max_delay"`
+	Jitter      Jitter                         `json:"jitter"`
+	Errors      map[ErrorClass]retryPolicyFile `json:"errors"`
+}
+
+func (f retryPolicyFile) policy() (RetryPolicy, error) {
+	p := RetryPolicy{MaxAttempts: f.MaxAttempts, Jitter: f.Jitter}
+	var err error
+	if f.BaseDelay != "" {
+		if p.BaseDelay, err = time.ParseDuration(f.BaseDelay); err != nil {
+			return p, fmt.Errorf("base_delay: %w", err)
+		}
+	}
+	if f.MaxDelay != "" {
+		if p.MaxDelay, err = time.ParseDuration(f.MaxDelay); err != nil {
+			return p, fmt.Errorf("max_delay: %w", err)
+		}
+	}
+	for class, override := range f.Errors {
+		o, err := override.policy()
+		if err != nil {
+			return p, fmt.Errorf("%s: %w", class, err)
+		}
+		if p.Errors == nil {
+			p.Errors = make(map[ErrorClass]RetryPolicy)
+		}
+		p.Errors[class] = o
+	}
+	return p, nil
+}
+
+// LoadRetryPolicies reads retry policies from a JSON file such as
+//
+//	{
+//	  "default": {"max_attempts": 5, "base_delay": "200ms", "max_delay": "1m", "jitter": "full"},
+//	  "channels": {"webhook": {"errors": {"http_5xx": {"max_attempts": 8}}}},
+//	  "types": {"new_post": {"max_attempts": 3}}
+//	}
+//
+// Settings left out are taken from DefaultRetryPolicy, and channel and type
+// policies inherit from the default one.
+func LoadRetryPolicies(path string) (*RetryPolicies, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Default  retryPolicyFile            `json:"default"`
+		Channels map[string]retryPolicyFile `json:"channels"`
+		Types    map[string]retryPolicyFile `json:"types"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	policies := DefaultRetryPolicies()
+	defaultPolicy, err := file.Default.policy()
+	if err != nil {
+		return nil, fmt.Errorf("default: %w", err)
+	}
+	policies.Default = defaultPolicy.inherit(policies.Default)
+
+	for channel, f := range file.Channels {
+		policy, err := f.policy()
+		if err != nil {
+			return nil, fmt.Errorf("channel %s: %w", channel, err)
+		}
+		if policies.Channels == nil {
+			policies.Channels = make(map[string]RetryPolicy)
+		}
+		policies.Channels[channel] = policy
+	}
+	for notificationType, f := range file.Types {
+		policy, err := f.policy()
+		if err != nil {
+			return nil, fmt.Errorf("type %s: %w", notificationType, err)
+		}
+		if policies.Types == nil {
+			policies.Types = make(map[models.NotificationType]RetryPolicy)
+		}
+		policies.Types[models.NotificationType(notificationType)] = policy
+	}
+
+	if err := policies.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return policies, nil
+}
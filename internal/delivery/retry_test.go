@@ -0,0 +1,195 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+func TestRetryPoliciesFor(t *testing.T) {
+	policies := DefaultRetryPolicies()
+	policies.Default.MaxAttempts = 5
+	policies.Channels = map[string]RetryPolicy{
+		"webhook": {Errors: map[ErrorClass]RetryPolicy{
+			ErrorClassHTTP4xx: {MaxAttempts: 1},
+			ErrorClassHTTP429: {BaseDelay: 5 * time.Second},
+		}},
+		"email": {MaxAttempts: 2},
+	}
+	policies.Types = map[models.NotificationType]RetryPolicy{
+		models.TypeNewPost: {MaxAttempts: 3},
+	}
+	if err := policies.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	status := func(code int) error {
+		return &ChannelError{Channel: "webhook", Err: &HTTPStatusError{URL: "http://example.com", StatusCode: code}}
+	}
+	emailErr := &ChannelError{Channel: "email", Err: errors.New("connection reset")}
+
+	tests := []struct {
+		name             string
+		notificationType models.NotificationType
+		err              error
+		wantAttempts     int
+		wantBaseDelay    time.Duration
+		wantRetry        bool // after the first attempt
+	}{
+		{"channel error override beats type", models.TypeNewPost, status(400), 1, 100 * time.Millisecond, false},
+		{"type beats channel and default", models.TypeNewPost, status(503), 3, 100 * time.Millisecond, true},
+		{"channel error override keeps type attempts", models.TypeNewPost, status(429), 3, 5 * time.Second, true},
+		{"type beats channel attempts", models.TypeNewPost, emailErr, 3, 100 * time.Millisecond, true},
+		{"untyped is new_post", "", status(400), 1, 100 * time.Millisecond, false},
+		{"no type policy uses channel", "other", emailErr, 2, 100 * time.Millisecond, true},
+		{"no type policy uses channel error override", "other", status(400), 1, 100 * time.Millisecond, false},
+		{"no type policy falls back to default", "other", status(503), 5, 100 * time.Millisecond, true},
+		{"default does not retry 4xx", "other", &HTTPStatusError{StatusCode: 404}, 1, 100 * time.Millisecond, false},
+		{"default retries 408", "other", &HTTPStatusError{StatusCode: 408}, 5, 100 * time.Millisecond, true},
+		{"unnamed channel uses type", models.TypeNewPost, errors.New("boom"), 3, 100 * time.Millisecond, true},
+		{"most attempts across channels", "other", errors.Join(status(400), emailErr), 2, 100 * time.Millisecond, true},
+		{"permanent is not retried", models.TypeNewPost, Permanent(errors.New("no address")), 3, 100 * time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := policies.For(&models.Notification{Type: tt.notificationType}, tt.err)
+			if policy.MaxAttempts != tt.wantAttempts || policy.BaseDelay != tt.wantBaseDelay {
+				t.Errorf("For = %d attempts after %v, want %d after %v", policy.MaxAttempts, policy.BaseDelay, tt.wantAttempts, tt.wantBaseDelay)
+			}
+			if got := policy.ShouldRetry(tt.err, 1); got != tt.wantRetry {
+				t.Errorf("ShouldRetry(err, 1) = %v, want %v", got, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyForError(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   time.Second,
+		MaxDelay:    time.Minute,
+		Jitter:      JitterEqual,
+		Errors: map[ErrorClass]RetryPolicy{
+			ErrorClassTimeout: {MaxAttempts: 6, Jitter: JitterNone},
+			ErrorClassHTTP5xx: {MaxDelay: 10 * time.Second},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		err   error
+		class ErrorClass
+		want  RetryPolicy
+	}{
+		{"timeout override", context.DeadlineExceeded, ErrorClassTimeout, RetryPolicy{MaxAttempts: 6, BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: JitterNone}},
+		{"408 is a timeout", &HTTPStatusError{StatusCode: 408}, ErrorClassTimeout, RetryPolicy{MaxAttempts: 6, BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: JitterNone}},
+		{"5xx override", &HTTPStatusError{StatusCode: 502}, ErrorClassHTTP5xx, RetryPolicy{MaxAttempts: 4, BaseDelay: time.Second, MaxDelay: 10 * time.Second, Jitter: JitterEqual}},
+		{"no override", &HTTPStatusError{StatusCode: 404}, ErrorClassHTTP4xx, RetryPolicy{MaxAttempts: 4, BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: JitterEqual}},
+		{"other", errors.New("boom"), ErrorClassOther, RetryPolicy{MaxAttempts: 4, BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: JitterEqual}},
+		{"permanent", Permanent(&HTTPStatusError{StatusCode: 502}), ErrorClassPermanent, RetryPolicy{MaxAttempts: 4, BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: JitterEqual}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.class {
+				t.Errorf("Classify = %q, want %q", got, tt.class)
+			}
+			got := policy.ForError(tt.err)
+			if got.MaxAttempts != tt.want.MaxAttempts || got.BaseDelay != tt.want.BaseDelay ||
+				got.MaxDelay != tt.want.MaxDelay || got.Jitter != tt.want.Jitter {
+				t.Errorf("ForError = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoffJitterBounds(t *testing.T) {
+	base := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: 30 * time.Second}
+
+	tests := []struct {
+		jitter   Jitter
+		attempts int
+		min, max time.Duration
+	}{
+		{JitterNone, 1, 100 * time.Millisecond, 100 * time.Millisecond},
+		{JitterNone, 3, 400 * time.Millisecond, 400 * time.Millisecond},
+		{JitterFull, 3, 0, 400 * time.Millisecond},
+		{JitterEqual, 3, 200 * time.Millisecond, 400 * time.Millisecond},
+		{JitterDecorrelated, 3, 100 * time.Millisecond, 600 * time.Millisecond},
+		{JitterDecorrelated, 1, 100 * time.Millisecond, 300 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		policy := base
+		policy.Jitter = tt.jitter
+		for i := 0; i < 1000; i++ {
+			if got := policy.Backoff(tt.attempts); got < tt.min || got > tt.max {
+				t.Fatalf("%s jitter: Backoff(%d) = %v, want between %v and %v", tt.jitter, tt.attempts, got, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestRetryPolicyBackoffMaxDelay(t *testing.T) {
+	tests := []struct {
+		jitter   Jitter
+		attempts int
+		max      time.Duration
+	}{
+		{JitterNone, 10, time.Second},
+		{JitterNone, 1000, time.Second},
+		{JitterFull, 50, time.Second},
+		{JitterEqual, 50, time.Second},
+		{JitterDecorrelated, 50, time.Second},
+	}
+	for _, tt := range tests {
+		policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Jitter: tt.jitter}
+		for i := 0; i < 100; i++ {
+			if got := policy.Backoff(tt.attempts); got > tt.max {
+				t.Fatalf("%s jitter: Backoff(%d) = %v, want at most %v", tt.jitter, tt.attempts, got, tt.max)
+			}
+		}
+	}
+
+	exact := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Jitter: JitterNone}
+	if got := exact.Backoff(5); got != time.Second {
+		t.Errorf("Backoff(5) = %v, want the 1s cap rather than 1.6s", got)
+	}
+	if got := exact.Backoff(4); got != 800*time.Millisecond {
+		t.Errorf("Backoff(4) = %v, want 800ms", got)
+	}
+}
+
+func TestLoadRetryPoliciesReadmeExample(t *testing.T) {
+	path := t.TempDir() + "/retry.json"
+	config := `{
+  "default": {"max_attempts": 5, "base_delay": "200ms", "max_delay": "1m", "jitter": "full"},
+  "channels": {
+    "webhook": {"errors": {"http_5xx": {"max_attempts": 8}, "http_429": {"base_delay": "5s"}}}
+  },
+  "types": {"new_post": {"max_attempts": 3}}
+}`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	policies, err := LoadRetryPolicies(path)
+	if err != nil {
+		t.Fatalf("LoadRetryPolicies: %v", err)
+	}
+
+	notification := &models.Notification{Type: models.TypeNewPost}
+	err = &ChannelError{Channel: "webhook", Err: &HTTPStatusError{StatusCode: 503}}
+	if policy := policies.For(notification, err); policy.MaxAttempts != 8 {
+		t.Errorf("webhook 503 got %d attempts, want 8", policy.MaxAttempts)
+	}
+	err = &ChannelError{Channel: "webhook", Err: errors.New("connection reset")}
+	if policy := policies.For(notification, err); policy.MaxAttempts != 3 {
+		t.Errorf("other webhook failure got %d attempts, want the type's 3", policy.MaxAttempts)
+	}
+	err = &ChannelError{Channel: "webhook", Err: &HTTPStatusError{StatusCode: 400}}
+	if policy := policies.For(notification, err); policy.MaxAttempts != 1 || policy.ShouldRetry(err, 1) {
+		t.Errorf("webhook 400 got %d attempts, want the default's 1 and no retry", policy.MaxAttempts)
+	}
+}
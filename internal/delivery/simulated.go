@@ -0,0 +1,55 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+// ErrSimulatedFailure is the retryable error returned by SimulatedDeliverer
+var ErrSimulatedFailure = errors.New("simulated delivery failure")
+
+// SimulatedDeliverer pretends to deliver notifications. Each delivery takes a
+// random time between MinLatency and MaxLatency and fails with probability
+// FailureRate.
+type SimulatedDeliverer struct {
+	MinLatency  time.Duration
+	MaxLatency  time.Duration
+	FailureRate float64
+}
+
+// NewSimulatedDeliverer creates a simulated deliverer
+func NewSimulatedDeliverer(minLatency, maxLatency time.Duration, failureRate float64) *SimulatedDeliverer {
+	if maxLatency < minLatency {
+		maxLatency = minLatency
+	}
+	return &SimulatedDeliverer{
+		MinLatency:  minLatency,
+		MaxLatency:  maxLatency,
+		FailureRate: failureRate,
+	}
+}
+
+// Deliver simulates processing delay and random failures
+func (d *SimulatedDeliverer) Deliver(ctx context.Context, notification *models.Notification) error {
+	latency := d.MinLatency
+	if spread := d.MaxLatency - d.MinLatency; spread > 0 {
+		latency += time.Duration(rand.Int63n(int64(spread)))
+	}
+
+	timer := time.NewTimer(latency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if rand.Float64() < d.FailureRate {
+		return ErrSimulatedFailure
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+// Package smtptest provides an in-process SMTP server for exercising
+// delivery.EmailDeliverer without a real mail server, in the spirit of
+// net/http/httptest.
+package smtptest
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is an email accepted by the server
+type Message struct {
+	From string
+	To   []string
+	Data []byte
+	// Username is who the session authenticated as, empty without AUTH
+	Username string
+	// TLS reports whether the session was upgraded with STARTTLS
+	TLS bool
+}
+
+// Server is a minimal SMTP server listening on a loopback address. It speaks
+// enough of RFC 5321 for net/smtp: EHLO/HELO, STARTTLS, AUTH PLAIN, MAIL,
+// RCPT, DATA, RSET, NOOP and QUIT.
+type Server struct {
+	// Addr is the host:port the server listens on
+	Addr string
+	// Host and Port are Addr split for SMTP client configuration
+	Host string
+	Port int
+
+	// RejectRecipients, when set, is called for every RCPT TO address; a
+	// non-zero reply code (e.g. 550 or 451) rejects it
+	RejectRecipients func(address string) int
+
+	// Username and Password, when Username is set, are the only credentials
+	// AUTH accepts, and MAIL is refused until a session has authenticated
+	Username string
+	Password string
+
+	listener net.Listener
+	tls      *tls.Config
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewServer starts a server on 127.0.0.1 with an OS-assigned port. Callers
+// must Close it.
+func NewServer() *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("smtptest: failed to listen: %v", err))
+	}
+
+	addr := l.Addr().(*net.TCPAddr)
+	s := &Server{
+		Addr:     addr.String(),
+		Host:     addr.IP.String(),
+		Port:     addr.Port,
+		listener: l,
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// NewStartTLSServer starts a server like NewServer that also offers
+// STARTTLS, with a self-signed certificate for 127.0.0.1. ClientTLSConfig
+// returns a configuration that trusts it.
+func NewStartTLSServer() *Server {
+	cert, err := selfSignedCertificate()
+	if err != nil {
+		panic(fmt.Sprintf("smtptest: failed to create certificate: %v", err))
+	}
+	s := NewServer()
+	s.tls = &tls.Config{Certificates: []tls.Certificate{cert}}
+	return s
+}
+
+// ClientTLSConfig returns a TLS configuration for clients of a server
+// started with NewStartTLSServer
+func (s *Server) ClientTLSConfig() *tls.Config {
+	pool := x509.NewCertPool()
+	if s.tls != nil {
+		pool.AddCert(s.tls.Certificates[0].Leaf)
+	}
+	return &tls.Config{RootCAs: pool, ServerName: s.Host}
+}
+
+// Messages returns a copy of every message accepted so far
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := make([]Message, len(s.messages))
+	copy(messages, s.messages)
+	return messages
+}
+
+// Close stops the server and waits for open sessions to finish
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+// handle runs one SMTP session
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	reply := func(format string, args ...interface{}) {
+		fmt.Fprintf(w, format+"\r\n", args...)
+		w.Flush()
+	}
+
+	var msg Message
+	var username string
+	upgraded := false
+	reply("220 %s smtptest ready", s.Host)
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-%s greets %s", s.Host, arg)
+			reply("250-8BITMIME")
+			if s.tls != nil && !upgraded {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN")
+		case "HELO":
+			reply("250 %s", s.Host)
+		case "STARTTLS":
+			if s.tls == nil || upgraded {
+				reply("502 5.5.1 STARTTLS not available")
+				continue
+			}
+			reply("220 2.0.0 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			r = bufio.NewReader(conn)
+			w = bufio.NewWriter(conn)
+			upgraded = true
+			msg, username = Message{}, ""
+		case "AUTH":
+			user, ok := s.authenticate(arg)
+			if !ok {
+				reply("535 5.7.8 Authentication credentials invalid")
+				continue
+			}
+			username = user
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			if s.Username != "" && username == "" {
+				reply("530 5.7.0 Authentication required")
+				continue
+			}
+			msg = Message{From: addressArg(arg), Username: username, TLS: upgraded}
+			reply("250 2.1.0 Ok")
+		case "RCPT":
+			address := addressArg(arg)
+			if s.RejectRecipients != nil {
+				if code := s.RejectRecipients(address); code != 0 {
+					reply("%s recipient %s rejected", strconv.Itoa(code), address)
+					continue
+				}
+			}
+			msg.To = append(msg.To, address)
+			reply("250 2.1.5 Ok")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			data, err := readData(r)
+			if err != nil {
+				return
+			}
+			msg.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = Message{Username: username, TLS: upgraded}
+			reply("250 2.0.0 Ok: queued")
+		case "RSET":
+			msg = Message{Username: username, TLS: upgraded}
+			reply("250 2.0.0 Ok")
+		case "NOOP":
+			reply("250 2.0.0 Ok")
+		case "QUIT":
+			reply("221 2.0.0 Bye")
+			return
+		default:
+			reply("502 5.5.2 Command not recognized")
+		}
+	}
+}
+
+// authenticate checks a "PLAIN <initial response>" AUTH argument and
+// returns the user it authenticates
+func (s *Server) authenticate(arg string) (string, bool) {
+	mechanism, response, _ := strings.Cut(arg, " ")
+	if !strings.EqualFold(mechanism, "PLAIN") {
+		return "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(response)
+	if err != nil {
+		return "", false
+	}
+	fields := strings.Split(string(decoded), "\x00")
+	if len(fields) != 3 {
+		return "", false
+	}
+	user, password := fields[1], fields[2]
+	if s.Username != "" && (user != s.Username || password != s.Password) {
+		return "", false
+	}
+	return user, true
+}
+
+// selfSignedCertificate creates a certificate for 127.0.0.1
+func selfSignedCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{"smtptest"}},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, nil
+}
+
+// addressArg extracts the address from "FROM:<a@b>" or "TO:<a@b> PARAMS"
+func addressArg(arg string) string {
+	_, rest, _ := strings.Cut(arg, ":")
+	rest = strings.TrimSpace(rest)
+	if i := strings.IndexByte(rest, '>'); i >= 0 {
+		rest = rest[:i+1]
+	}
+	return strings.Trim(rest, "<>")
+}
+
+// readData reads a dot-terminated DATA payload, undoing dot-stuffing
+func readData(r *bufio.Reader) ([]byte, error) {
+	var data []byte
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if line == ".\r\n" || line == ".\n" {
+			return data, nil
+		}
+		line = strings.TrimPrefix(line, ".")
+		data = append(data, line...)
+	}
+}
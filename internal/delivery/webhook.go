@@ -0,0 +1,182 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+// Headers set on every webhook request
+const (
+	// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of
+	// "<timestamp>.<body>" keyed with the shared secret
+	SignatureHeader = "X-DNDS-Signature"
+	// TimestampHeader carries the Unix time the request was signed at
+	TimestampHeader = "X-DNDS-Timestamp"
+	// IdempotencyKeyHeader is stable across retries of the same notification
+	IdempotencyKeyHeader = "Idempotency-Key"
+)
+
+// ErrNoWebhook is returned for recipients without a registered webhook URL
+var ErrNoWebhook = errors.New("no webhook registered for user")
+
+// HTTPStatusError is returned when a webhook responds with a non-2xx status
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("webhook %s responded with status %d", e.URL, e.StatusCode)
+}
+
+// WebhookRegistry resolves the URL a user's notifications are posted to
+type WebhookRegistry interface {
+	WebhookURL(userID string) (string, bool)
+}
+
+// StaticWebhookRegistry holds per-user webhook URLs and falls back to a
+// tenant-wide default for users without one
+type StaticWebhookRegistry struct {
+	mu         sync.RWMutex
+	defaultURL string
+	urls       map[string]string
+}
+
+// NewStaticWebhookRegistry creates a registry. defaultURL may be empty, in
+// which case only users in perUser receive webhooks.
+func NewStaticWebhookRegistry(defaultURL string, perUser map[string]string) *StaticWebhookRegistry {
+	urls := make(map[string]string, len(perUser))
+	for userID, url := range perUser {
+		urls[userID] = url
+	}
+	return &StaticWebhookRegistry{defaultURL: defaultURL, urls: urls}
+}
+
+// Register sets the webhook URL for a user
+func (r *StaticWebhookRegistry) Register(userID, url string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.urls[userID] = url
+}
+
+// WebhookURL returns the URL registered for a user, or the default URL
+func (r *StaticWebhookRegistry) WebhookURL(userID string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if url, ok := r.urls[userID]; ok {
+		return url, true
+	}
+	return r.defaultURL, r.defaultURL != ""
+}
+
+// WebhookPayload is the JSON body POSTed for each notification
+type WebhookPayload struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	PostID    string    `json:"post_id"`
+	AuthorID  string    `json:"author_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	Attempt   int       `json:"attempt"`
+}
+
+// WebhookDeliverer POSTs signed JSON payloads to the recipient's webhook.
+// Non-2xx responses and timeouts are retryable; a recipient without a
+// registered URL is a permanent failure.
+type WebhookDeliverer struct {
+	registry WebhookRegistry
+	secret   []byte
+	client   *http.Client
+}
+
+// NewWebhookDeliverer creates a webhook deliverer that signs requests with
+// secret and gives up on a request after timeout
+func NewWebhookDeliverer(registry WebhookRegistry, secret string, timeout time.Duration) *WebhookDeliverer {
+	return &WebhookDeliverer{
+		registry: registry,
+		secret:   []byte(secret),
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// Deliver sends the notification to the recipient's webhook
+func (d *WebhookDeliverer) Deliver(ctx context.Context, notification *models.Notification) error {
+	url, ok := d.registry.WebhookURL(notification.UserID)
+	if !ok {
+		return Permanent(fmt.Errorf("%w %s", ErrNoWebhook, notification.UserID))
+	}
+
+	body, err := json.Marshal(&WebhookPayload{
+		ID:        notification.ID,
+		UserID:    notification.UserID,
+		PostID:    notification.PostID,
+		AuthorID:  notification.AuthorID,
+		Content:   notification.Content,
+		CreatedAt: notification.CreatedAt,
+		Attempt:   notification.Attempts + 1,
+	})
+	if err != nil {
+		return Permanent(fmt.Errorf("encode webhook payload: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(fmt.Errorf("build webhook request: %w", err))
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(d.secret, timestamp, body))
+	req.Header.Set(IdempotencyKeyHeader, IdempotencyKey(notification))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &HTTPStatusError{URL: url, StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// IdempotencyKey returns the key receivers use to drop duplicate deliveries.
+// It only depends on the notification ID, so every retry carries the same key.
+func IdempotencyKey(notification *models.Notification) string {
+	sum := sha256.Sum256([]byte("notification:" + notification.ID))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Sign returns the SignatureHeader value for a request body sent at timestamp
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks a SignatureHeader value in constant time. Receivers
+// should also reject timestamps too far from their own clock.
+func VerifySignature(secret []byte, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,174 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+// webhookReceiver is an httptest handler that checks every request and
+// answers with the next status in statuses, then 200
+type webhookReceiver struct {
+	t        *testing.T
+	secret   []byte
+	mu       sync.Mutex
+	statuses []int
+	payloads []WebhookPayload
+	keys     []string
+}
+
+func (h *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.t.Errorf("read body: %v", err)
+	}
+	timestamp := r.Header.Get(TimestampHeader)
+	if !VerifySignature(h.secret, timestamp, body, r.Header.Get(SignatureHeader)) {
+		h.t.Errorf("request signature %q does not verify", r.Header.Get(SignatureHeader))
+	}
+	if sent, err := strconv.ParseInt(timestamp, 10, 64); err != nil || time.Since(time.Unix(sent, 0)) > time.Minute {
+		h.t.Errorf("timestamp %q is not the current Unix time", timestamp)
+	}
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		h.t.Errorf("decode payload: %v", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.payloads = append(h.payloads, payload)
+	h.keys = append(h.keys, r.Header.Get(IdempotencyKeyHeader))
+	status := http.StatusOK
+	if len(h.statuses) > 0 {
+		status, h.statuses = h.statuses[0], h.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func newWebhookTest(t *testing.T, statuses ...int) (*WebhookDeliverer, *webhookReceiver) {
+	t.Helper()
+	receiver := &webhookReceiver{t: t, secret: []byte("secret"), statuses: statuses}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+	registry := NewStaticWebhookRegistry("", map[string]string{"user2": server.URL})
+	return NewWebhookDeliverer(registry, "secret", 5*time.Second), receiver
+}
+
+// deliverWithRetries delivers like the queue does: until it succeeds or the
+// default retry policy gives up. It returns the attempts made and the last
+// error.
+func deliverWithRetries(d Deliverer, notification *models.Notification) (int, error) {
+	policies := DefaultRetryPolicies()
+	for {
+		err := d.Deliver(context.Background(), notification)
+		if err == nil {
+			return notification.Attempts + 1, nil
+		}
+		notification.Attempts++
+		if !policies.For(notification, err).ShouldRetry(err, notification.Attempts) {
+			return notification.Attempts, err
+		}
+	}
+}
+
+func TestWebhookDelivererSignsPayload(t *testing.T) {
+	d, receiver := newWebhookTest(t)
+	notification := &models.Notification{ID: "n1", UserID: "user2", PostID: "post1", AuthorID: "user1", Content: "hi"}
+
+	if err := d.Deliver(context.Background(), notification); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if len(receiver.payloads) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(receiver.payloads))
+	}
+	got := receiver.payloads[0]
+	if got.ID != "n1" || got.UserID != "user2" || got.PostID != "post1" || got.Content != "hi" || got.Attempt != 1 {
+		t.Errorf("payload = %+v", got)
+	}
+	if receiver.keys[0] != IdempotencyKey(notification) {
+		t.Errorf("idempotency key %q, want %q", receiver.keys[0], IdempotencyKey(notification))
+	}
+
+	// A different secret does not verify
+	if VerifySignature([]byte("other"), "1", []byte("{}"), Sign([]byte("secret"), "1", []byte("{}"))) {
+		t.Error("signature verified with the wrong secret")
+	}
+}
+
+func TestWebhookDelivererRetriesServerErrors(t *testing.T) {
+	d, receiver := newWebhookTest(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+	notification := &models.Notification{ID: "n1", UserID: "user2"}
+
+	attempts, err := deliverWithRetries(d, notification)
+	if err != nil {
+		t.Fatalf("delivery failed after %d attempts: %v", attempts, err)
+	}
+	if attempts != 3 {
+		t.Errorf("delivered on attempt %d, want 3", attempts)
+	}
+	// Every retry carries the same idempotency key and counts its attempt
+	for i, payload := range receiver.payloads {
+		if payload.Attempt != i+1 || receiver.keys[i] != receiver.keys[0] {
+			t.Errorf("request %d: attempt %d with key %s, want attempt %d with key %s", i, payload.Attempt, receiver.keys[i], i+1, receiver.keys[0])
+		}
+	}
+}
+
+func TestWebhookDelivererFailsOnClientErrors(t *testing.T) {
+	for _, tt := range []struct {
+		status       int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{http.StatusBadRequest, 1, true},
+		{http.StatusGone, 1, true},
+		{http.StatusTooManyRequests, 2, false},
+		{http.StatusRequestTimeout, 2, false},
+	} {
+		d, _ := newWebhookTest(t, tt.status)
+		attempts, err := deliverWithRetries(d, &models.Notification{ID: "n1", UserID: "user2"})
+		if (err != nil) != tt.wantErr || attempts != tt.wantAttempts {
+			t.Errorf("%d: ended after %d attempts with %v, want %d attempts and error %v", tt.status, attempts, err, tt.wantAttempts, tt.wantErr)
+		}
+		var statusErr *HTTPStatusError
+		if err != nil && (!errors.As(err, &statusErr) || statusErr.StatusCode != tt.status) {
+			t.Errorf("%d: error %v, want an HTTPStatusError", tt.status, err)
+		}
+	}
+}
+
+func TestWebhookDelivererTimeoutIsRetryable(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	registry := NewStaticWebhookRegistry(server.URL, nil)
+	d := NewWebhookDeliverer(registry, "secret", 50*time.Millisecond)
+
+	err := d.Deliver(context.Background(), &models.Notification{ID: "n1", UserID: "user2"})
+	if err == nil || !IsRetryable(err) {
+		t.Errorf("Deliver = %v, want a retryable timeout", err)
+	}
+}
+
+func TestWebhookDelivererWithoutURLIsPermanent(t *testing.T) {
+	d, receiver := newWebhookTest(t)
+	err := d.Deliver(context.Background(), &models.Notification{ID: "n1", UserID: "user3"})
+	if !errors.Is(err, ErrNoWebhook) || IsRetryable(err) {
+		t.Errorf("Deliver = %v, want a permanent ErrNoWebhook", err)
+	}
+	if len(receiver.payloads) != 0 {
+		t.Error("request sent for a user without a webhook")
+	}
+}
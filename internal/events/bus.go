@@ -0,0 +1,144 @@
+// Package events is the in-process event bus the notification queue
+// publishes notification lifecycle events to.
+package events
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+// Type identifies what happened to a notification
+type Type int
+
+const (
+	// NotificationAdded is published when a notification enters the queue
+	NotificationAdded Type = iota + 1
+	// NotificationStatusChanged is published on every status transition
+	NotificationStatusChanged
+)
+
+// ErrSlowSubscriber is reported by Subscription.Err when events were
+// published faster than the subscriber consumed them
+var ErrSlowSubscriber = errors.New("subscriber fell behind")
+
+// Event describes a change to a notification. Notification is a copy taken
+// when the event was published, so it is safe to read from any goroutine.
+type Event struct {
+	Type           Type
+	Notification   models.Notification
+	PreviousStatus models.NotificationStatus
+}
+
+// Bus fans events out to subscribers. Publishing never blocks: a subscriber
+// whose buffer is full is closed with ErrSlowSubscriber instead.
+type Bus struct {
+	mu sync.RWMutex
+	// byUser holds subscriptions for one user; "" holds subscriptions to all
+	byUser map[string]map[*Subscription]struct{}
+}
+
+// NewBus creates an empty bus
+func NewBus() *Bus {
+	return &Bus{byUser: make(map[string]map[*Subscription]struct{})}
+}
+
+// Subscription receives the events of one user, or of every user
+type Subscription struct {
+	bus    *Bus
+	userID string
+	c      chan Event
+
+	once sync.Once
+	err  error
+}
+
+// Subscribe returns a subscription to the events of userID, or of every user
+// if userID is empty. buffer bounds how far the subscriber may fall behind.
+func (b *Bus) Subscribe(userID string, buffer int) *Subscription {
+	sub := &Subscription{
+		bus:    b,
+		userID: userID,
+		c:      make(chan Event, buffer),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.byUser[userID] == nil {
+		b.byUser[userID] = make(map[*Subscription]struct{})
+	}
+	b.byUser[userID][sub] = struct{}{}
+	return sub
+}
+
+// Publish delivers an event to the recipient's subscribers and to
+// subscribers of every user
+func (b *Bus) Publish(eventType Type, notification *models.Notification, previous models.NotificationStatus) {
+	event := Event{Type: eventType, Notification: *notification, PreviousStatus: previous}
+
+	var slow []*Subscription
+	b.mu.RLock()
+	for _, userID := range []string{notification.UserID, ""} {
+		for sub := range b.byUser[userID] {
+			select {
+			case sub.c <- event:
+			default:
+				slow = append(slow, sub)
+			}
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range slow {
+		sub.close(ErrSlowSubscriber)
+	}
+}
+
+// SubscriberCount returns the number of open subscriptions
+func (b *Bus) SubscriberCount() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	count := 0
+	for _, subs := range b.byUser {
+		count += len(subs)
+	}
+	return count
+}
+
+// C returns the channel events are delivered on. It is closed when the
+// subscription ends.
+func (s *Subscription) C() <-chan Event {
+	return s.c
+}
+
+// Err returns why the subscription ended, or nil if it was unsubscribed or is
+// still open
+func (s *Subscription) Err() error {
+	s.bus.mu.RLock()
+	defer s.bus.mu.RUnlock()
+
+	return s.err
+}
+
+// Unsubscribe ends the subscription and closes its channel
+func (s *Subscription) Unsubscribe() {
+	s.close(nil)
+}
+
+func (s *Subscription) close(err error) {
+	s.once.Do(func() {
+		b := s.bus
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		s.err = err
+		delete(b.byUser[s.userID], s)
+		if len(b.byUser[s.userID]) == 0 {
+			delete(b.byUser, s.userID)
+		}
+		close(s.c)
+	})
+}
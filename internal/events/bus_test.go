@@ -0,0 +1,129 @@
+package events
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+func notificationFor(userID string) *models.Notification {
+	return models.NewNotification(userID, &models.Post{ID: "post1", AuthorID: "user1"})
+}
+
+// received drains what is buffered on sub without blocking
+func received(sub *Subscription) []Event {
+	var got []Event
+	for {
+		select {
+		case event, ok := <-sub.C():
+			if !ok {
+				return got
+			}
+			got = append(got, event)
+		default:
+			return got
+		}
+	}
+}
+
+func TestBusFansOut(t *testing.T) {
+	bus := NewBus()
+	first := bus.Subscribe("user2", 4)
+	second := bus.Subscribe("user2", 4)
+	everyone := bus.Subscribe("", 4)
+	other := bus.Subscribe("user3", 4)
+
+	n := notificationFor("user2")
+	bus.Publish(NotificationAdded, n, models.StatusUnknown)
+	n.Status = models.StatusDelivered
+	bus.Publish(NotificationStatusChanged, n, models.StatusQueued)
+
+	for name, sub := range map[string]*Subscription{"first": first, "second": second, "everyone": everyone} {
+		got := received(sub)
+		if len(got) != 2 {
+			t.Fatalf("%s got %d events, want 2", name, len(got))
+		}
+		if got[0].Type != NotificationAdded || got[0].Notification.Status != models.StatusQueued {
+			t.Errorf("%s first event = %+v, want the queued notification added", name, got[0])
+		}
+		if got[1].Type != NotificationStatusChanged || got[1].Notification.Status != models.StatusDelivered || got[1].PreviousStatus != models.StatusQueued {
+			t.Errorf("%s second event = %+v, want the change from queued to delivered", name, got[1])
+		}
+	}
+	if got := received(other); len(got) != 0 {
+		t.Errorf("user3 got %d events for user2", len(got))
+	}
+}
+
+func TestBusUnsubscribe(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe("user2", 4)
+	kept := bus.Subscribe("user2", 4)
+	if count := bus.SubscriberCount(); count != 2 {
+		t.Fatalf("SubscriberCount = %d, want 2", count)
+	}
+
+	sub.Unsubscribe()
+	sub.Unsubscribe() // safe to repeat
+	if _, ok := <-sub.C(); ok {
+		t.Error("channel still open after Unsubscribe")
+	}
+	if err := sub.Err(); err != nil {
+		t.Errorf("Err = %v after Unsubscribe, want nil", err)
+	}
+	if count := bus.SubscriberCount(); count != 1 {
+		t.Errorf("SubscriberCount = %d, want 1", count)
+	}
+
+	// Publishing to a user with a closed subscription does not panic
+	bus.Publish(NotificationAdded, notificationFor("user2"), models.StatusUnknown)
+	if got := received(kept); len(got) != 1 {
+		t.Errorf("remaining subscription got %d events, want 1", len(got))
+	}
+
+	kept.Unsubscribe()
+	if count := bus.SubscriberCount(); count != 0 {
+		t.Errorf("SubscriberCount = %d after every Unsubscribe", count)
+	}
+}
+
+func TestBusDropsSlowSubscribers(t *testing.T) {
+	bus := NewBus()
+	slow := bus.Subscribe("user2", 2)
+	fast := bus.Subscribe("user2", 16)
+
+	for i := 0; i < 5; i++ {
+		bus.Publish(NotificationAdded, notificationFor("user2"), models.StatusUnknown)
+	}
+
+	// The slow subscriber keeps what fit in its buffer, then is closed
+	if got := received(slow); len(got) != 2 {
+		t.Errorf("slow subscriber got %d events, want its buffer of 2", len(got))
+	}
+	if _, ok := <-slow.C(); ok {
+		t.Error("slow subscriber still open")
+	}
+	if err := slow.Err(); !errors.Is(err, ErrSlowSubscriber) {
+		t.Errorf("Err = %v, want ErrSlowSubscriber", err)
+	}
+	// Publishing never blocked on it, and the others got everything
+	if got := received(fast); len(got) != 5 {
+		t.Errorf("fast subscriber got %d events, want 5", len(got))
+	}
+	if count := bus.SubscriberCount(); count != 1 {
+		t.Errorf("SubscriberCount = %d, want the slow subscriber removed", count)
+	}
+}
+
+func TestBusEventsAreCopies(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe("user2", 1)
+	n := notificationFor("user2")
+	bus.Publish(NotificationAdded, n, models.StatusUnknown)
+	n.Status = models.StatusFailed
+
+	if event := <-sub.C(); event.Notification.Status != models.StatusQueued {
+		t.Errorf("event changed with the notification: %v", event.Notification.Status)
+	}
+}
@@ -0,0 +1,143 @@
+// Package loader batches and caches the user and post lookups made while
+// resolving one GraphQL request, so nested fields on a page of notifications
+// cost one store call per entity type instead of one per notification.
+package loader
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+type contextKey struct{}
+
+// Loaders holds the per-request caches. IDs announced with Prime are fetched
+// together by the first lookup that misses the cache.
+type Loaders struct {
+	users *batch[*models.User]
+	posts *batch[*models.Post]
+}
+
+// New creates empty loaders reading from s
+func New(s store.Store) *Loaders {
+	return &Loaders{
+		users: newBatch(s.GetUsers),
+		posts: newBatch(s.GetPosts),
+	}
+}
+
+// NewContext returns a copy of ctx carrying loaders
+func NewContext(ctx context.Context, loaders *Loaders) context.Context {
+	return context.WithValue(ctx, contextKey{}, loaders)
+}
+
+// FromContext returns the loaders of ctx, or nil if there are none
+func FromContext(ctx context.Context) *Loaders {
+	loaders, _ := ctx.Value(contextKey{}).(*Loaders)
+	return loaders
+}
+
+// Middleware gives every request its own loaders. It must not wrap
+// long-lived requests such as WebSocket subscriptions, whose cache would go
+// stale.
+func Middleware(s store.Store, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), New(s))))
+	})
+}
+
+// PrimeNotifications announces the recipients, authors and posts of
+// notifications that are about to be resolved
+func (l *Loaders) PrimeNotifications(notifications []*models.Notification) {
+	userIDs := make([]string, 0, 2*len(notifications))
+	postIDs := make([]string, 0, len(notifications))
+	for _, n := range notifications {
+		userIDs = append(userIDs, n.UserID)
+		if n.AuthorID != "" {
+			userIDs = append(userIDs, n.AuthorID)
+		}
+		postIDs = append(postIDs, n.PostID)
+	}
+	l.users.prime(userIDs)
+	l.posts.prime(postIDs)
+}
+
+// User returns a user, or store.ErrUserNotFound
+func (l *Loaders) User(id string) (*models.User, error) {
+	user, err := l.users.load(id)
+	if err == nil && user == nil {
+		err = store.ErrUserNotFound
+	}
+	return user, err
+}
+
+// Post returns a post, or store.ErrPostNotFound
+func (l *Loaders) Post(id string) (*models.Post, error) {
+	post, err := l.posts.load(id)
+	if err == nil && post == nil {
+		err = store.ErrPostNotFound
+	}
+	return post, err
+}
+
+// batch caches the results of a batch fetch function. Misses are cached as
+// nil so unknown IDs are only looked up once.
+type batch[V any] struct {
+	fetch func(ids []string) (map[string]V, error)
+
+	mu      sync.Mutex
+	pending map[string]struct{}
+	loaded  map[string]V
+}
+
+func newBatch[V any](fetch func(ids []string) (map[string]V, error)) *batch[V] {
+	return &batch[V]{
+		fetch:   fetch,
+		pending: make(map[string]struct{}),
+		loaded:  make(map[string]V),
+	}
+}
+
+// prime queues IDs for the next fetch
+func (b *batch[V]) prime(ids []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, id := range ids {
+		if _, ok := b.loaded[id]; !ok {
+			b.pending[id] = struct{}{}
+		}
+	}
+}
+
+// load returns the value for id, fetching it along with every pending ID if
+// it is not cached. The lock is held across the fetch so concurrent
+// resolvers wait for one batch instead of issuing their own.
+func (b *batch[V]) load(id string) (V, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if v, ok := b.loaded[id]; ok {
+		return v, nil
+	}
+
+	b.pending[id] = struct{}{}
+	ids := make([]string, 0, len(b.pending))
+	for pendingID := range b.pending {
+		ids = append(ids, pendingID)
+	}
+	b.pending = make(map[string]struct{})
+
+	found, err := b.fetch(ids)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	for _, fetchedID := range ids {
+		b.loaded[fetchedID] = found[fetchedID]
+	}
+	return b.loaded[id], nil
+}
@@ -0,0 +1,174 @@
+package resolver
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+const (
+	// defaultPageSize is used when a connection field is queried without first
+	defaultPageSize = 20
+	// maxPageSize caps first so a single query cannot load everything
+	maxPageSize = 100
+)
+
+// encodeCursor makes an opaque cursor from an ID. Clients must not rely on
+// the encoding.
+func encodeCursor(id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(id))
+}
+
+// decodeCursor reverses encodeCursor
+func decodeCursor(cursor string) (string, error) {
+	id, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return string(id), nil
+}
+
+// pageSize validates first and applies the default
+func pageSize(first *int32) (int, error) {
+	if first == nil {
+		return defaultPageSize, nil
+	}
+	if *first < 0 || *first > maxPageSize {
+		return 0, fmt.Errorf("first must be between 0 and %d", maxPageSize)
+	}
+	return int(*first), nil
+}
+
+// pageBounds validates first and last for a connection that can be paged in
+// both directions. Without either, the first defaultPageSize items are
+// returned; a zero result means no limit from that side.
+func pageBounds(first, last *int32) (int, int, error) {
+	if first == nil && last == nil {
+		return defaultPageSize, 0, nil
+	}
+	bounds := make([]int, 2)
+	for i, arg := range []struct {
+		name  string
+		value *int32
+	}{{"first", first}, {"last", last}} {
+		if arg.value == nil {
+			continue
+		}
+		if *arg.value < 1 || *arg.value > maxPageSize {
+			return 0, 0, fmt.Errorf("%s must be between 1 and %d", arg.name, maxPageSize)
+		}
+		bounds[i] = int(*arg.value)
+	}
+	return bounds[0], bounds[1], nil
+}
+
+// PageInfoResolver resolver for GraphQL PageInfo type
+type PageInfoResolver struct {
+	hasNextPage     bool
+	hasPreviousPage bool
+	startCursor     *string
+	endCursor       *string
+}
+
+func (r *PageInfoResolver) HasNextPage() bool {
+	return r.hasNextPage
+}
+
+func (r *PageInfoResolver) HasPreviousPage() bool {
+	return r.hasPreviousPage
+}
+
+func (r *PageInfoResolver) StartCursor() *string {
+	return r.startCursor
+}
+
+func (r *PageInfoResolver) EndCursor() *string {
+	return r.endCursor
+}
+
+// UserConnectionResolver resolver for GraphQL UserConnection type
+type UserConnectionResolver struct {
+	users    []*models.User
+	pageInfo *PageInfoResolver
+}
+
+func (r *UserConnectionResolver) Edges() []*UserEdgeResolver {
+	edges := make([]*UserEdgeResolver, len(r.users))
+	for i, user := range r.users {
+		edges[i] = &UserEdgeResolver{user: user}
+	}
+	return edges
+}
+
+func (r *UserConnectionResolver) PageInfo() *PageInfoResolver {
+	return r.pageInfo
+}
+
+// UserEdgeResolver resolver for GraphQL UserEdge type
+type UserEdgeResolver struct {
+	user *models.User
+}
+
+func (r *UserEdgeResolver) Cursor() string {
+	return encodeCursor(r.user.ID)
+}
+
+func (r *UserEdgeResolver) Node() *UserResolver {
+	return &UserResolver{user: r.user}
+}
+
+// newUserConnection builds a connection from a page fetched with one extra
+// user, which only signals that there is a next page
+func newUserConnection(users []*models.User, limit int, after string) *UserConnectionResolver {
+	pageInfo := &PageInfoResolver{hasPreviousPage: after != ""}
+	if len(users) > limit {
+		users = users[:limit]
+		pageInfo.hasNextPage = true
+	}
+	if len(users) > 0 {
+		start, end := encodeCursor(users[0].ID), encodeCursor(users[len(users)-1].ID)
+		pageInfo.startCursor, pageInfo.endCursor = &start, &end
+	}
+	return &UserConnectionResolver{users: users, pageInfo: pageInfo}
+}
+
+// NotificationConnectionResolver resolver for GraphQL NotificationConnection type
+type NotificationConnectionResolver struct {
+	page          *store.NotificationPage
+	notifications []*NotificationResolver
+}
+
+func (r *NotificationConnectionResolver) Edges() []*NotificationEdgeResolver {
+	edges := make([]*NotificationEdgeResolver, len(r.notifications))
+	for i, node := range r.notifications {
+		edges[i] = &NotificationEdgeResolver{node: node}
+	}
+	return edges
+}
+
+func (r *NotificationConnectionResolver) PageInfo() *PageInfoResolver {
+	pageInfo := &PageInfoResolver{
+		hasNextPage:     r.page.HasNext,
+		hasPreviousPage: r.page.HasPrevious,
+	}
+	if notifications := r.page.Notifications; len(notifications) > 0 {
+		start, end := encodeCursor(notifications[0].ID), encodeCursor(notifications[len(notifications)-1].ID)
+		pageInfo.startCursor, pageInfo.endCursor = &start, &end
+	}
+	return pageInfo
+}
+
+// NotificationEdgeResolver resolver for GraphQL NotificationEdge type
+type NotificationEdgeResolver struct {
+	node *NotificationResolver
+}
+
+func (r *NotificationEdgeResolver) Cursor() string {
+	return encodeCursor(r.node.notification.ID)
+}
+
+func (r *NotificationEdgeResolver) Node() *NotificationResolver {
+	return r.node
+}
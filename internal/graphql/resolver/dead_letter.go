@@ -0,0 +1,253 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+// DeadLetterReason represents the GraphQL enum for dead-letter reasons
+type DeadLetterReason string
+
+func deadLetterReasonFromModel(reason models.DeadLetterReason) DeadLetterReason {
+	return DeadLetterReason(strings.ToUpper(string(reason)))
+}
+
+func (r DeadLetterReason) toModel() models.DeadLetterReason {
+	return models.DeadLetterReason(strings.ToLower(string(r)))
+}
+
+// DeadLetterFilterInput is the GraphQL DeadLetterFilter input
+type DeadLetterFilterInput struct {
+	UserID     *graphql.ID
+	Reason     *DeadLetterReason
+	Replayed   *bool
+	DeadSince  *string
+	DeadBefore *string
+}
+
+// toStore converts the input to a store filter
+func (f *DeadLetterFilterInput) toStore() (store.DeadLetterFilter, error) {
+	var filter store.DeadLetterFilter
+	if f == nil {
+		return filter, nil
+	}
+	if f.UserID != nil {
+		filter.UserID = string(*f.UserID)
+	}
+	if f.Reason != nil {
+		filter.Reason = f.Reason.toModel()
+	}
+	filter.Replayed = f.Replayed
+	for _, bound := range []struct {
+		name  string
+		value *string
+		dst   *time.Time
+	}{{"deadSince", f.DeadSince, &filter.DeadSince}, {"deadBefore", f.DeadBefore, &filter.DeadBefore}} {
+		if bound.value == nil {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, *bound.value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s %q: want an RFC 3339 time", bound.name, *bound.value)
+		}
+		*bound.dst = t
+	}
+	return filter, nil
+}
+
+// DeadLetterResolver resolver for GraphQL DeadLetter type
+type DeadLetterResolver struct {
+	deadLetter *models.DeadLetter
+	resolver   *Resolver
+}
+
+func (r *DeadLetterResolver) NotificationID() graphql.ID {
+	return graphql.ID(r.deadLetter.NotificationID)
+}
+
+// Notification resolves the dead-lettered notification, or null once it is gone
+func (r *DeadLetterResolver) Notification(ctx context.Context) (*NotificationResolver, error) {
+	notification, err := r.resolver.store.GetNotification(r.deadLetter.NotificationID)
+	if errors.Is(err, store.ErrNotificationNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newNotificationResolvers(r.resolver.loaders(ctx), []*models.Notification{notification})[0], nil
+}
+
+func (r *DeadLetterResolver) UserID() graphql.ID {
+	return graphql.ID(r.deadLetter.UserID)
+}
+
+func (r *DeadLetterResolver) Reason() DeadLetterReason {
+	return deadLetterReasonFromModel(r.deadLetter.Reason)
+}
+
+func (r *DeadLetterResolver) LastError() string {
+	return r.deadLetter.LastError
+}
+
+func (r *DeadLetterResolver) Attempts() int32 {
+	return int32(r.deadLetter.Attempts)
+}
+
+func (r *DeadLetterResolver) AttemptedAt() []string {
+	times := make([]string, len(r.deadLetter.AttemptedAt))
+	for i, t := range r.deadLetter.AttemptedAt {
+		times[i] = t.Format(time.RFC3339Nano)
+	}
+	return times
+}
+
+func (r *DeadLetterResolver) DeadAt() string {
+	return r.deadLetter.DeadAt.Format(time.RFC3339Nano)
+}
+
+func (r *DeadLetterResolver) Replayed() bool {
+	return r.deadLetter.Replayed
+}
+
+func (r *DeadLetterResolver) Replays() []*DeadLetterReplayResolver {
+	replays := make([]*DeadLetterReplayResolver, len(r.deadLetter.Replays))
+	for i := range r.deadLetter.Replays {
+		replays[i] = &DeadLetterReplayResolver{replay: &r.deadLetter.Replays[i]}
+	}
+	return replays
+}
+
+// DeadLetterReplayResolver resolver for GraphQL DeadLetterReplay type
+type DeadLetterReplayResolver struct {
+	replay *models.DeadLetterReplay
+}
+
+func (r *DeadLetterReplayResolver) At() string {
+	return r.replay.At.Format(time.RFC3339Nano)
+}
+
+func (r *DeadLetterReplayResolver) Reason() DeadLetterReason {
+	return deadLetterReasonFromModel(r.replay.Reason)
+}
+
+func (r *DeadLetterReplayResolver) LastError() string {
+	return r.replay.LastError
+}
+
+func (r *DeadLetterReplayResolver) Attempts() int32 {
+	return int32(r.replay.Attempts)
+}
+
+// DeadLetterBatchResolver resolver for GraphQL DeadLetterBatchResult type
+type DeadLetterBatchResolver struct {
+	notificationIDs []string
+}
+
+func (r *DeadLetterBatchResolver) Count() int32 {
+	return int32(len(r.notificationIDs))
+}
+
+func (r *DeadLetterBatchResolver) NotificationIDs() []graphql.ID {
+	return toGraphQLIDs(r.notificationIDs)
+}
+
+// DeadLetters resolves the deadLetters query
+func (r *Resolver) DeadLetters(ctx context.Context, args struct {
+	Filter *DeadLetterFilterInput
+	First  *int32
+}) ([]*DeadLetterResolver, error) {
+	limit, err := pageSize(args.First)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := args.Filter.toStore()
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetters, err := r.store.ListDeadLetters(filter, limit)
+	if err != nil {
+		log.Printf("Error listing dead letters: %v", err)
+		return nil, err
+	}
+	resolvers := make([]*DeadLetterResolver, len(deadLetters))
+	for i, deadLetter := range deadLetters {
+		resolvers[i] = &DeadLetterResolver{deadLetter: deadLetter, resolver: r}
+	}
+	return resolvers, nil
+}
+
+// DeadLetter resolves the deadLetter query; notifications without a dead
+// letter resolve to null
+func (r *Resolver) DeadLetter(ctx context.Context, args struct{ NotificationID graphql.ID }) (*DeadLetterResolver, error) {
+	deadLetter, err := r.store.GetDeadLetter(string(args.NotificationID))
+	if errors.Is(err, store.ErrDeadLetterNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Error retrieving dead letter: %v", err)
+		return nil, err
+	}
+	return &DeadLetterResolver{deadLetter: deadLetter, resolver: r}, nil
+}
+
+// ReplayDeadLetter resolves the replayDeadLetter mutation
+func (r *Resolver) ReplayDeadLetter(ctx context.Context, args struct{ NotificationID graphql.ID }) (*DeadLetterResolver, error) {
+	id := string(args.NotificationID)
+	if err := r.queue.ReplayDeadLetter(ctx, id); err != nil {
+		return nil, fmt.Errorf("replay %s: %w", id, err)
+	}
+	return r.DeadLetter(ctx, args)
+}
+
+// ReplayDeadLetters resolves the replayDeadLetters mutation
+func (r *Resolver) ReplayDeadLetters(ctx context.Context, args struct{ Filter *DeadLetterFilterInput }) (*DeadLetterBatchResolver, error) {
+	filter, err := args.Filter.toStore()
+	if err != nil {
+		return nil, err
+	}
+
+	replayed, err := r.queue.ReplayDeadLetters(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("replayed %d dead letters, then: %w", len(replayed), err)
+	}
+	return &DeadLetterBatchResolver{notificationIDs: replayed}, nil
+}
+
+// PurgeDeadLetters resolves the purgeDeadLetters mutation. Either
+// notificationIds or a filter is required, so nothing is purged by accident.
+func (r *Resolver) PurgeDeadLetters(ctx context.Context, args struct {
+	NotificationIDs *[]graphql.ID
+	Filter          *DeadLetterFilterInput
+}) (*DeadLetterBatchResolver, error) {
+	var ids []string
+	if args.NotificationIDs != nil {
+		if len(*args.NotificationIDs) == 0 {
+			return nil, errors.New("at least one notification ID is required")
+		}
+		for _, id := range *args.NotificationIDs {
+			ids = append(ids, string(id))
+		}
+	} else if args.Filter == nil {
+		return nil, errors.New("notificationIds or filter is required")
+	}
+	filter, err := args.Filter.toStore()
+	if err != nil {
+		return nil, err
+	}
+
+	purged, err := r.queue.PurgeDeadLetters(ids, filter)
+	if err != nil {
+		log.Printf("Error purging dead letters: %v", err)
+		return nil, err
+	}
+	return &DeadLetterBatchResolver{notificationIDs: purged}, nil
+}
@@ -0,0 +1,96 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+func TestNotificationAttempts(t *testing.T) {
+	st := store.NewMemoryStore(true)
+	schema, _ := newTestSchema(t, st)
+
+	post, err := st.GetPost("post1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := models.NewNotification("user2", post)
+	if err := st.SaveNotification(n); err != nil {
+		t.Fatalf("SaveNotification: %v", err)
+	}
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	n.Status = models.StatusDelivered
+	n.Attempts = 1
+	n.DeliveryAttempts = []models.DeliveryAttempt{
+		{Attempt: 1, Channel: "webhook", StartedAt: started, FinishedAt: started.Add(time.Second), Outcome: models.OutcomeRetrying,
+			Error: "webhook responded with status 503", ErrorClass: "http_5xx", Backoff: 1500 * time.Millisecond},
+		{Attempt: 1, Channel: "email", StartedAt: started, FinishedAt: started.Add(time.Second), Outcome: models.OutcomeDelivered},
+		{Attempt: 2, Channel: "webhook", StartedAt: started.Add(2 * time.Second), FinishedAt: started.Add(3 * time.Second), Outcome: models.OutcomeDelivered},
+	}
+	if err := st.UpdateNotification(n); err != nil {
+		t.Fatalf("UpdateNotification: %v", err)
+	}
+
+	resp := schema.Exec(context.Background(), `query($userId: ID!) {
+		notifications(userId: $userId) { edges { node {
+			attemptCount
+			attempts { attempt channel startedAt finishedAt outcome error errorClass backoff }
+		} } }
+	}`, "", map[string]interface{}{"userId": "user2"})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("query errors: %v", resp.Errors)
+	}
+	var data struct {
+		Notifications struct {
+			Edges []struct {
+				Node struct {
+					AttemptCount int `json:"attemptCount"`
+					Attempts     []struct {
+						Attempt    int     `json:"attempt"`
+						Channel    string  `json:"channel"`
+						StartedAt  string  `json:"startedAt"`
+						FinishedAt string  `json:"finishedAt"`
+						Outcome    string  `json:"outcome"`
+						Error      *string `json:"error"`
+						ErrorClass *string `json:"errorClass"`
+						Backoff    *string `json:"backoff"`
+					} `json:"attempts"`
+				} `json:"node"`
+			} `json:"edges"`
+		} `json:"notifications"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Notifications.Edges) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(data.Notifications.Edges))
+	}
+	node := data.Notifications.Edges[0].Node
+	if node.AttemptCount != 1 || len(node.Attempts) != 3 {
+		t.Fatalf("attemptCount %d with %d attempts, want 1 with 3", node.AttemptCount, len(node.Attempts))
+	}
+
+	retried := node.Attempts[0]
+	if retried.Attempt != 1 || retried.Channel != "webhook" || retried.Outcome != "RETRYING" ||
+		retried.StartedAt != "2026-01-02T03:04:05Z" || retried.FinishedAt != "2026-01-02T03:04:06Z" {
+		t.Errorf("first attempt = %+v", retried)
+	}
+	if retried.Error == nil || *retried.Error != "webhook responded with status 503" {
+		t.Errorf("first attempt error = %v", retried.Error)
+	}
+	if retried.ErrorClass == nil || *retried.ErrorClass != "http_5xx" {
+		t.Errorf("first attempt errorClass = %v, want http_5xx", retried.ErrorClass)
+	}
+	if retried.Backoff == nil || *retried.Backoff != "1.5s" {
+		t.Errorf("first attempt backoff = %v, want 1.5s", retried.Backoff)
+	}
+	for i, a := range node.Attempts[1:] {
+		if a.Outcome != "DELIVERED" || a.Error != nil || a.ErrorClass != nil || a.Backoff != nil {
+			t.Errorf("attempt %d = %+v, want a delivery without error, errorClass or backoff", i+2, a)
+		}
+	}
+}
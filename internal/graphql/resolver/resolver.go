@@ -2,9 +2,16 @@ package resolver
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/graph-gophers/graphql-go"
+	"github.com/suyashXD/DNDS/internal/events"
+	"github.com/suyashXD/DNDS/internal/graphql/loader"
 	"github.com/suyashXD/DNDS/internal/models"
 	"github.com/suyashXD/DNDS/internal/queue"
 	"github.com/suyashXD/DNDS/internal/store"
@@ -12,12 +19,12 @@ import (
 
 // Resolver is the root resolver for GraphQL queries
 type Resolver struct {
-	store *store.MemoryStore
+	store store.Store
 	queue *queue.NotificationQueue
 }
 
 // NewResolver creates a new GraphQL resolver
-func NewResolver(store *store.MemoryStore, queue *queue.NotificationQueue) *Resolver {
+func NewResolver(store store.Store, queue *queue.NotificationQueue) *Resolver {
 	return &Resolver{
 		store: store,
 		queue: queue,
@@ -45,9 +52,83 @@ func NotificationStatusFromModel(status models.NotificationStatus) NotificationS
 	}
 }
 
+// notificationStatusToModel converts the GraphQL enum to the model status
+func notificationStatusToModel(status NotificationStatus) models.NotificationStatus {
+	switch status {
+	case "QUEUED":
+		return models.StatusQueued
+	case "DELIVERED":
+		return models.StatusDelivered
+	case "FAILED":
+		return models.StatusFailed
+	case "RETRYING":
+		return models.StatusRetrying
+	default:
+		return models.StatusUnknown
+	}
+}
+
+// NotificationFilterInput is the GraphQL NotificationFilter input
+type NotificationFilterInput struct {
+	Status        *[]NotificationStatus
+	Read          *bool
+	AuthorID      *graphql.ID
+	PostID        *graphql.ID
+	CreatedSince  *string
+	CreatedBefore *string
+}
+
+// toStore converts the input to a store filter
+func (f *NotificationFilterInput) toStore() (store.NotificationFilter, error) {
+	var filter store.NotificationFilter
+	if f == nil {
+		return filter, nil
+	}
+	if f.Status != nil {
+		for _, status := range *f.Status {
+			filter.Statuses = append(filter.Statuses, notificationStatusToModel(status))
+		}
+	}
+	filter.Read = f.Read
+	if f.AuthorID != nil {
+		filter.AuthorID = string(*f.AuthorID)
+	}
+	if f.PostID != nil {
+		filter.PostID = string(*f.PostID)
+	}
+	for _, bound := range []struct {
+		name  string
+		value *string
+		dst   *time.Time
+	}{{"createdSince", f.CreatedSince, &filter.CreatedSince}, {"createdBefore", f.CreatedBefore, &filter.CreatedBefore}} {
+		if bound.value == nil {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, *bound.value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s %q: want an RFC 3339 time", bound.name, *bound.value)
+		}
+		*bound.dst = t
+	}
+	return filter, nil
+}
+
 // Notification resolver for GraphQL Notification type
 type NotificationResolver struct {
 	notification *models.Notification
+	loaders      *loader.Loaders
+}
+
+// newNotificationResolvers wraps the notifications of one response, priming
+// loaders with the users and posts their nested fields may ask for
+func newNotificationResolvers(loaders *loader.Loaders, notifications []*models.Notification) []*NotificationResolver {
+	loaders.PrimeNotifications(notifications)
+
+	resolvers := make([]*NotificationResolver, len(notifications))
+	for i, notification := range notifications {
+		resolvers[i] = &NotificationResolver{notification: notification, loaders: loaders}
+	}
+	return resolvers
 }
 
 func (r *NotificationResolver) ID() graphql.ID {
@@ -66,6 +147,33 @@ func (r *NotificationResolver) AuthorID() graphql.ID {
 	return graphql.ID(r.notification.AuthorID)
 }
 
+// Recipient resolves the user the notification was sent to
+func (r *NotificationResolver) Recipient() (*UserResolver, error) {
+	user, err := r.loaders.User(r.notification.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &UserResolver{user: user}, nil
+}
+
+// Author resolves the author of the post, or deletedAuthor once they deleted
+// their account
+func (r *NotificationResolver) Author() (*UserResolver, error) {
+	return resolveAuthor(r.loaders, r.notification.AuthorID)
+}
+
+// Post resolves the post the notification is about
+func (r *NotificationResolver) Post() (*PostResolver, error) {
+	post, err := r.loaders.Post(r.notification.PostID)
+	if errors.Is(err, store.ErrPostNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &PostResolver{post: post, loaders: r.loaders}, nil
+}
+
 func (r *NotificationResolver) Content() string {
 	return r.notification.Content
 }
@@ -82,10 +190,109 @@ func (r *NotificationResolver) Status() NotificationStatus {
 	return NotificationStatusFromModel(r.notification.Status)
 }
 
-func (r *NotificationResolver) Attempts() int32 {
+func (r *NotificationResolver) AttemptCount() int32 {
 	return int32(r.notification.Attempts)
 }
 
+func (r *NotificationResolver) Attempts() []*DeliveryAttemptResolver {
+	attempts := make([]*DeliveryAttemptResolver, len(r.notification.DeliveryAttempts))
+	for i := range r.notification.DeliveryAttempts {
+		attempts[i] = &DeliveryAttemptResolver{attempt: &r.notification.DeliveryAttempts[i]}
+	}
+	return attempts
+}
+
+// DeliveryOutcome represents the GraphQL enum for delivery outcomes
+type DeliveryOutcome string
+
+// DeliveryAttemptResolver resolver for GraphQL DeliveryAttempt type
+type DeliveryAttemptResolver struct {
+	attempt *models.DeliveryAttempt
+}
+
+func (r *DeliveryAttemptResolver) Attempt() int32 {
+	return int32(r.attempt.Attempt)
+}
+
+func (r *DeliveryAttemptResolver) Channel() string {
+	return r.attempt.Channel
+}
+
+func (r *DeliveryAttemptResolver) StartedAt() string {
+	return r.attempt.StartedAt.Format(time.RFC3339Nano)
+}
+
+func (r *DeliveryAttemptResolver) FinishedAt() string {
+	return r.attempt.FinishedAt.Format(time.RFC3339Nano)
+}
+
+func (r *DeliveryAttemptResolver) Outcome() DeliveryOutcome {
+	return DeliveryOutcome(strings.ToUpper(string(r.attempt.Outcome)))
+}
+
+func (r *DeliveryAttemptResolver) Error() *string {
+	if r.attempt.Error == "" {
+		return nil
+	}
+	return &r.attempt.Error
+}
+
+func (r *DeliveryAttemptResolver) ErrorClass() *string {
+	if r.attempt.ErrorClass == "" {
+		return nil
+	}
+	return &r.attempt.ErrorClass
+}
+
+func (r *DeliveryAttemptResolver) Backoff() *string {
+	if r.attempt.Outcome != models.OutcomeRetrying {
+		return nil
+	}
+	backoff := r.attempt.Backoff.String()
+	return &backoff
+}
+
+// NotificationCountsResolver resolver for GraphQL NotificationCounts type
+type NotificationCountsResolver struct {
+	counts *store.NotificationCounts
+}
+
+func (r *NotificationCountsResolver) Total() int32 {
+	return int32(r.counts.Total)
+}
+
+func (r *NotificationCountsResolver) Unread() int32 {
+	return int32(r.counts.Unread)
+}
+
+func (r *NotificationCountsResolver) ByStatus() []*StatusCountResolver {
+	statuses := make([]models.NotificationStatus, 0, len(r.counts.ByStatus))
+	for status := range r.counts.ByStatus {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i] < statuses[j] })
+
+	resolvers := make([]*StatusCountResolver, len(statuses))
+	for i, status := range statuses {
+		resolvers[i] = &StatusCountResolver{status: status, count: r.counts.ByStatus[status]}
+	}
+	return resolvers
+}
+
+// StatusCountResolver resolver for GraphQL StatusCount type
+type StatusCountResolver struct {
+	status models.NotificationStatus
+	count  int
+}
+
+func (r *StatusCountResolver) Status() NotificationStatus {
+	return NotificationStatusFromModel(r.status)
+}
+
+func (r *StatusCountResolver) Count() int32 {
+	return int32(r.count)
+}
+
 // MetricsResolver resolver for GraphQL Metrics type
 type MetricsResolver struct {
 	metrics map[string]interface{}
@@ -111,32 +318,397 @@ func (r *MetricsResolver) QueueSize() int32 {
 	return int32(r.metrics["queue_size"].(int))
 }
 
+func (r *MetricsResolver) Dropped() int32 {
+	return int32(r.metrics["dropped"].(int64))
+}
+
+func (r *MetricsResolver) Spilled() int32 {
+	return int32(r.metrics["spilled"].(int))
+}
+
 func (r *MetricsResolver) WorkerCount() int32 {
 	return int32(r.metrics["worker_count"].(int))
 }
 
-// GetNotifications resolves the getNotifications query
-func (r *Resolver) GetNotifications(ctx context.Context, args struct{ UserID graphql.ID }) ([]*NotificationResolver, error) {
-	userID := string(args.UserID)
-	
-	// Get the latest 20 notifications for this user
-	notifications, err := r.store.GetUserNotifications(userID, 20)
+func (r *MetricsResolver) PendingRetries() int32 {
+	return int32(r.metrics["pending_retries"].(int))
+}
+
+func (r *MetricsResolver) NextRetryAt() *string {
+	if at := r.metrics["next_retry_at"].(string); at != "" {
+		return &at
+	}
+	return nil
+}
+
+// Notifications resolves the notifications query
+func (r *Resolver) Notifications(ctx context.Context, args struct {
+	UserID graphql.ID
+	Filter *NotificationFilterInput
+	Order  string
+	First  *int32
+	After  *string
+	Last   *int32
+	Before *string
+}) (*NotificationConnectionResolver, error) {
+	first, last, err := pageBounds(args.First, args.Last)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := args.Filter.toStore()
+	if err != nil {
+		return nil, err
+	}
+	query := store.NotificationQuery{UserID: string(args.UserID), Filter: filter, First: first, Last: last}
+	if args.Order == "OLDEST_FIRST" {
+		query.Order = store.OldestFirst
+	}
+	if args.After != nil {
+		if query.After, err = decodeCursor(*args.After); err != nil {
+			return nil, err
+		}
+	}
+	if args.Before != nil {
+		if query.Before, err = decodeCursor(*args.Before); err != nil {
+			return nil, err
+		}
+	}
+
+	page, err := r.store.QueryNotifications(query)
+	if errors.Is(err, store.ErrNotificationNotFound) {
+		return nil, fmt.Errorf("unknown cursor for user %s", query.UserID)
+	}
 	if err != nil {
 		log.Printf("Error retrieving notifications: %v", err)
 		return nil, err
 	}
-	
-	// Convert to resolvers
-	resolvers := make([]*NotificationResolver, len(notifications))
-	for i, notification := range notifications {
-		resolvers[i] = &NotificationResolver{notification: notification}
+	return &NotificationConnectionResolver{page: page, notifications: newNotificationResolvers(r.loaders(ctx), page.Notifications)}, nil
+}
+
+// NotificationCounts resolves the notificationCounts query
+func (r *Resolver) NotificationCounts(ctx context.Context, args struct{ UserID graphql.ID }) (*NotificationCountsResolver, error) {
+	userID := string(args.UserID)
+	if _, err := r.store.GetUser(userID); err != nil {
+		return nil, err
+	}
+
+	counts, err := r.store.GetNotificationCounts(userID)
+	if err != nil {
+		log.Printf("Error retrieving notification counts: %v", err)
+		return nil, err
 	}
-	
-	return resolvers, nil
+	return &NotificationCountsResolver{counts: counts}, nil
 }
 
 // GetMetrics resolves the getMetrics query
 func (r *Resolver) GetMetrics(ctx context.Context) (*MetricsResolver, error) {
 	metrics := r.queue.GetMetrics()
 	return &MetricsResolver{metrics: metrics}, nil
-}
\ No newline at end of file
+}
+
+// User resolves the user query; unknown users resolve to null
+func (r *Resolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*UserResolver, error) {
+	user, err := r.store.GetUser(string(args.ID))
+	if errors.Is(err, store.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Error retrieving user: %v", err)
+		return nil, err
+	}
+	return &UserResolver{user: user}, nil
+}
+
+// Users resolves the users query, paging through users in ID order
+func (r *Resolver) Users(ctx context.Context, args struct {
+	First *int32
+	After *string
+}) (*UserConnectionResolver, error) {
+	limit, err := pageSize(args.First)
+	if err != nil {
+		return nil, err
+	}
+	var after string
+	if args.After != nil {
+		if after, err = decodeCursor(*args.After); err != nil {
+			return nil, err
+		}
+	}
+
+	// One extra user tells whether there is a next page
+	users, err := r.store.ListUsers(after, limit+1)
+	if err != nil {
+		log.Printf("Error listing users: %v", err)
+		return nil, err
+	}
+	return newUserConnection(users, limit, after), nil
+}
+
+// MarkReadResolver resolver for GraphQL MarkReadResult type
+type MarkReadResolver struct {
+	notifications []*NotificationResolver
+	unreadCount   int
+}
+
+func (r *MarkReadResolver) Notifications() []*NotificationResolver {
+	return r.notifications
+}
+
+func (r *MarkReadResolver) UnreadCount() int32 {
+	return int32(r.unreadCount)
+}
+
+// MarkNotificationRead resolves the markNotificationRead mutation
+func (r *Resolver) MarkNotificationRead(ctx context.Context, args struct{ ID graphql.ID }) (*MarkReadResolver, error) {
+	return r.MarkNotificationsRead(ctx, struct{ IDs []graphql.ID }{IDs: []graphql.ID{args.ID}})
+}
+
+// MarkNotificationsRead resolves the markNotificationsRead mutation. All IDs
+// must belong to one user, whose unread count is returned.
+func (r *Resolver) MarkNotificationsRead(ctx context.Context, args struct{ IDs []graphql.ID }) (*MarkReadResolver, error) {
+	if len(args.IDs) == 0 {
+		return nil, errors.New("at least one notification ID is required")
+	}
+
+	ids := make([]string, len(args.IDs))
+	var userID string
+	for i, id := range args.IDs {
+		ids[i] = string(id)
+		notification, err := r.store.GetNotification(ids[i])
+		if err != nil {
+			return nil, fmt.Errorf("notification %s: %w", id, err)
+		}
+		if userID == "" {
+			userID = notification.UserID
+		} else if notification.UserID != userID {
+			return nil, errors.New("notifications belong to more than one user")
+		}
+	}
+
+	notifications, err := r.store.MarkNotificationsRead(ids)
+	if err != nil {
+		log.Printf("Error marking notifications read: %v", err)
+		return nil, err
+	}
+	return r.markReadResult(ctx, userID, notifications)
+}
+
+// MarkAllNotificationsRead resolves the markAllNotificationsRead mutation
+func (r *Resolver) MarkAllNotificationsRead(ctx context.Context, args struct {
+	UserID graphql.ID
+	Before *string
+}) (*MarkReadResolver, error) {
+	userID := string(args.UserID)
+	if _, err := r.store.GetUser(userID); err != nil {
+		return nil, err
+	}
+
+	before := time.Now()
+	if args.Before != nil {
+		var err error
+		before, err = time.Parse(time.RFC3339Nano, *args.Before)
+		if err != nil {
+			return nil, fmt.Errorf("invalid before %q: want an RFC 3339 time", *args.Before)
+		}
+	}
+
+	notifications, err := r.store.MarkAllNotificationsRead(userID, before)
+	if err != nil {
+		log.Printf("Error marking notifications read: %v", err)
+		return nil, err
+	}
+	return r.markReadResult(ctx, userID, notifications)
+}
+
+func (r *Resolver) markReadResult(ctx context.Context, userID string, notifications []*models.Notification) (*MarkReadResolver, error) {
+	unread, err := r.store.CountUnreadNotifications(userID)
+	if err != nil {
+		log.Printf("Error counting unread notifications: %v", err)
+		return nil, err
+	}
+	return &MarkReadResolver{
+		notifications: newNotificationResolvers(r.loaders(ctx), notifications),
+		unreadCount:   unread,
+	}, nil
+}
+
+// loaders returns the request's loaders. Contexts without any, such as those
+// of subscriptions, get fresh ones so nothing is cached across events.
+func (r *Resolver) loaders(ctx context.Context) *loader.Loaders {
+	if loaders := loader.FromContext(ctx); loaders != nil {
+		return loaders
+	}
+	return loader.New(r.store)
+}
+
+// PostResolver resolver for GraphQL Post type
+type PostResolver struct {
+	post    *models.Post
+	loaders *loader.Loaders
+}
+
+func (r *PostResolver) ID() graphql.ID {
+	return graphql.ID(r.post.ID)
+}
+
+// Author resolves the author of the post, or deletedAuthor once they deleted
+// their account
+func (r *PostResolver) Author() (*UserResolver, error) {
+	return resolveAuthor(r.loaders, r.post.AuthorID)
+}
+
+func (r *PostResolver) Content() string {
+	return r.post.Content
+}
+
+func (r *PostResolver) CreatedAt() string {
+	return r.post.CreatedAt.Format("2006-01-02T15:04:05Z")
+}
+
+// deletedAuthor stands in for the author of a deleted account, so author
+// fields never resolve to null
+var deletedAuthor = &models.User{Username: "[deleted]"}
+
+// resolveAuthor loads an author; authors of deleted accounts have their ID
+// cleared and resolve to deletedAuthor
+func resolveAuthor(loaders *loader.Loaders, authorID string) (*UserResolver, error) {
+	if authorID == "" {
+		return &UserResolver{user: deletedAuthor}, nil
+	}
+	user, err := loaders.User(authorID)
+	if errors.Is(err, store.ErrUserNotFound) {
+		return &UserResolver{user: deletedAuthor}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &UserResolver{user: user}, nil
+}
+
+// UserResolver resolver for GraphQL User type
+type UserResolver struct {
+	user *models.User
+}
+
+func (r *UserResolver) ID() graphql.ID {
+	return graphql.ID(r.user.ID)
+}
+
+func (r *UserResolver) Username() string {
+	return r.user.Username
+}
+
+func (r *UserResolver) FollowerIDs() []graphql.ID {
+	return toGraphQLIDs(r.user.FollowerIDs)
+}
+
+func (r *UserResolver) FollowingIDs() []graphql.ID {
+	return toGraphQLIDs(r.user.FollowingIDs)
+}
+
+func toGraphQLIDs(ids []string) []graphql.ID {
+	result := make([]graphql.ID, len(ids))
+	for i, id := range ids {
+		result[i] = graphql.ID(id)
+	}
+	return result
+}
+
+// FollowResolver resolver for GraphQL FollowResult type
+type FollowResolver struct {
+	follower *models.User
+	followee *models.User
+}
+
+func (r *FollowResolver) Follower() *UserResolver {
+	return &UserResolver{user: r.follower}
+}
+
+func (r *FollowResolver) Followee() *UserResolver {
+	return &UserResolver{user: r.followee}
+}
+
+// followArgs are the arguments of the follow and unfollow mutations
+type followArgs struct {
+	FollowerID graphql.ID
+	FolloweeID graphql.ID
+}
+
+// Follow resolves the follow mutation
+func (r *Resolver) Follow(ctx context.Context, args followArgs) (*FollowResolver, error) {
+	return r.updateFollow(args, r.store.Follow)
+}
+
+// Unfollow resolves the unfollow mutation
+func (r *Resolver) Unfollow(ctx context.Context, args followArgs) (*FollowResolver, error) {
+	return r.updateFollow(args, r.store.Unfollow)
+}
+
+func (r *Resolver) updateFollow(args followArgs, update func(followerID, followeeID string) error) (*FollowResolver, error) {
+	followerID, followeeID := string(args.FollowerID), string(args.FolloweeID)
+	if err := update(followerID, followeeID); err != nil {
+		return nil, err
+	}
+
+	follower, err := r.store.GetUser(followerID)
+	if err != nil {
+		return nil, err
+	}
+	followee, err := r.store.GetUser(followeeID)
+	if err != nil {
+		return nil, err
+	}
+	return &FollowResolver{follower: follower, followee: followee}, nil
+}
+
+// subscriptionBuffer is how many events a subscription may fall behind by
+// before it is ended
+const subscriptionBuffer = 64
+
+// NotificationAdded resolves the notificationAdded subscription
+func (r *Resolver) NotificationAdded(ctx context.Context, args struct{ UserID graphql.ID }) <-chan *NotificationResolver {
+	return r.subscribe(ctx, string(args.UserID), events.NotificationAdded)
+}
+
+// NotificationStatusChanged resolves the notificationStatusChanged subscription
+func (r *Resolver) NotificationStatusChanged(ctx context.Context, args struct{ UserID graphql.ID }) <-chan *NotificationResolver {
+	return r.subscribe(ctx, string(args.UserID), events.NotificationStatusChanged)
+}
+
+// subscribe streams a user's events of one type until ctx is done. The
+// channel is closed when the subscription ends, which completes it for the
+// client.
+func (r *Resolver) subscribe(ctx context.Context, userID string, eventType events.Type) <-chan *NotificationResolver {
+	sub := r.queue.Events().Subscribe(userID, subscriptionBuffer)
+	c := make(chan *NotificationResolver)
+
+	go func() {
+		defer close(c)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case event, ok := <-sub.C():
+				if !ok {
+					if err := sub.Err(); err != nil {
+						log.Printf("Subscription for user %s ended: %v", userID, err)
+					}
+					return
+				}
+				if event.Type != eventType {
+					continue
+				}
+				notification := event.Notification
+				select {
+				case c <- &NotificationResolver{notification: &notification, loaders: r.loaders(ctx)}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return c
+}
@@ -0,0 +1,137 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/suyashXD/DNDS/internal/graphql/loader"
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+// countingStore counts the user and post lookups made through it
+type countingStore struct {
+	store.Store
+	getUser, getUsers, getPost, getPosts atomic.Int32
+}
+
+func (s *countingStore) GetUser(id string) (*models.User, error) {
+	s.getUser.Add(1)
+	return s.Store.GetUser(id)
+}
+
+func (s *countingStore) GetUsers(ids []string) (map[string]*models.User, error) {
+	s.getUsers.Add(1)
+	return s.Store.GetUsers(ids)
+}
+
+func (s *countingStore) GetPost(id string) (*models.Post, error) {
+	s.getPost.Add(1)
+	return s.Store.GetPost(id)
+}
+
+func (s *countingStore) GetPosts(ids []string) (map[string]*models.Post, error) {
+	s.getPosts.Add(1)
+	return s.Store.GetPosts(ids)
+}
+
+func TestNestedFieldsBatchStoreCalls(t *testing.T) {
+	st := store.NewMemoryStore(true)
+	// Twenty posts by four authors, one of whom deletes their account
+	authors := []string{"user1", "user3", "user4", "user5"}
+	for i := 0; i < 20; i++ {
+		post := &models.Post{ID: fmt.Sprintf("p%d", i), AuthorID: authors[i%len(authors)], Content: fmt.Sprintf("post %d", i)}
+		if err := st.SavePost(post); err != nil {
+			t.Fatalf("SavePost: %v", err)
+		}
+		if err := st.SaveNotification(models.NewNotification("user2", post)); err != nil {
+			t.Fatalf("SaveNotification: %v", err)
+		}
+	}
+	if err := st.DeleteUser("user5"); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+
+	counting := &countingStore{Store: st}
+	schema, _ := newTestSchema(t, counting)
+	ctx := loader.NewContext(context.Background(), loader.New(counting))
+	resp := schema.Exec(ctx, `query {
+		notifications(userId: "user2", first: 20) { edges { node {
+			authorId
+			recipient { id }
+			author { id username }
+			post { author { id username } }
+		} } }
+	}`, "", nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("query errors: %v", resp.Errors)
+	}
+
+	type user struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	}
+	var data struct {
+		Notifications struct {
+			Edges []struct {
+				Node struct {
+					AuthorID  string `json:"authorId"`
+					Recipient user   `json:"recipient"`
+					Author    user   `json:"author"`
+					Post      struct {
+						Author user `json:"author"`
+					} `json:"post"`
+				} `json:"node"`
+			} `json:"edges"`
+		} `json:"notifications"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Notifications.Edges) != 20 {
+		t.Fatalf("got %d notifications, want 20", len(data.Notifications.Edges))
+	}
+	deleted := 0
+	for i, edge := range data.Notifications.Edges {
+		n := edge.Node
+		if n.Recipient.ID != "user2" || n.Author.ID != n.AuthorID || n.Post.Author != n.Author {
+			t.Errorf("notification %d = %+v, want it sent to user2 by the author of its post", i, n)
+		}
+		if n.AuthorID == "" {
+			deleted++
+			if n.Author.Username != "[deleted]" {
+				t.Errorf("notification %d has author %+v, want the [deleted] placeholder", i, n.Author)
+			}
+		}
+	}
+	if deleted != 5 {
+		t.Errorf("%d notifications by a deleted author, want 5", deleted)
+	}
+
+	if got := counting.getUsers.Load(); got != 1 {
+		t.Errorf("GetUsers called %d times, want one batch", got)
+	}
+	if got := counting.getPosts.Load(); got != 1 {
+		t.Errorf("GetPosts called %d times, want one batch", got)
+	}
+	if users, posts := counting.getUser.Load(), counting.getPost.Load(); users != 0 || posts != 0 {
+		t.Errorf("GetUser and GetPost called %d and %d times, want none outside the batches", users, posts)
+	}
+}
+
+func TestGetMetrics(t *testing.T) {
+	schema, _ := newTestSchema(t, store.NewMemoryStore(true))
+	resp := schema.Exec(context.Background(), `query {
+		getMetrics { totalSent queueSize dropped spilled pendingRetries nextRetryAt }
+	}`, "", nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("query errors: %v", resp.Errors)
+	}
+	want := `{"getMetrics":{"totalSent":0,"queueSize":0,"dropped":0,"spilled":0,"pendingRetries":0,"nextRetryAt":null}}`
+	if string(resp.Data) != want {
+		t.Errorf("getMetrics = %s, want %s", resp.Data, want)
+	}
+}
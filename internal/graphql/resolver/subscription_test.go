@@ -0,0 +1,148 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"github.com/suyashXD/DNDS/internal/delivery"
+	"github.com/suyashXD/DNDS/internal/events"
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/queue"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+// newTestSchema parses the served schema against a resolver over st with a
+// queue that is never started, so events are only what the test publishes
+func newTestSchema(t *testing.T, st store.Store) (*graphql.Schema, *events.Bus) {
+	t.Helper()
+	schemaContent, err := os.ReadFile("../schema/schema.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := queue.NewNotificationQueue(st, delivery.NewSimulatedDeliverer(0, 0, 0), 1)
+	schema := graphql.MustParseSchema(string(schemaContent), NewResolver(st, q))
+	return schema, q.Events()
+}
+
+type subscriptionPayload struct {
+	NotificationStatusChanged struct {
+		ID     string `json:"id"`
+		UserID string `json:"userId"`
+		Status string `json:"status"`
+	} `json:"notificationStatusChanged"`
+}
+
+func subscribeStatusChanged(t *testing.T, ctx context.Context, schema *graphql.Schema, userID string) <-chan interface{} {
+	t.Helper()
+	responses, err := schema.Subscribe(ctx, `subscription($userId: ID!) {
+		notificationStatusChanged(userId: $userId) { id userId status }
+	}`, "", map[string]interface{}{"userId": userID})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	return responses
+}
+
+func nextPayload(t *testing.T, responses <-chan interface{}) subscriptionPayload {
+	t.Helper()
+	select {
+	case r, ok := <-responses:
+		if !ok {
+			t.Fatal("subscription ended")
+		}
+		resp := r.(*graphql.Response)
+		if len(resp.Errors) > 0 {
+			t.Fatalf("subscription errors: %v", resp.Errors)
+		}
+		var payload subscriptionPayload
+		if err := json.Unmarshal(resp.Data, &payload); err != nil {
+			t.Fatal(err)
+		}
+		return payload
+	case <-time.After(5 * time.Second):
+		t.Fatal("no subscription event")
+	}
+	return subscriptionPayload{}
+}
+
+// waitForSubscribers waits until the bus has count subscriptions
+func waitForSubscribers(t *testing.T, bus *events.Bus, count int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for bus.SubscriberCount() != count {
+		if time.Now().After(deadline) {
+			t.Fatalf("SubscriberCount = %d, want %d", bus.SubscriberCount(), count)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNotificationStatusChangedSubscription(t *testing.T) {
+	schema, bus := newTestSchema(t, store.NewMemoryStore(true))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := subscribeStatusChanged(t, ctx, schema, "user2")
+	second := subscribeStatusChanged(t, ctx, schema, "user2")
+	waitForSubscribers(t, bus, 2)
+
+	post := &models.Post{ID: "post1", AuthorID: "user1"}
+	other := models.NewNotification("user3", post)
+	own := models.NewNotification("user2", post)
+	// Neither another user's change nor an addition is sent
+	bus.Publish(events.NotificationStatusChanged, other, models.StatusQueued)
+	bus.Publish(events.NotificationAdded, own, models.StatusUnknown)
+	own.Status = models.StatusDelivered
+	bus.Publish(events.NotificationStatusChanged, own, models.StatusQueued)
+
+	for _, responses := range []<-chan interface{}{first, second} {
+		got := nextPayload(t, responses).NotificationStatusChanged
+		if got.ID != own.ID || got.UserID != "user2" || got.Status != "DELIVERED" {
+			t.Errorf("got %+v, want %s delivered", got, own.ID)
+		}
+	}
+
+	// Ending the operation ends the bus subscription
+	cancel()
+	waitForSubscribers(t, bus, 0)
+}
+
+func TestSlowSubscriptionIsEnded(t *testing.T) {
+	schema, bus := newTestSchema(t, store.NewMemoryStore(true))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	responses := subscribeStatusChanged(t, ctx, schema, "user2")
+	waitForSubscribers(t, bus, 1)
+
+	// Nothing is read while far more than the buffer is published
+	n := models.NewNotification("user2", &models.Post{ID: "post1", AuthorID: "user1"})
+	n.Status = models.StatusDelivered
+	for i := 0; i < 10*subscriptionBuffer; i++ {
+		bus.Publish(events.NotificationStatusChanged, n, models.StatusQueued)
+	}
+	waitForSubscribers(t, bus, 0)
+
+	// The client gets what was buffered, then the subscription completes
+	count := 0
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-responses:
+			if !ok {
+				if count >= 10*subscriptionBuffer {
+					t.Errorf("got all %d events, want the subscription cut short", count)
+				}
+				return
+			}
+			count++
+		case <-timeout:
+			t.Fatalf("subscription still open after %d events", count)
+		}
+	}
+}
@@ -146,12 +146,13 @@ func (x *Post) GetCreatedAt() int64 {
 }
 
 type NotificationResponse struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	PostId              string                 `protobuf:"bytes,1,opt,name=post_id,json=postId,proto3" json:"post_id,omitempty"`
-	NotificationsQueued int32                  `protobuf:"varint,2,opt,name=notifications_queued,json=notificationsQueued,proto3" json:"notifications_queued,omitempty"`
-	Success             bool                   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	PostId               string                 `protobuf:"bytes,1,opt,name=post_id,json=postId,proto3" json:"post_id,omitempty"`
+	NotificationsQueued  int32                  `protobuf:"varint,2,opt,name=notifications_queued,json=notificationsQueued,proto3" json:"notifications_queued,omitempty"`
+	Success              bool                   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
+	NotificationsDropped int32                  `protobuf:"varint,4,opt,name=notifications_dropped,json=notificationsDropped,proto3" json:"notifications_dropped,omitempty"` // Not queued: FAILED if the queue was full, left QUEUED for the next start if it was stopping
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
 }
 
 func (x *NotificationResponse) Reset() {
@@ -205,6 +206,177 @@ func (x *NotificationResponse) GetSuccess() bool {
 	return false
 }
 
+func (x *NotificationResponse) GetNotificationsDropped() int32 {
+	if x != nil {
+		return x.NotificationsDropped
+	}
+	return 0
+}
+
+type BatchPublishPostsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Posts         []*Post                `protobuf:"bytes,1,rep,name=posts,proto3" json:"posts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchPublishPostsRequest) Reset() {
+	*x = BatchPublishPostsRequest{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchPublishPostsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchPublishPostsRequest) ProtoMessage() {}
+
+func (x *BatchPublishPostsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchPublishPostsRequest.ProtoReflect.Descriptor instead.
+func (*BatchPublishPostsRequest) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BatchPublishPostsRequest) GetPosts() []*Post {
+	if x != nil {
+		return x.Posts
+	}
+	return nil
+}
+
+type PublishPostResult struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	PostId              string                 `protobuf:"bytes,1,opt,name=post_id,json=postId,proto3" json:"post_id,omitempty"`
+	NotificationsQueued int32                  `protobuf:"varint,2,opt,name=notifications_queued,json=notificationsQueued,proto3" json:"notifications_queued,omitempty"`
+	Error               string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"` // Empty if the post was published and all its notifications queued
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *PublishPostResult) Reset() {
+	*x = PublishPostResult{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishPostResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishPostResult) ProtoMessage() {}
+
+func (x *PublishPostResult) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishPostResult.ProtoReflect.Descriptor instead.
+func (*PublishPostResult) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PublishPostResult) GetPostId() string {
+	if x != nil {
+		return x.PostId
+	}
+	return ""
+}
+
+func (x *PublishPostResult) GetNotificationsQueued() int32 {
+	if x != nil {
+		return x.NotificationsQueued
+	}
+	return 0
+}
+
+func (x *PublishPostResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type PublishPostsResponse struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Results             []*PublishPostResult   `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	PostsPublished      int32                  `protobuf:"varint,2,opt,name=posts_published,json=postsPublished,proto3" json:"posts_published,omitempty"`
+	NotificationsQueued int32                  `protobuf:"varint,3,opt,name=notifications_queued,json=notificationsQueued,proto3" json:"notifications_queued,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *PublishPostsResponse) Reset() {
+	*x = PublishPostsResponse{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishPostsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishPostsResponse) ProtoMessage() {}
+
+func (x *PublishPostsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishPostsResponse.ProtoReflect.Descriptor instead.
+func (*PublishPostsResponse) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PublishPostsResponse) GetResults() []*PublishPostResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *PublishPostsResponse) GetPostsPublished() int32 {
+	if x != nil {
+		return x.PostsPublished
+	}
+	return 0
+}
+
+func (x *PublishPostsResponse) GetNotificationsQueued() int32 {
+	if x != nil {
+		return x.NotificationsQueued
+	}
+	return 0
+}
+
 type Notification struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -215,13 +387,14 @@ type Notification struct {
 	CreatedAt     int64                  `protobuf:"varint,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`               // When the notification was created
 	Read          bool                   `protobuf:"varint,7,opt,name=read,proto3" json:"read,omitempty"`                                          // Whether notification has been read
 	Status        NotificationStatus     `protobuf:"varint,8,opt,name=status,proto3,enum=notification.NotificationStatus" json:"status,omitempty"` // Current status of the notification
+	Attempts      []*DeliveryAttempt     `protobuf:"bytes,9,rep,name=attempts,proto3" json:"attempts,omitempty"`                                   // Every delivery attempt, oldest first
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Notification) Reset() {
 	*x = Notification{}
-	mi := &file_internal_grpc_proto_notification_proto_msgTypes[2]
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -233,7 +406,7 @@ func (x *Notification) String() string {
 func (*Notification) ProtoMessage() {}
 
 func (x *Notification) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_grpc_proto_notification_proto_msgTypes[2]
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -246,7 +419,7 @@ func (x *Notification) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Notification.ProtoReflect.Descriptor instead.
 func (*Notification) Descriptor() ([]byte, []int) {
-	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{2}
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *Notification) GetId() string {
@@ -305,6 +478,13 @@ func (x *Notification) GetStatus() NotificationStatus {
 	return NotificationStatus_UNKNOWN
 }
 
+func (x *Notification) GetAttempts() []*DeliveryAttempt {
+	if x != nil {
+		return x.Attempts
+	}
+	return nil
+}
+
 type User struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -317,7 +497,7 @@ type User struct {
 
 func (x *User) Reset() {
 	*x = User{}
-	mi := &file_internal_grpc_proto_notification_proto_msgTypes[3]
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -329,7 +509,7 @@ func (x *User) String() string {
 func (*User) ProtoMessage() {}
 
 func (x *User) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_grpc_proto_notification_proto_msgTypes[3]
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -342,7 +522,7 @@ func (x *User) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use User.ProtoReflect.Descriptor instead.
 func (*User) Descriptor() ([]byte, []int) {
-	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{3}
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *User) GetId() string {
@@ -373,46 +553,971 @@ func (x *User) GetFollowingIds() []string {
 	return nil
 }
 
-var File_internal_grpc_proto_notification_proto protoreflect.FileDescriptor
+type FollowRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FollowerId    string                 `protobuf:"bytes,1,opt,name=follower_id,json=followerId,proto3" json:"follower_id,omitempty"` // User who follows
+	FolloweeId    string                 `protobuf:"bytes,2,opt,name=followee_id,json=followeeId,proto3" json:"followee_id,omitempty"` // User being followed
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_internal_grpc_proto_notification_proto_rawDesc = "" +
-	"\n" +
-	"&internal/grpc/proto/notification.proto\x12\fnotification\"l\n" +
-	"\x04Post\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
-	"\tauthor_id\x18\x02 \x01(\tR\bauthorId\x12\x18\n" +
-	"\acontent\x18\x03 \x01(\tR\acontent\x12\x1d\n" +
-	"\n" +
-	"created_at\x18\x04 \x01(\x03R\tcreatedAt\"|\n" +
-	"\x14NotificationResponse\x12\x17\n" +
-	"\apost_id\x18\x01 \x01(\tR\x06postId\x121\n" +
-	"\x14notifications_queued\x18\x02 \x01(\x05R\x13notificationsQueued\x12\x18\n" +
-	"\asuccess\x18\x03 \x01(\bR\asuccess\"\xf4\x01\n" +
-	"\fNotification\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x17\n" +
-	"\apost_id\x18\x03 \x01(\tR\x06postId\x12\x1b\n" +
-	"\tauthor_id\x18\x04 \x01(\tR\bauthorId\x12\x18\n" +
-	"\acontent\x18\x05 \x01(\tR\acontent\x12\x1d\n" +
-	"\n" +
-	"created_at\x18\x06 \x01(\x03R\tcreatedAt\x12\x12\n" +
-	"\x04read\x18\a \x01(\bR\x04read\x128\n" +
-	"\x06status\x18\b \x01(\x0e2 .notification.NotificationStatusR\x06status\"z\n" +
-	"\x04User\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
-	"\busername\x18\x02 \x01(\tR\busername\x12!\n" +
-	"\ffollower_ids\x18\x03 \x03(\tR\vfollowerIds\x12#\n" +
-	"\rfollowing_ids\x18\x04 \x03(\tR\ffollowingIds*V\n" +
-	"\x12NotificationStatus\x12\v\n" +
-	"\aUNKNOWN\x10\x00\x12\n" +
-	"\n" +
-	"\x06QUEUED\x10\x01\x12\r\n" +
-	"\tDELIVERED\x10\x02\x12\n" +
-	"\n" +
-	"\x06FAILED\x10\x03\x12\f\n" +
-	"\bRETRYING\x10\x042^\n" +
-	"\x13NotificationService\x12G\n" +
-	"\vPublishPost\x12\x12.notification.Post\x1a\".notification.NotificationResponse\"\x00B.Z,github.com/suyashXD/DNDS/internal/grpc/protob\x06proto3"
+func (x *FollowRequest) Reset() {
+	*x = FollowRequest{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FollowRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FollowRequest) ProtoMessage() {}
+
+func (x *FollowRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FollowRequest.ProtoReflect.Descriptor instead.
+func (*FollowRequest) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FollowRequest) GetFollowerId() string {
+	if x != nil {
+		return x.FollowerId
+	}
+	return ""
+}
+
+func (x *FollowRequest) GetFolloweeId() string {
+	if x != nil {
+		return x.FolloweeId
+	}
+	return ""
+}
+
+type FollowResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Follower      *User                  `protobuf:"bytes,1,opt,name=follower,proto3" json:"follower,omitempty"`
+	Followee      *User                  `protobuf:"bytes,2,opt,name=followee,proto3" json:"followee,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FollowResponse) Reset() {
+	*x = FollowResponse{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FollowResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FollowResponse) ProtoMessage() {}
+
+func (x *FollowResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FollowResponse.ProtoReflect.Descriptor instead.
+func (*FollowResponse) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *FollowResponse) GetFollower() *User {
+	if x != nil {
+		return x.Follower
+	}
+	return nil
+}
+
+func (x *FollowResponse) GetFollowee() *User {
+	if x != nil {
+		return x.Followee
+	}
+	return nil
+}
+
+type GetUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserRequest) Reset() {
+	*x = GetUserRequest{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserRequest) ProtoMessage() {}
+
+func (x *GetUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserRequest.ProtoReflect.Descriptor instead.
+func (*GetUserRequest) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetUserRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteUserRequest) Reset() {
+	*x = DeleteUserRequest{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteUserRequest) ProtoMessage() {}
+
+func (x *DeleteUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteUserRequest.ProtoReflect.Descriptor instead.
+func (*DeleteUserRequest) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteUserRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteUserResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteUserResponse) Reset() {
+	*x = DeleteUserResponse{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteUserResponse) ProtoMessage() {}
+
+func (x *DeleteUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteUserResponse.ProtoReflect.Descriptor instead.
+func (*DeleteUserResponse) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DeleteUserResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeleteUserResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+type GetUnreadCountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUnreadCountRequest) Reset() {
+	*x = GetUnreadCountRequest{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUnreadCountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUnreadCountRequest) ProtoMessage() {}
+
+func (x *GetUnreadCountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUnreadCountRequest.ProtoReflect.Descriptor instead.
+func (*GetUnreadCountRequest) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetUnreadCountRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type UnreadCountResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	UnreadCount   int32                  `protobuf:"varint,2,opt,name=unread_count,json=unreadCount,proto3" json:"unread_count,omitempty"`
+	TotalCount    int32                  `protobuf:"varint,3,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	StatusCounts  []*StatusCount         `protobuf:"bytes,4,rep,name=status_counts,json=statusCounts,proto3" json:"status_counts,omitempty"` // Statuses with notifications only
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnreadCountResponse) Reset() {
+	*x = UnreadCountResponse{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnreadCountResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnreadCountResponse) ProtoMessage() {}
+
+func (x *UnreadCountResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnreadCountResponse.ProtoReflect.Descriptor instead.
+func (*UnreadCountResponse) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UnreadCountResponse) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UnreadCountResponse) GetUnreadCount() int32 {
+	if x != nil {
+		return x.UnreadCount
+	}
+	return 0
+}
+
+func (x *UnreadCountResponse) GetTotalCount() int32 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+func (x *UnreadCountResponse) GetStatusCounts() []*StatusCount {
+	if x != nil {
+		return x.StatusCounts
+	}
+	return nil
+}
+
+type StatusCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        NotificationStatus     `protobuf:"varint,1,opt,name=status,proto3,enum=notification.NotificationStatus" json:"status,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusCount) Reset() {
+	*x = StatusCount{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusCount) ProtoMessage() {}
+
+func (x *StatusCount) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusCount.ProtoReflect.Descriptor instead.
+func (*StatusCount) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *StatusCount) GetStatus() NotificationStatus {
+	if x != nil {
+		return x.Status
+	}
+	return NotificationStatus_UNKNOWN
+}
+
+func (x *StatusCount) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type StreamRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ResumeAfter   string                 `protobuf:"bytes,2,opt,name=resume_after,json=resumeAfter,proto3" json:"resume_after,omitempty"`                     // Replay notifications newer than this ID first
+	Statuses      []NotificationStatus   `protobuf:"varint,3,rep,packed,name=statuses,proto3,enum=notification.NotificationStatus" json:"statuses,omitempty"` // Only send these statuses; all if empty
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest) Reset() {
+	*x = StreamRequest{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest) ProtoMessage() {}
+
+func (x *StreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest.ProtoReflect.Descriptor instead.
+func (*StreamRequest) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *StreamRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *StreamRequest) GetResumeAfter() string {
+	if x != nil {
+		return x.ResumeAfter
+	}
+	return ""
+}
+
+func (x *StreamRequest) GetStatuses() []NotificationStatus {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+type DeadLetterFilter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`                                  // retries_exhausted, permanent_error or dropped
+	DeadSince     int64                  `protobuf:"varint,3,opt,name=dead_since,json=deadSince,proto3" json:"dead_since,omitempty"`          // Unix timestamp, inclusive
+	DeadBefore    int64                  `protobuf:"varint,4,opt,name=dead_before,json=deadBefore,proto3" json:"dead_before,omitempty"`       // Unix timestamp, exclusive
+	OnlyReplayed  bool                   `protobuf:"varint,5,opt,name=only_replayed,json=onlyReplayed,proto3" json:"only_replayed,omitempty"` // Only match dead letters that were replayed
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeadLetterFilter) Reset() {
+	*x = DeadLetterFilter{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLetterFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLetterFilter) ProtoMessage() {}
+
+func (x *DeadLetterFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLetterFilter.ProtoReflect.Descriptor instead.
+func (*DeadLetterFilter) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *DeadLetterFilter) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *DeadLetterFilter) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *DeadLetterFilter) GetDeadSince() int64 {
+	if x != nil {
+		return x.DeadSince
+	}
+	return 0
+}
+
+func (x *DeadLetterFilter) GetDeadBefore() int64 {
+	if x != nil {
+		return x.DeadBefore
+	}
+	return 0
+}
+
+func (x *DeadLetterFilter) GetOnlyReplayed() bool {
+	if x != nil {
+		return x.OnlyReplayed
+	}
+	return false
+}
+
+type ReplayDeadLetterRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	NotificationId string                 `protobuf:"bytes,1,opt,name=notification_id,json=notificationId,proto3" json:"notification_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ReplayDeadLetterRequest) Reset() {
+	*x = ReplayDeadLetterRequest{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayDeadLetterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayDeadLetterRequest) ProtoMessage() {}
+
+func (x *ReplayDeadLetterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayDeadLetterRequest.ProtoReflect.Descriptor instead.
+func (*ReplayDeadLetterRequest) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ReplayDeadLetterRequest) GetNotificationId() string {
+	if x != nil {
+		return x.NotificationId
+	}
+	return ""
+}
+
+type ReplayDeadLettersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *DeadLetterFilter      `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplayDeadLettersRequest) Reset() {
+	*x = ReplayDeadLettersRequest{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayDeadLettersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayDeadLettersRequest) ProtoMessage() {}
+
+func (x *ReplayDeadLettersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayDeadLettersRequest.ProtoReflect.Descriptor instead.
+func (*ReplayDeadLettersRequest) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ReplayDeadLettersRequest) GetFilter() *DeadLetterFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type PurgeDeadLettersRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	NotificationIds []string               `protobuf:"bytes,1,rep,name=notification_ids,json=notificationIds,proto3" json:"notification_ids,omitempty"`
+	Filter          *DeadLetterFilter      `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"` // Required when notification_ids is empty
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *PurgeDeadLettersRequest) Reset() {
+	*x = PurgeDeadLettersRequest{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PurgeDeadLettersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgeDeadLettersRequest) ProtoMessage() {}
+
+func (x *PurgeDeadLettersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgeDeadLettersRequest.ProtoReflect.Descriptor instead.
+func (*PurgeDeadLettersRequest) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *PurgeDeadLettersRequest) GetNotificationIds() []string {
+	if x != nil {
+		return x.NotificationIds
+	}
+	return nil
+}
+
+func (x *PurgeDeadLettersRequest) GetFilter() *DeadLetterFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type DeadLettersResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Count           int32                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	NotificationIds []string               `protobuf:"bytes,2,rep,name=notification_ids,json=notificationIds,proto3" json:"notification_ids,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DeadLettersResponse) Reset() {
+	*x = DeadLettersResponse{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLettersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLettersResponse) ProtoMessage() {}
+
+func (x *DeadLettersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLettersResponse.ProtoReflect.Descriptor instead.
+func (*DeadLettersResponse) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *DeadLettersResponse) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *DeadLettersResponse) GetNotificationIds() []string {
+	if x != nil {
+		return x.NotificationIds
+	}
+	return nil
+}
+
+type DeliveryAttempt struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Attempt       int32                  `protobuf:"varint,1,opt,name=attempt,proto3" json:"attempt,omitempty"`                         // Number of the delivery, counted from 1 again after a replay
+	Channel       string                 `protobuf:"bytes,2,opt,name=channel,proto3" json:"channel,omitempty"`                          // Delivery channel, e.g. webhook
+	StartedAt     int64                  `protobuf:"varint,3,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`    // Unix time in milliseconds
+	FinishedAt    int64                  `protobuf:"varint,4,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"` // Unix time in milliseconds
+	Outcome       string                 `protobuf:"bytes,5,opt,name=outcome,proto3" json:"outcome,omitempty"`                          // delivered, retrying or failed
+	Error         string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`                              // Empty if the channel succeeded
+	BackoffMs     int64                  `protobuf:"varint,7,opt,name=backoff_ms,json=backoffMs,proto3" json:"backoff_ms,omitempty"`    // Wait before the next attempt; 0 unless retrying
+	ErrorClass    string                 `protobuf:"bytes,8,opt,name=error_class,json=errorClass,proto3" json:"error_class,omitempty"`  // Retry policy error class, e.g. http_5xx; empty if the channel succeeded
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeliveryAttempt) Reset() {
+	*x = DeliveryAttempt{}
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeliveryAttempt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeliveryAttempt) ProtoMessage() {}
+
+func (x *DeliveryAttempt) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_grpc_proto_notification_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeliveryAttempt.ProtoReflect.Descriptor instead.
+func (*DeliveryAttempt) Descriptor() ([]byte, []int) {
+	return file_internal_grpc_proto_notification_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *DeliveryAttempt) GetAttempt() int32 {
+	if x != nil {
+		return x.Attempt
+	}
+	return 0
+}
+
+func (x *DeliveryAttempt) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *DeliveryAttempt) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+func (x *DeliveryAttempt) GetFinishedAt() int64 {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return 0
+}
+
+func (x *DeliveryAttempt) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *DeliveryAttempt) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *DeliveryAttempt) GetBackoffMs() int64 {
+	if x != nil {
+		return x.BackoffMs
+	}
+	return 0
+}
+
+func (x *DeliveryAttempt) GetErrorClass() string {
+	if x != nil {
+		return x.ErrorClass
+	}
+	return ""
+}
+
+var File_internal_grpc_proto_notification_proto protoreflect.FileDescriptor
+
+const file_internal_grpc_proto_notification_proto_rawDesc = "" +
+	"\n" +
+	"&internal/grpc/proto/notification.proto\x12\fnotification\"l\n" +
+	"\x04Post\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\tauthor_id\x18\x02 \x01(\tR\bauthorId\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\x03R\tcreatedAt\"\xb1\x01\n" +
+	"\x14NotificationResponse\x12\x17\n" +
+	"\apost_id\x18\x01 \x01(\tR\x06postId\x121\n" +
+	"\x14notifications_queued\x18\x02 \x01(\x05R\x13notificationsQueued\x12\x18\n" +
+	"\asuccess\x18\x03 \x01(\bR\asuccess\x123\n" +
+	"\x15notifications_dropped\x18\x04 \x01(\x05R\x14notificationsDropped\"D\n" +
+	"\x18BatchPublishPostsRequest\x12(\n" +
+	"\x05posts\x18\x01 \x03(\v2\x12.notification.PostR\x05posts\"u\n" +
+	"\x11PublishPostResult\x12\x17\n" +
+	"\apost_id\x18\x01 \x01(\tR\x06postId\x121\n" +
+	"\x14notifications_queued\x18\x02 \x01(\x05R\x13notificationsQueued\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\xad\x01\n" +
+	"\x14PublishPostsResponse\x129\n" +
+	"\aresults\x18\x01 \x03(\v2\x1f.notification.PublishPostResultR\aresults\x12'\n" +
+	"\x0fposts_published\x18\x02 \x01(\x05R\x0epostsPublished\x121\n" +
+	"\x14notifications_queued\x18\x03 \x01(\x05R\x13notificationsQueued\"\xaf\x02\n" +
+	"\fNotification\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x17\n" +
+	"\apost_id\x18\x03 \x01(\tR\x06postId\x12\x1b\n" +
+	"\tauthor_id\x18\x04 \x01(\tR\bauthorId\x12\x18\n" +
+	"\acontent\x18\x05 \x01(\tR\acontent\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\x03R\tcreatedAt\x12\x12\n" +
+	"\x04read\x18\a \x01(\bR\x04read\x128\n" +
+	"\x06status\x18\b \x01(\x0e2 .notification.NotificationStatusR\x06status\x129\n" +
+	"\battempts\x18\t \x03(\v2\x1d.notification.DeliveryAttemptR\battempts\"z\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12!\n" +
+	"\ffollower_ids\x18\x03 \x03(\tR\vfollowerIds\x12#\n" +
+	"\rfollowing_ids\x18\x04 \x03(\tR\ffollowingIds\"Q\n" +
+	"\rFollowRequest\x12\x1f\n" +
+	"\vfollower_id\x18\x01 \x01(\tR\n" +
+	"followerId\x12\x1f\n" +
+	"\vfollowee_id\x18\x02 \x01(\tR\n" +
+	"followeeId\"p\n" +
+	"\x0eFollowResponse\x12.\n" +
+	"\bfollower\x18\x01 \x01(\v2\x12.notification.UserR\bfollower\x12.\n" +
+	"\bfollowee\x18\x02 \x01(\v2\x12.notification.UserR\bfollowee\" \n" +
+	"\x0eGetUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"#\n" +
+	"\x11DeleteUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\">\n" +
+	"\x12DeleteUserResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\"0\n" +
+	"\x15GetUnreadCountRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"\xb2\x01\n" +
+	"\x13UnreadCountResponse\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12!\n" +
+	"\funread_count\x18\x02 \x01(\x05R\vunreadCount\x12\x1f\n" +
+	"\vtotal_count\x18\x03 \x01(\x05R\n" +
+	"totalCount\x12>\n" +
+	"\rstatus_counts\x18\x04 \x03(\v2\x19.notification.StatusCountR\fstatusCounts\"]\n" +
+	"\vStatusCount\x128\n" +
+	"\x06status\x18\x01 \x01(\x0e2 .notification.NotificationStatusR\x06status\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\"\x89\x01\n" +
+	"\rStreamRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12!\n" +
+	"\fresume_after\x18\x02 \x01(\tR\vresumeAfter\x12<\n" +
+	"\bstatuses\x18\x03 \x03(\x0e2 .notification.NotificationStatusR\bstatuses\"\xa8\x01\n" +
+	"\x10DeadLetterFilter\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x1d\n" +
+	"\n" +
+	"dead_since\x18\x03 \x01(\x03R\tdeadSince\x12\x1f\n" +
+	"\vdead_before\x18\x04 \x01(\x03R\n" +
+	"deadBefore\x12#\n" +
+	"\ronly_replayed\x18\x05 \x01(\bR\fonlyReplayed\"B\n" +
+	"\x17ReplayDeadLetterRequest\x12'\n" +
+	"\x0fnotification_id\x18\x01 \x01(\tR\x0enotificationId\"R\n" +
+	"\x18ReplayDeadLettersRequest\x126\n" +
+	"\x06filter\x18\x01 \x01(\v2\x1e.notification.DeadLetterFilterR\x06filter\"|\n" +
+	"\x17PurgeDeadLettersRequest\x12)\n" +
+	"\x10notification_ids\x18\x01 \x03(\tR\x0fnotificationIds\x126\n" +
+	"\x06filter\x18\x02 \x01(\v2\x1e.notification.DeadLetterFilterR\x06filter\"V\n" +
+	"\x13DeadLettersResponse\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x05R\x05count\x12)\n" +
+	"\x10notification_ids\x18\x02 \x03(\tR\x0fnotificationIds\"\xf5\x01\n" +
+	"\x0fDeliveryAttempt\x12\x18\n" +
+	"\aattempt\x18\x01 \x01(\x05R\aattempt\x12\x18\n" +
+	"\achannel\x18\x02 \x01(\tR\achannel\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\x03 \x01(\x03R\tstartedAt\x12\x1f\n" +
+	"\vfinished_at\x18\x04 \x01(\x03R\n" +
+	"finishedAt\x12\x18\n" +
+	"\aoutcome\x18\x05 \x01(\tR\aoutcome\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\x12\x1d\n" +
+	"\n" +
+	"backoff_ms\x18\a \x01(\x03R\tbackoffMs\x12\x1f\n" +
+	"\verror_class\x18\b \x01(\tR\n" +
+	"errorClass*V\n" +
+	"\x12NotificationStatus\x12\v\n" +
+	"\aUNKNOWN\x10\x00\x12\n" +
+	"\n" +
+	"\x06QUEUED\x10\x01\x12\r\n" +
+	"\tDELIVERED\x10\x02\x12\n" +
+	"\n" +
+	"\x06FAILED\x10\x03\x12\f\n" +
+	"\bRETRYING\x10\x042\xf1\b\n" +
+	"\x13NotificationService\x12G\n" +
+	"\vPublishPost\x12\x12.notification.Post\x1a\".notification.NotificationResponse\"\x00\x12J\n" +
+	"\fPublishPosts\x12\x12.notification.Post\x1a\".notification.PublishPostsResponse\"\x00(\x01\x12a\n" +
+	"\x11BatchPublishPosts\x12&.notification.BatchPublishPostsRequest\x1a\".notification.PublishPostsResponse\"\x00\x12E\n" +
+	"\x06Follow\x12\x1b.notification.FollowRequest\x1a\x1c.notification.FollowResponse\"\x00\x12G\n" +
+	"\bUnfollow\x12\x1b.notification.FollowRequest\x1a\x1c.notification.FollowResponse\"\x00\x126\n" +
+	"\n" +
+	"CreateUser\x12\x12.notification.User\x1a\x12.notification.User\"\x00\x12=\n" +
+	"\aGetUser\x12\x1c.notification.GetUserRequest\x1a\x12.notification.User\"\x00\x126\n" +
+	"\n" +
+	"UpdateUser\x12\x12.notification.User\x1a\x12.notification.User\"\x00\x12Q\n" +
+	"\n" +
+	"DeleteUser\x12\x1f.notification.DeleteUserRequest\x1a .notification.DeleteUserResponse\"\x00\x12Z\n" +
+	"\x0eGetUnreadCount\x12#.notification.GetUnreadCountRequest\x1a!.notification.UnreadCountResponse\"\x00\x12R\n" +
+	"\x13StreamNotifications\x12\x1b.notification.StreamRequest\x1a\x1a.notification.Notification\"\x000\x01\x12^\n" +
+	"\x10ReplayDeadLetter\x12%.notification.ReplayDeadLetterRequest\x1a!.notification.DeadLettersResponse\"\x00\x12`\n" +
+	"\x11ReplayDeadLetters\x12&.notification.ReplayDeadLettersRequest\x1a!.notification.DeadLettersResponse\"\x00\x12^\n" +
+	"\x10PurgeDeadLetters\x12%.notification.PurgeDeadLettersRequest\x1a!.notification.DeadLettersResponse\"\x00B.Z,github.com/suyashXD/DNDS/internal/grpc/protob\x06proto3"
 
 var (
 	file_internal_grpc_proto_notification_proto_rawDescOnce sync.Once
@@ -427,23 +1532,77 @@ func file_internal_grpc_proto_notification_proto_rawDescGZIP() []byte {
 }
 
 var file_internal_grpc_proto_notification_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_internal_grpc_proto_notification_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_internal_grpc_proto_notification_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
 var file_internal_grpc_proto_notification_proto_goTypes = []any{
-	(NotificationStatus)(0),      // 0: notification.NotificationStatus
-	(*Post)(nil),                 // 1: notification.Post
-	(*NotificationResponse)(nil), // 2: notification.NotificationResponse
-	(*Notification)(nil),         // 3: notification.Notification
-	(*User)(nil),                 // 4: notification.User
+	(NotificationStatus)(0),          // 0: notification.NotificationStatus
+	(*Post)(nil),                     // 1: notification.Post
+	(*NotificationResponse)(nil),     // 2: notification.NotificationResponse
+	(*BatchPublishPostsRequest)(nil), // 3: notification.BatchPublishPostsRequest
+	(*PublishPostResult)(nil),        // 4: notification.PublishPostResult
+	(*PublishPostsResponse)(nil),     // 5: notification.PublishPostsResponse
+	(*Notification)(nil),             // 6: notification.Notification
+	(*User)(nil),                     // 7: notification.User
+	(*FollowRequest)(nil),            // 8: notification.FollowRequest
+	(*FollowResponse)(nil),           // 9: notification.FollowResponse
+	(*GetUserRequest)(nil),           // 10: notification.GetUserRequest
+	(*DeleteUserRequest)(nil),        // 11: notification.DeleteUserRequest
+	(*DeleteUserResponse)(nil),       // 12: notification.DeleteUserResponse
+	(*GetUnreadCountRequest)(nil),    // 13: notification.GetUnreadCountRequest
+	(*UnreadCountResponse)(nil),      // 14: notification.UnreadCountResponse
+	(*StatusCount)(nil),              // 15: notification.StatusCount
+	(*StreamRequest)(nil),            // 16: notification.StreamRequest
+	(*DeadLetterFilter)(nil),         // 17: notification.DeadLetterFilter
+	(*ReplayDeadLetterRequest)(nil),  // 18: notification.ReplayDeadLetterRequest
+	(*ReplayDeadLettersRequest)(nil), // 19: notification.ReplayDeadLettersRequest
+	(*PurgeDeadLettersRequest)(nil),  // 20: notification.PurgeDeadLettersRequest
+	(*DeadLettersResponse)(nil),      // 21: notification.DeadLettersResponse
+	(*DeliveryAttempt)(nil),          // 22: notification.DeliveryAttempt
 }
 var file_internal_grpc_proto_notification_proto_depIdxs = []int32{
-	0, // 0: notification.Notification.status:type_name -> notification.NotificationStatus
-	1, // 1: notification.NotificationService.PublishPost:input_type -> notification.Post
-	2, // 2: notification.NotificationService.PublishPost:output_type -> notification.NotificationResponse
-	2, // [2:3] is the sub-list for method output_type
-	1, // [1:2] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	1,  // 0: notification.BatchPublishPostsRequest.posts:type_name -> notification.Post
+	4,  // 1: notification.PublishPostsResponse.results:type_name -> notification.PublishPostResult
+	0,  // 2: notification.Notification.status:type_name -> notification.NotificationStatus
+	22, // 3: notification.Notification.attempts:type_name -> notification.DeliveryAttempt
+	7,  // 4: notification.FollowResponse.follower:type_name -> notification.User
+	7,  // 5: notification.FollowResponse.followee:type_name -> notification.User
+	15, // 6: notification.UnreadCountResponse.status_counts:type_name -> notification.StatusCount
+	0,  // 7: notification.StatusCount.status:type_name -> notification.NotificationStatus
+	0,  // 8: notification.StreamRequest.statuses:type_name -> notification.NotificationStatus
+	17, // 9: notification.ReplayDeadLettersRequest.filter:type_name -> notification.DeadLetterFilter
+	17, // 10: notification.PurgeDeadLettersRequest.filter:type_name -> notification.DeadLetterFilter
+	1,  // 11: notification.NotificationService.PublishPost:input_type -> notification.Post
+	1,  // 12: notification.NotificationService.PublishPosts:input_type -> notification.Post
+	3,  // 13: notification.NotificationService.BatchPublishPosts:input_type -> notification.BatchPublishPostsRequest
+	8,  // 14: notification.NotificationService.Follow:input_type -> notification.FollowRequest
+	8,  // 15: notification.NotificationService.Unfollow:input_type -> notification.FollowRequest
+	7,  // 16: notification.NotificationService.CreateUser:input_type -> notification.User
+	10, // 17: notification.NotificationService.GetUser:input_type -> notification.GetUserRequest
+	7,  // 18: notification.NotificationService.UpdateUser:input_type -> notification.User
+	11, // 19: notification.NotificationService.DeleteUser:input_type -> notification.DeleteUserRequest
+	13, // 20: notification.NotificationService.GetUnreadCount:input_type -> notification.GetUnreadCountRequest
+	16, // 21: notification.NotificationService.StreamNotifications:input_type -> notification.StreamRequest
+	18, // 22: notification.NotificationService.ReplayDeadLetter:input_type -> notification.ReplayDeadLetterRequest
+	19, // 23: notification.NotificationService.ReplayDeadLetters:input_type -> notification.ReplayDeadLettersRequest
+	20, // 24: notification.NotificationService.PurgeDeadLetters:input_type -> notification.PurgeDeadLettersRequest
+	2,  // 25: notification.NotificationService.PublishPost:output_type -> notification.NotificationResponse
+	5,  // 26: notification.NotificationService.PublishPosts:output_type -> notification.PublishPostsResponse
+	5,  // 27: notification.NotificationService.BatchPublishPosts:output_type -> notification.PublishPostsResponse
+	9,  // 28: notification.NotificationService.Follow:output_type -> notification.FollowResponse
+	9,  // 29: notification.NotificationService.Unfollow:output_type -> notification.FollowResponse
+	7,  // 30: notification.NotificationService.CreateUser:output_type -> notification.User
+	7,  // 31: notification.NotificationService.GetUser:output_type -> notification.User
+	7,  // 32: notification.NotificationService.UpdateUser:output_type -> notification.User
+	12, // 33: notification.NotificationService.DeleteUser:output_type -> notification.DeleteUserResponse
+	14, // 34: notification.NotificationService.GetUnreadCount:output_type -> notification.UnreadCountResponse
+	6,  // 35: notification.NotificationService.StreamNotifications:output_type -> notification.Notification
+	21, // 36: notification.NotificationService.ReplayDeadLetter:output_type -> notification.DeadLettersResponse
+	21, // 37: notification.NotificationService.ReplayDeadLetters:output_type -> notification.DeadLettersResponse
+	21, // 38: notification.NotificationService.PurgeDeadLetters:output_type -> notification.DeadLettersResponse
+	25, // [25:39] is the sub-list for method output_type
+	11, // [11:25] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_internal_grpc_proto_notification_proto_init() }
@@ -457,7 +1616,7 @@ func file_internal_grpc_proto_notification_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_grpc_proto_notification_proto_rawDesc), len(file_internal_grpc_proto_notification_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   4,
+			NumMessages:   22,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
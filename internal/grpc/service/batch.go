@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/suyashXD/DNDS/internal/grpc/proto"
+)
+
+// PublishPosts publishes a stream of posts. Each post is saved and its
+// notifications queued before the next is read, so a full queue holds the
+// client back instead of dropping notifications.
+func (s *NotificationService) PublishPosts(stream grpc.ClientStreamingServer[proto.Post, proto.PublishPostsResponse]) error {
+	ctx := stream.Context()
+	resp := &proto.PublishPostsResponse{}
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		s.publishBatchPost(ctx, req, resp)
+	}
+
+	log.Printf("Published %d posts from stream, queued %d notifications", resp.PostsPublished, resp.NotificationsQueued)
+	return stream.SendAndClose(resp)
+}
+
+// BatchPublishPosts publishes several posts in order, waiting for queue
+// space rather than dropping notifications
+func (s *NotificationService) BatchPublishPosts(ctx context.Context, req *proto.BatchPublishPostsRequest) (*proto.PublishPostsResponse, error) {
+	resp := &proto.PublishPostsResponse{}
+	for _, post := range req.Posts {
+		s.publishBatchPost(ctx, post, resp)
+	}
+
+	log.Printf("Published %d posts from batch, queued %d notifications", resp.PostsPublished, resp.NotificationsQueued)
+	return resp, nil
+}
+
+// publishBatchPost publishes one post of a batch and appends its result to
+// resp. A post that fails to save, or whose notifications could not all be
+// queued because the call was cancelled or the queue stopped, is reported in
+// its result; the rest of the batch still goes ahead. Once the call is
+// cancelled, the posts after it are reported as not processed without being
+// saved.
+func (s *NotificationService) publishBatchPost(ctx context.Context, req *proto.Post, resp *proto.PublishPostsResponse) {
+	if err := ctx.Err(); err != nil {
+		resp.Results = append(resp.Results, &proto.PublishPostResult{
+			PostId: req.Id,
+			Error:  fmt.Sprintf("not processed: %v", err),
+		})
+		return
+	}
+
+	post, notifications, err := s.savePost(req)
+	if err != nil {
+		resp.Results = append(resp.Results, &proto.PublishPostResult{
+			PostId: req.Id,
+			Error:  status.Convert(err).Message(),
+		})
+		return
+	}
+
+	queued, err := s.queue.QueueNotificationsWait(ctx, notifications)
+	resp.PostsPublished++
+	resp.NotificationsQueued += int32(queued)
+	result := &proto.PublishPostResult{
+		PostId:              post.ID,
+		NotificationsQueued: int32(queued),
+	}
+	if err != nil {
+		result.Error = fmt.Sprintf("queued %d of %d notifications: %v", queued, len(notifications), err)
+	}
+	resp.Results = append(resp.Results, result)
+}
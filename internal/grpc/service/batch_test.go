@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/grpc/proto"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+func TestBatchPublishPostsReportsQueueFailuresPerPost(t *testing.T) {
+	st := store.NewMemoryStore(true)
+	svc := newTestService(t, st)
+	fillQueue(t, svc.queue, 3)
+
+	// The post that fails to save does not stop the batch. The next one gets
+	// the free space and times out waiting for more, and the one after it is
+	// not processed at all.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	resp, err := svc.BatchPublishPosts(ctx, &proto.BatchPublishPostsRequest{Posts: []*proto.Post{
+		{Id: "a", AuthorId: "nobody"},
+		{Id: "b", AuthorId: "user1"},
+		{Id: "c", AuthorId: "user1"},
+	}})
+	if err != nil {
+		t.Fatalf("BatchPublishPosts = %v, want the partial response", err)
+	}
+	if resp.PostsPublished != 1 || resp.NotificationsQueued != 3 || len(resp.Results) != 3 {
+		t.Fatalf("published %d posts with %d notifications and %d results, want 1, 3 and 3", resp.PostsPublished, resp.NotificationsQueued, len(resp.Results))
+	}
+
+	want := []struct {
+		id     string
+		queued int32
+		err    string
+	}{
+		{"a", 0, "followers"},
+		{"b", 3, "queued 3 of 6 notifications: context deadline exceeded"},
+		{"c", 0, "not processed: context deadline exceeded"},
+	}
+	for i, w := range want {
+		got := resp.Results[i]
+		if got.PostId != w.id || got.NotificationsQueued != w.queued || !strings.Contains(got.Error, w.err) {
+			t.Errorf("result %d = %+v, want %s with %d queued and error %q", i, got, w.id, w.queued, w.err)
+		}
+	}
+	if _, err := st.GetPost("b"); err != nil {
+		t.Errorf("GetPost(b): %v", err)
+	}
+	if _, err := st.GetPost("c"); !errors.Is(err, store.ErrPostNotFound) {
+		t.Errorf("GetPost(c) = %v, want the unprocessed post not saved", err)
+	}
+}
+
+func TestBatchPublishPostsAfterStop(t *testing.T) {
+	st := store.NewMemoryStore(true)
+	svc := newTestService(t, st)
+	svc.queue.Stop()
+
+	resp, err := svc.BatchPublishPosts(context.Background(), &proto.BatchPublishPostsRequest{Posts: []*proto.Post{
+		{Id: "a", AuthorId: "user1"},
+		{Id: "b", AuthorId: "user2"},
+	}})
+	if err != nil {
+		t.Fatalf("BatchPublishPosts = %v, want the partial response", err)
+	}
+	if resp.PostsPublished != 2 || len(resp.Results) != 2 {
+		t.Fatalf("published %d posts with %d results, want 2 and 2", resp.PostsPublished, len(resp.Results))
+	}
+	for _, result := range resp.Results {
+		if !strings.Contains(result.Error, "stopped") {
+			t.Errorf("result %+v, want a queue stopped error", result)
+		}
+	}
+}
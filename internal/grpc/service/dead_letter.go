@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/suyashXD/DNDS/internal/grpc/proto"
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/queue"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+// ReplayDeadLetter queues a dead-lettered notification again
+func (s *NotificationService) ReplayDeadLetter(ctx context.Context, req *proto.ReplayDeadLetterRequest) (*proto.DeadLettersResponse, error) {
+	if req.NotificationId == "" {
+		return nil, status.Error(codes.InvalidArgument, "notification_id is required")
+	}
+	if err := s.queue.ReplayDeadLetter(ctx, req.NotificationId); err != nil {
+		return nil, deadLetterError("replay dead letter", err)
+	}
+	return deadLettersResponse([]string{req.NotificationId}), nil
+}
+
+// ReplayDeadLetters replays the dead letters matching the filter that have
+// not been replayed yet
+func (s *NotificationService) ReplayDeadLetters(ctx context.Context, req *proto.ReplayDeadLettersRequest) (*proto.DeadLettersResponse, error) {
+	replayed, err := s.queue.ReplayDeadLetters(ctx, deadLetterFilterFromProto(req.Filter))
+	if err != nil {
+		return nil, deadLetterError("replay dead letters", err)
+	}
+	return deadLettersResponse(replayed), nil
+}
+
+// PurgeDeadLetters deletes dead letters by notification ID or filter. A
+// filter is required without IDs, so an empty request purges nothing.
+func (s *NotificationService) PurgeDeadLetters(ctx context.Context, req *proto.PurgeDeadLettersRequest) (*proto.DeadLettersResponse, error) {
+	if len(req.NotificationIds) == 0 && req.Filter == nil {
+		return nil, status.Error(codes.InvalidArgument, "notification_ids or filter is required")
+	}
+	purged, err := s.queue.PurgeDeadLetters(req.NotificationIds, deadLetterFilterFromProto(req.Filter))
+	if err != nil {
+		return nil, storeError("purge dead letters", err)
+	}
+	return deadLettersResponse(purged), nil
+}
+
+// deadLetterError maps an error replaying dead letters to a gRPC status
+func deadLetterError(action string, err error) error {
+	switch {
+	case errors.Is(err, queue.ErrDeadLetterReplayed):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, queue.ErrQueueStopped):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return storeError(action, err)
+	}
+}
+
+// deadLetterFilterFromProto converts a filter message; nil matches everything
+func deadLetterFilterFromProto(f *proto.DeadLetterFilter) store.DeadLetterFilter {
+	var filter store.DeadLetterFilter
+	if f == nil {
+		return filter
+	}
+	filter.UserID = f.UserId
+	filter.Reason = models.DeadLetterReason(f.Reason)
+	if f.OnlyReplayed {
+		replayed := true
+		filter.Replayed = &replayed
+	}
+	if f.DeadSince != 0 {
+		filter.DeadSince = time.Unix(f.DeadSince, 0)
+	}
+	if f.DeadBefore != 0 {
+		filter.DeadBefore = time.Unix(f.DeadBefore, 0)
+	}
+	return filter
+}
+
+func deadLettersResponse(notificationIDs []string) *proto.DeadLettersResponse {
+	return &proto.DeadLettersResponse{
+		Count:           int32(len(notificationIDs)),
+		NotificationIds: notificationIDs,
+	}
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,20 +20,49 @@ import (
 // NotificationService implements the gRPC NotificationService
 type NotificationService struct {
 	proto.UnimplementedNotificationServiceServer
-	store *store.MemoryStore
+	store store.Store
 	queue *queue.NotificationQueue
 }
 
 // NewNotificationService creates a new notification service
-func NewNotificationService(store *store.MemoryStore, queue *queue.NotificationQueue) *NotificationService {
+func NewNotificationService(store store.Store, queue *queue.NotificationQueue) *NotificationService {
 	return &NotificationService{
 		store: store,
 		queue: queue,
 	}
 }
 
-// PublishPost handles new post events and triggers notifications to followers
+// PublishPost handles new post events and triggers notifications to followers.
+// The post is saved before its notifications are queued, so the call is not
+// idempotent: when queueing fails the error carries the NotificationResponse
+// as a detail, and retrying it publishes the post again.
 func (s *NotificationService) PublishPost(ctx context.Context, req *proto.Post) (*proto.NotificationResponse, error) {
+	post, notifications, err := s.savePost(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Queue notifications for delivery
+	queuedCount, err := s.queue.QueueNotifications(ctx, notifications)
+
+	log.Printf("Post %s published by %s, queued %d notifications", post.ID, post.AuthorID, queuedCount)
+
+	// Return response
+	resp := &proto.NotificationResponse{
+		PostId:               post.ID,
+		NotificationsQueued:  int32(queuedCount),
+		NotificationsDropped: int32(len(notifications) - queuedCount),
+		Success:              err == nil,
+	}
+	if err != nil {
+		return nil, queueError(err, resp)
+	}
+	return resp, nil
+}
+
+// savePost stores a post along with a notification for each of the author's
+// followers. The notifications are returned for the caller to queue.
+func (s *NotificationService) savePost(req *proto.Post) (*models.Post, []*models.Notification, error) {
 	// Create an internal post model from the request
 	post := &models.Post{
 		ID:        req.Id,
@@ -50,44 +81,194 @@ func (s *NotificationService) PublishPost(ctx context.Context, req *proto.Post)
 		post.CreatedAt = time.Now()
 	}
 
-	// Save the post
-	err := s.store.SavePost(post)
-	if err != nil {
-		log.Printf("Failed to save post: %v", err)
-		return nil, status.Errorf(codes.Internal, "failed to save post: %v", err)
-	}
-
 	// Get the author's followers
 	followers, err := s.store.GetFollowers(post.AuthorID)
 	if err != nil {
 		log.Printf("Failed to get followers: %v", err)
-		return nil, status.Errorf(codes.Internal, "failed to get followers: %v", err)
+		return nil, nil, status.Errorf(codes.Internal, "failed to get followers: %v", err)
 	}
 
 	// Create notifications for each follower
 	notifications := make([]*models.Notification, 0, len(followers))
 	for _, follower := range followers {
-		notification := models.NewNotification(follower.ID, post)
-		
-		// Save the notification first
-		err := s.store.SaveNotification(notification)
-		if err != nil {
-			log.Printf("Failed to save notification for user %s: %v", follower.ID, err)
-			continue
-		}
-		
-		notifications = append(notifications, notification)
+		notifications = append(notifications, models.NewNotification(follower.ID, post))
 	}
 
-	// Queue notifications for delivery
-	queuedCount := s.queue.QueueNotifications(notifications)
+	// Save the post and its notifications atomically
+	err = s.store.SavePostWithNotifications(post, notifications)
+	if err != nil {
+		log.Printf("Failed to save post: %v", err)
+		return nil, nil, status.Errorf(codes.Internal, "failed to save post: %v", err)
+	}
 
-	log.Printf("Post %s published by %s, queued %d notifications", post.ID, post.AuthorID, queuedCount)
+	return post, notifications, nil
+}
 
-	// Return response
-	return &proto.NotificationResponse{
-		PostId:             post.ID,
-		NotificationsQueued: int32(queuedCount),
-		Success:            true,
-	}, nil
-}
\ No newline at end of file
+// Follow makes the follower follow the followee
+func (s *NotificationService) Follow(ctx context.Context, req *proto.FollowRequest) (*proto.FollowResponse, error) {
+	return s.updateFollow(req, s.store.Follow)
+}
+
+// Unfollow removes the follow edge between two users
+func (s *NotificationService) Unfollow(ctx context.Context, req *proto.FollowRequest) (*proto.FollowResponse, error) {
+	return s.updateFollow(req, s.store.Unfollow)
+}
+
+// updateFollow validates req, applies update and returns both users
+func (s *NotificationService) updateFollow(req *proto.FollowRequest, update func(followerID, followeeID string) error) (*proto.FollowResponse, error) {
+	if req.FollowerId == "" || req.FolloweeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "follower_id and followee_id are required")
+	}
+
+	if err := update(req.FollowerId, req.FolloweeId); err != nil {
+		return nil, storeError("update follow", err)
+	}
+
+	follower, err := s.getUser(req.FollowerId)
+	if err != nil {
+		return nil, err
+	}
+	followee, err := s.getUser(req.FolloweeId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.FollowResponse{Follower: follower, Followee: followee}, nil
+}
+
+// CreateUser registers a new user. An ID is generated if none is given.
+func (s *NotificationService) CreateUser(ctx context.Context, req *proto.User) (*proto.User, error) {
+	if req.Username == "" {
+		return nil, status.Error(codes.InvalidArgument, "username is required")
+	}
+
+	user := &models.User{ID: req.Id, Username: req.Username}
+	if user.ID == "" {
+		user.ID = uuid.New().String()
+	}
+
+	if err := s.store.CreateUser(user); err != nil {
+		return nil, storeError("create user", err)
+	}
+	log.Printf("User %s (%s) created", user.ID, user.Username)
+
+	return s.getUser(user.ID)
+}
+
+// GetUser looks up a user by ID
+func (s *NotificationService) GetUser(ctx context.Context, req *proto.GetUserRequest) (*proto.User, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	return s.getUser(req.Id)
+}
+
+// UpdateUser changes a user's username
+func (s *NotificationService) UpdateUser(ctx context.Context, req *proto.User) (*proto.User, error) {
+	if req.Id == "" || req.Username == "" {
+		return nil, status.Error(codes.InvalidArgument, "id and username are required")
+	}
+
+	if err := s.store.UpdateUser(&models.User{ID: req.Id, Username: req.Username}); err != nil {
+		return nil, storeError("update user", err)
+	}
+	return s.getUser(req.Id)
+}
+
+// DeleteUser removes a user and cascades to their edges and notifications
+func (s *NotificationService) DeleteUser(ctx context.Context, req *proto.DeleteUserRequest) (*proto.DeleteUserResponse, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	if err := s.store.DeleteUser(req.Id); err != nil {
+		return nil, storeError("delete user", err)
+	}
+	log.Printf("User %s deleted", req.Id)
+
+	return &proto.DeleteUserResponse{Id: req.Id, Success: true}, nil
+}
+
+// GetUnreadCount returns a user's notification counters
+func (s *NotificationService) GetUnreadCount(ctx context.Context, req *proto.GetUnreadCountRequest) (*proto.UnreadCountResponse, error) {
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if _, err := s.store.GetUser(req.UserId); err != nil {
+		return nil, storeError("get user", err)
+	}
+
+	counts, err := s.store.GetNotificationCounts(req.UserId)
+	if err != nil {
+		return nil, storeError("count notifications", err)
+	}
+
+	resp := &proto.UnreadCountResponse{
+		UserId:      req.UserId,
+		UnreadCount: int32(counts.Unread),
+		TotalCount:  int32(counts.Total),
+	}
+	// Proto statuses share the model's numbering
+	for notificationStatus, count := range counts.ByStatus {
+		resp.StatusCounts = append(resp.StatusCounts, &proto.StatusCount{
+			Status: proto.NotificationStatus(notificationStatus),
+			Count:  int32(count),
+		})
+	}
+	sort.Slice(resp.StatusCounts, func(i, j int) bool {
+		return resp.StatusCounts[i].Status < resp.StatusCounts[j].Status
+	})
+	return resp, nil
+}
+
+func (s *NotificationService) getUser(id string) (*proto.User, error) {
+	user, err := s.store.GetUser(id)
+	if err != nil {
+		return nil, storeError("get user", err)
+	}
+	return userToProto(user), nil
+}
+
+// storeError maps a store error to a gRPC status
+func storeError(action string, err error) error {
+	switch {
+	case errors.Is(err, store.ErrUserNotFound), errors.Is(err, store.ErrPostNotFound), errors.Is(err, store.ErrNotificationNotFound),
+		errors.Is(err, store.ErrDeadLetterNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, store.ErrUserExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, store.ErrSelfFollow):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		log.Printf("Failed to %s: %v", action, err)
+		return status.Errorf(codes.Internal, "failed to %s: %v", action, err)
+	}
+}
+
+// queueError maps a queue error to a gRPC status carrying resp as a detail,
+// so clients can still see what was saved, queued and dropped
+func queueError(err error, resp *proto.NotificationResponse) error {
+	var st *status.Status
+	switch {
+	case errors.Is(err, queue.ErrQueueFull):
+		st = status.New(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, queue.ErrQueueStopped):
+		st = status.New(codes.Unavailable, err.Error())
+	default:
+		st = status.FromContextError(err)
+	}
+	if detailed, detailErr := st.WithDetails(resp); detailErr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// userToProto converts a user model to its protobuf message
+func userToProto(user *models.User) *proto.User {
+	return &proto.User{
+		Id:           user.ID,
+		Username:     user.Username,
+		FollowerIds:  user.FollowerIDs,
+		FollowingIds: user.FollowingIDs,
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/suyashXD/DNDS/internal/grpc/proto"
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/queue"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+// fillQueue buffers notifications until only free slots are left. The queue
+// is never started, so nothing drains it.
+func fillQueue(t *testing.T, q *queue.NotificationQueue, free int) {
+	t.Helper()
+	post := &models.Post{ID: "filler", AuthorID: "user1"}
+	filler := make([]*models.Notification, 1000-free)
+	for i := range filler {
+		filler[i] = models.NewNotification("user2", post)
+	}
+	if _, err := q.QueueNotifications(context.Background(), filler); err != nil {
+		t.Fatalf("QueueNotifications: %v", err)
+	}
+}
+
+func TestPublishPostErrorsCarryTheResponse(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name       string
+		setup      func(t *testing.T, q *queue.NotificationQueue)
+		ctx        context.Context
+		code       codes.Code
+		queued     int32
+		dropped    int32
+		wantStatus models.NotificationStatus // of the dropped notifications
+	}{
+		{
+			name: "rejected",
+			setup: func(t *testing.T, q *queue.NotificationQueue) {
+				q.SetOverflowPolicy(queue.OverflowReject)
+				fillQueue(t, q, 2)
+			},
+			ctx:        context.Background(),
+			code:       codes.ResourceExhausted,
+			queued:     2,
+			dropped:    4,
+			wantStatus: models.StatusFailed,
+		},
+		{
+			name:       "blocked past the deadline",
+			setup:      func(t *testing.T, q *queue.NotificationQueue) { fillQueue(t, q, 0) },
+			ctx:        cancelled,
+			code:       codes.Canceled,
+			queued:     0,
+			dropped:    6,
+			wantStatus: models.StatusFailed,
+		},
+		{
+			name:       "stopped",
+			setup:      func(t *testing.T, q *queue.NotificationQueue) { q.Stop() },
+			ctx:        context.Background(),
+			code:       codes.Unavailable,
+			queued:     0,
+			dropped:    6,
+			wantStatus: models.StatusQueued,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := store.NewMemoryStore(true)
+			svc := newTestService(t, st)
+			tt.setup(t, svc.queue)
+
+			// user1 has six followers
+			resp, err := svc.PublishPost(tt.ctx, &proto.Post{Id: "new", AuthorId: "user1", Content: "hi"})
+			if resp != nil {
+				t.Errorf("PublishPost returned a response with its error")
+			}
+			s := status.Convert(err)
+			if s.Code() != tt.code {
+				t.Fatalf("PublishPost = %v, want %s", err, tt.code)
+			}
+			details := s.Details()
+			if len(details) != 1 {
+				t.Fatalf("error has %d details, want the NotificationResponse", len(details))
+			}
+			detail, ok := details[0].(*proto.NotificationResponse)
+			if !ok {
+				t.Fatalf("error detail is %T, want *proto.NotificationResponse", details[0])
+			}
+			if detail.PostId != "new" || detail.Success || detail.NotificationsQueued != tt.queued || detail.NotificationsDropped != tt.dropped {
+				t.Errorf("detail = %+v, want %d queued and %d dropped", detail, tt.queued, tt.dropped)
+			}
+
+			// The post was saved anyway, which is why a blind retry duplicates it
+			if _, err := st.GetPost("new"); err != nil {
+				t.Errorf("GetPost: %v", err)
+			}
+			left := 0
+			for _, follower := range []string{"user2", "user3", "user4", "user5", "user6", "user7"} {
+				page, err := st.QueryNotifications(store.NotificationQuery{
+					UserID: follower,
+					Filter: store.NotificationFilter{PostID: "new", Statuses: []models.NotificationStatus{tt.wantStatus}},
+					First:  10,
+				})
+				if err != nil {
+					t.Fatalf("QueryNotifications: %v", err)
+				}
+				left += len(page.Notifications)
+			}
+			if left != int(tt.dropped) {
+				t.Errorf("%d dropped notifications are %v, want %d", left, tt.wantStatus, tt.dropped)
+			}
+		})
+	}
+}
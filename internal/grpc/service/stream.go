@@ -0,0 +1,130 @@
+package service
+
+import (
+	"log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/suyashXD/DNDS/internal/grpc/proto"
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+const (
+	// streamBuffer bounds how far a stream may fall behind before it is ended
+	streamBuffer = 64
+	// streamReplayPage is how many notifications a resumed stream reads from
+	// the store at a time
+	streamReplayPage = 500
+)
+
+// StreamNotifications sends a user's notifications whenever they are queued
+// or change status. With resume_after it first replays the notifications
+// newer than that one, oldest first, in their current status.
+func (s *NotificationService) StreamNotifications(req *proto.StreamRequest, stream grpc.ServerStreamingServer[proto.Notification]) error {
+	if req.UserId == "" {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if _, err := s.store.GetUser(req.UserId); err != nil {
+		return storeError("get user", err)
+	}
+
+	statuses := make([]models.NotificationStatus, len(req.Statuses))
+	for i, st := range req.Statuses {
+		// Proto statuses share the model's numbering
+		statuses[i] = models.NotificationStatus(st)
+	}
+	filter := store.NotificationFilter{Statuses: statuses}
+
+	// Subscribe before replaying so nothing published in between is missed
+	sub := s.queue.Events().Subscribe(req.UserId, streamBuffer)
+	defer sub.Unsubscribe()
+
+	// replayed remembers the status each notification was replayed in, so
+	// the same state is not sent again when its event arrives
+	replayed := make(map[string]models.NotificationStatus)
+	if req.ResumeAfter != "" {
+		// Page forward from the cursor until caught up, so nothing between
+		// it and the newest notifications is skipped
+		after := req.ResumeAfter
+		for {
+			page, err := s.store.QueryNotifications(store.NotificationQuery{
+				UserID: req.UserId,
+				Filter: filter,
+				Order:  store.OldestFirst,
+				After:  after,
+				First:  streamReplayPage,
+			})
+			if err != nil {
+				return storeError("replay notifications", err)
+			}
+			for _, n := range page.Notifications {
+				if err := stream.Send(notificationToProto(n)); err != nil {
+					return err
+				}
+				replayed[n.ID] = n.Status
+				after = n.ID
+			}
+			if !page.HasNext || len(page.Notifications) == 0 {
+				break
+			}
+		}
+	}
+
+	ctx := stream.Context()
+	for {
+		select {
+		case event, ok := <-sub.C():
+			if !ok {
+				log.Printf("Notification stream for user %s ended: %v", req.UserId, sub.Err())
+				return status.Error(codes.ResourceExhausted, "stream fell behind; reconnect with resume_after")
+			}
+			n := &event.Notification
+			if !filter.Match(n) {
+				continue
+			}
+			if previous, ok := replayed[n.ID]; ok && previous == n.Status {
+				continue
+			}
+			if err := stream.Send(notificationToProto(n)); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// notificationToProto converts a notification model to its protobuf message
+func notificationToProto(n *models.Notification) *proto.Notification {
+	return &proto.Notification{
+		Id:        n.ID,
+		UserId:    n.UserID,
+		PostId:    n.PostID,
+		AuthorId:  n.AuthorID,
+		Content:   n.Content,
+		CreatedAt: n.CreatedAt.Unix(),
+		Read:      n.Read,
+		Status:    proto.NotificationStatus(n.Status),
+		Attempts:  deliveryAttemptsToProto(n.DeliveryAttempts),
+	}
+}
+
+func deliveryAttemptsToProto(attempts []models.DeliveryAttempt) []*proto.DeliveryAttempt {
+	result := make([]*proto.DeliveryAttempt, len(attempts))
+	for i, a := range attempts {
+		result[i] = &proto.DeliveryAttempt{
+			Attempt:    int32(a.Attempt),
+			Channel:    a.Channel,
+			StartedAt:  a.StartedAt.UnixMilli(),
+			FinishedAt: a.FinishedAt.UnixMilli(),
+			Outcome:    string(a.Outcome),
+			Error:      a.Error,
+			BackoffMs:  a.Backoff.Milliseconds(),
+			ErrorClass: a.ErrorClass,
+		}
+	}
+	return result
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/suyashXD/DNDS/internal/delivery"
+	"github.com/suyashXD/DNDS/internal/grpc/proto"
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/queue"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+// recordingStream is a StreamNotifications stream that records what is sent
+// and ends once want messages have arrived
+type recordingStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	cancel context.CancelFunc
+	want   int
+
+	mu   sync.Mutex
+	sent []*proto.Notification
+}
+
+func newRecordingStream(want int) *recordingStream {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	return &recordingStream{ctx: ctx, cancel: cancel, want: want}
+}
+
+func (s *recordingStream) Context() context.Context { return s.ctx }
+
+func (s *recordingStream) Send(n *proto.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, n)
+	if len(s.sent) == s.want {
+		s.cancel()
+	}
+	return nil
+}
+
+func newTestService(t *testing.T, st store.Store) *NotificationService {
+	t.Helper()
+	q := queue.NewNotificationQueue(st, delivery.NewSimulatedDeliverer(0, 0, 0), 1)
+	return NewNotificationService(st, q)
+}
+
+// saveNotifications stores count notifications for user2, a millisecond apart
+func saveNotifications(t *testing.T, st store.Store, count int) []*models.Notification {
+	t.Helper()
+	post, err := st.GetPost("post1")
+	if err != nil {
+		t.Fatalf("GetPost: %v", err)
+	}
+	base := time.Now().Add(-time.Hour)
+	notifications := make([]*models.Notification, count)
+	for i := range notifications {
+		n := models.NewNotification("user2", post)
+		n.CreatedAt = base.Add(time.Duration(i) * time.Millisecond)
+		if err := st.SaveNotification(n); err != nil {
+			t.Fatalf("SaveNotification: %v", err)
+		}
+		notifications[i] = n
+	}
+	return notifications
+}
+
+func TestStreamNotificationsResumeReplaysEverythingMissed(t *testing.T) {
+	for _, count := range []int{9, 2*streamReplayPage + 7} {
+		st := store.NewMemoryStore(true)
+		svc := newTestService(t, st)
+		notifications := saveNotifications(t, st, count)
+
+		// Resuming after the second notification must replay all the rest,
+		// not only the newest page
+		want := notifications[2:]
+		stream := newRecordingStream(len(want))
+		err := svc.StreamNotifications(&proto.StreamRequest{UserId: "user2", ResumeAfter: notifications[1].ID}, stream)
+		if err != context.Canceled {
+			t.Fatalf("StreamNotifications = %v, want it to end on cancellation", err)
+		}
+
+		if len(stream.sent) != len(want) {
+			t.Fatalf("replayed %d of %d notifications, want %d", len(stream.sent), count, len(want))
+		}
+		for i, n := range want {
+			if stream.sent[i].Id != n.ID {
+				t.Fatalf("replayed notification %d is %s, want %s (oldest first)", i, stream.sent[i].Id, n.ID)
+			}
+		}
+	}
+}
+
+func TestStreamNotificationsResumeFilteredByStatus(t *testing.T) {
+	st := store.NewMemoryStore(true)
+	svc := newTestService(t, st)
+	notifications := saveNotifications(t, st, 6)
+	for _, n := range notifications[3:5] {
+		n.Status = models.StatusDelivered
+		if err := st.UpdateNotification(n); err != nil {
+			t.Fatalf("UpdateNotification: %v", err)
+		}
+	}
+
+	stream := newRecordingStream(2)
+	err := svc.StreamNotifications(&proto.StreamRequest{
+		UserId:      "user2",
+		ResumeAfter: notifications[0].ID,
+		Statuses:    []proto.NotificationStatus{proto.NotificationStatus_DELIVERED},
+	}, stream)
+	if err != context.Canceled {
+		t.Fatalf("StreamNotifications = %v, want it to end on cancellation", err)
+	}
+	if len(stream.sent) != 2 || stream.sent[0].Id != notifications[3].ID || stream.sent[1].Id != notifications[4].ID {
+		t.Errorf("replayed %v, want the two delivered notifications", stream.sent)
+	}
+}
+
+func TestStreamNotificationsSendsTheDeliveryHistory(t *testing.T) {
+	st := store.NewMemoryStore(true)
+	svc := newTestService(t, st)
+	notifications := saveNotifications(t, st, 2)
+
+	started := time.UnixMilli(1767323045000)
+	n := notifications[1]
+	n.Status = models.StatusDelivered
+	n.Attempts = 1
+	n.DeliveryAttempts = []models.DeliveryAttempt{
+		{Attempt: 1, Channel: "webhook", StartedAt: started, FinishedAt: started.Add(time.Second), Outcome: models.OutcomeRetrying,
+			Error: "webhook responded with status 503", ErrorClass: "http_5xx", Backoff: 1500 * time.Millisecond},
+		{Attempt: 2, Channel: "webhook", StartedAt: started.Add(2 * time.Second), FinishedAt: started.Add(3 * time.Second), Outcome: models.OutcomeDelivered},
+	}
+	if err := st.UpdateNotification(n); err != nil {
+		t.Fatalf("UpdateNotification: %v", err)
+	}
+
+	stream := newRecordingStream(1)
+	err := svc.StreamNotifications(&proto.StreamRequest{UserId: "user2", ResumeAfter: notifications[0].ID}, stream)
+	if err != context.Canceled {
+		t.Fatalf("StreamNotifications = %v, want it to end on cancellation", err)
+	}
+	if len(stream.sent) != 1 || stream.sent[0].Id != n.ID {
+		t.Fatalf("replayed %v, want %s", stream.sent, n.ID)
+	}
+
+	attempts := stream.sent[0].Attempts
+	if len(attempts) != 2 {
+		t.Fatalf("sent %d attempts, want 2", len(attempts))
+	}
+	retried, delivered := attempts[0], attempts[1]
+	if retried.Attempt != 1 || retried.Channel != "webhook" || retried.Outcome != "retrying" ||
+		retried.StartedAt != 1767323045000 || retried.FinishedAt != 1767323046000 {
+		t.Errorf("first attempt = %v", retried)
+	}
+	if retried.Error != "webhook responded with status 503" || retried.ErrorClass != "http_5xx" || retried.BackoffMs != 1500 {
+		t.Errorf("first attempt failed with %q (%q) and %dms backoff, want the 503 (http_5xx) and 1500ms", retried.Error, retried.ErrorClass, retried.BackoffMs)
+	}
+	if delivered.Attempt != 2 || delivered.Outcome != "delivered" || delivered.Error != "" || delivered.ErrorClass != "" || delivered.BackoffMs != 0 {
+		t.Errorf("second attempt = %v, want a delivery without error, class or backoff", delivered)
+	}
+}
@@ -8,9 +8,9 @@ import (
 
 // User represents a user in the system
 type User struct {
-	ID          string   `json:"id"`
-	Username    string   `json:"username"`
-	FollowerIDs []string `json:"follower_ids"`
+	ID           string   `json:"id"`
+	Username     string   `json:"username"`
+	FollowerIDs  []string `json:"follower_ids"`
 	FollowingIDs []string `json:"following_ids"`
 }
 
@@ -33,23 +33,64 @@ const (
 	StatusRetrying
 )
 
+// IsTerminal reports whether a notification in this status will never be
+// picked up by the queue again
+func (s NotificationStatus) IsTerminal() bool {
+	return s == StatusDelivered || s == StatusFailed
+}
+
+// NotificationType says what a notification is about
+type NotificationType string
+
+// TypeNewPost notifies a follower of a new post
+const TypeNewPost NotificationType = "new_post"
+
 // Notification represents a single notification for a user
 type Notification struct {
-	ID        string            `json:"id"`
-	UserID    string            `json:"user_id"`
-	PostID    string            `json:"post_id"`
-	AuthorID  string            `json:"author_id"`
-	Content   string            `json:"content"`
-	CreatedAt time.Time         `json:"created_at"`
-	Read      bool              `json:"read"`
-	Status    NotificationStatus `json:"status"`
-	Attempts  int               `json:"attempts"`
+	ID            string             `json:"id"`
+	Type          NotificationType   `json:"type"`
+	UserID        string             `json:"user_id"`
+	PostID        string             `json:"post_id"`
+	AuthorID      string             `json:"author_id"`
+	Content       string             `json:"content"`
+	CreatedAt     time.Time          `json:"created_at"`
+	Read          bool               `json:"read"`
+	Status        NotificationStatus `json:"status"`
+	Attempts      int                `json:"attempts"`
+	NextAttemptAt time.Time          `json:"next_attempt_at"` // When a retrying notification is due; zero otherwise
+	DeliveredAt   time.Time          `json:"delivered_at"`    // When delivery succeeded; zero until then
+
+	// DeliveryAttempts is the history of every delivery, one entry per
+	// channel, oldest first. Replays add to it rather than clearing it.
+	DeliveryAttempts []DeliveryAttempt `json:"delivery_attempts"`
+}
+
+// DeliveryOutcome is what became of a notification after a delivery attempt
+type DeliveryOutcome string
+
+const (
+	OutcomeDelivered DeliveryOutcome = "delivered"
+	OutcomeRetrying  DeliveryOutcome = "retrying"
+	OutcomeFailed    DeliveryOutcome = "failed"
+)
+
+// DeliveryAttempt records one delivery of a notification over one channel
+type DeliveryAttempt struct {
+	Attempt    int             `json:"attempt"` // Number of the delivery, counted from 1 again after a replay
+	Channel    string          `json:"channel"` // Empty if the deliverer is not named
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at"`
+	Outcome    DeliveryOutcome `json:"outcome"` // Delivered if the channel succeeded, else what the queue decided
+	Error      string          `json:"error"`
+	ErrorClass string          `json:"error_class"` // Retry policy error class, e.g. http_5xx; empty if the channel succeeded
+	Backoff    time.Duration   `json:"backoff"`     // Wait before the next attempt; zero unless retrying
 }
 
 // NewNotification creates a new notification for a user about a post
 func NewNotification(userID string, post *Post) *Notification {
 	return &Notification{
 		ID:        uuid.New().String(),
+		Type:      TypeNewPost,
 		UserID:    userID,
 		PostID:    post.ID,
 		AuthorID:  post.AuthorID,
@@ -59,4 +100,38 @@ func NewNotification(userID string, post *Post) *Notification {
 		Status:    StatusQueued,
 		Attempts:  0,
 	}
-}
\ No newline at end of file
+}
+
+// DeadLetterReason says why a notification was dead-lettered
+type DeadLetterReason string
+
+const (
+	// DeadLetterRetriesExhausted means every attempt the retry policy allows failed
+	DeadLetterRetriesExhausted DeadLetterReason = "retries_exhausted"
+	// DeadLetterPermanentError means delivery failed with an error that is not retried
+	DeadLetterPermanentError DeadLetterReason = "permanent_error"
+	// DeadLetterDropped means the notification never made it into the queue
+	DeadLetterDropped DeadLetterReason = "dropped"
+)
+
+// DeadLetter records a notification that failed for good, so it can be
+// inspected and replayed. Replays are kept when it is replayed and fails again.
+type DeadLetter struct {
+	NotificationID string             `json:"notification_id"`
+	UserID         string             `json:"user_id"`
+	Reason         DeadLetterReason   `json:"reason"`
+	LastError      string             `json:"last_error"`
+	Attempts       int                `json:"attempts"`
+	AttemptedAt    []time.Time        `json:"attempted_at"` // Start of each attempt since the last replay
+	DeadAt         time.Time          `json:"dead_at"`
+	Replayed       bool               `json:"replayed"` // Set while a replay is in flight or succeeded
+	Replays        []DeadLetterReplay `json:"replays"`
+}
+
+// DeadLetterReplay is the audit entry of one replay of a dead letter
+type DeadLetterReplay struct {
+	At        time.Time        `json:"at"`
+	Reason    DeadLetterReason `json:"reason"`     // Why it was dead before the replay
+	LastError string           `json:"last_error"` // Error before the replay
+	Attempts  int              `json:"attempts"`   // Attempts made before the replay
+}
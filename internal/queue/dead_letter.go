@@ -0,0 +1,209 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/delivery"
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+// ErrDeadLetterReplayed is returned when replaying a dead letter that has
+// already been replayed and not failed since
+var ErrDeadLetterReplayed = errors.New("dead letter already replayed")
+
+// deadLetter records a notification that failed for good in the dead-letter
+// store. The replay history of an earlier dead letter is carried over.
+func (nq *NotificationQueue) deadLetter(notification *models.Notification, reason models.DeadLetterReason, err error) {
+	nq.deadMu.Lock()
+	defer nq.deadMu.Unlock()
+
+	deadLetter := &models.DeadLetter{
+		NotificationID: notification.ID,
+		UserID:         notification.UserID,
+		Reason:         reason,
+		LastError:      err.Error(),
+		Attempts:       notification.Attempts,
+		DeadAt:         time.Now(),
+	}
+	var since time.Time
+	if previous, err := nq.store.GetDeadLetter(notification.ID); err == nil {
+		deadLetter.Replays = previous.Replays
+		if len(previous.Replays) > 0 {
+			since = previous.Replays[len(previous.Replays)-1].At
+		}
+	}
+	deadLetter.AttemptedAt = attemptTimes(notification.DeliveryAttempts, since)
+	if err := nq.store.SaveDeadLetter(deadLetter); err != nil {
+		log.Printf("Failed to dead-letter notification %s: %v", notification.ID, err)
+	}
+}
+
+// attemptTimes returns when each delivery in history that started after
+// since began. A delivery over several channels counts once.
+func attemptTimes(history []models.DeliveryAttempt, since time.Time) []time.Time {
+	var times []time.Time
+	last := 0
+	for _, attempt := range history {
+		if attempt.StartedAt.Before(since) {
+			continue
+		}
+		if attempt.Attempt != last {
+			times = append(times, attempt.StartedAt)
+			last = attempt.Attempt
+		} else if attempt.StartedAt.Before(times[len(times)-1]) {
+			times[len(times)-1] = attempt.StartedAt
+		}
+	}
+	return times
+}
+
+// deadLetterReason returns why a delivery that failed with err is given up on
+func deadLetterReason(err error) models.DeadLetterReason {
+	if !delivery.IsRetryable(err) {
+		return models.DeadLetterPermanentError
+	}
+	return models.DeadLetterRetriesExhausted
+}
+
+// ReplayDeadLetter queues a dead-lettered notification again with its
+// attempts reset. The dead letter is kept, marked replayed, with the replay
+// added to its history; if the notification fails again it is dead-lettered
+// anew. A full queue is waited for whatever the overflow policy. If the
+// notification cannot be queued, it and its dead letter are put back as they
+// were.
+func (nq *NotificationQueue) ReplayDeadLetter(ctx context.Context, notificationID string) error {
+	notification, err := nq.markReplayed(notificationID)
+	if err != nil {
+		return err
+	}
+
+	attempts := notification.Attempts
+	notification.Attempts = 0
+	nq.setStatus(notification, models.StatusQueued)
+
+	if err := nq.push(ctx, notification, OverflowBlock); err != nil {
+		nq.unmarkReplayed(notification, attempts)
+		return err
+	}
+	log.Printf("Replayed dead-lettered notification %s to user %s", notification.ID, notification.UserID)
+	return nil
+}
+
+// markReplayed adds a replay to a dead letter's history and returns the
+// notification to replay
+func (nq *NotificationQueue) markReplayed(notificationID string) (*models.Notification, error) {
+	nq.deadMu.Lock()
+	defer nq.deadMu.Unlock()
+
+	deadLetter, err := nq.store.GetDeadLetter(notificationID)
+	if err != nil {
+		return nil, err
+	}
+	if deadLetter.Replayed {
+		return nil, ErrDeadLetterReplayed
+	}
+	notification, err := nq.store.GetNotification(notificationID)
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetter.Replays = append(deadLetter.Replays, models.DeadLetterReplay{
+		At:        time.Now(),
+		Reason:    deadLetter.Reason,
+		LastError: deadLetter.LastError,
+		Attempts:  deadLetter.Attempts,
+	})
+	deadLetter.Replayed = true
+	if err := nq.store.SaveDeadLetter(deadLetter); err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
+
+// unmarkReplayed undoes markReplayed for a notification that could not be
+// queued, leaving it failed with its previous attempts
+func (nq *NotificationQueue) unmarkReplayed(notification *models.Notification, attempts int) {
+	nq.deadMu.Lock()
+	defer nq.deadMu.Unlock()
+
+	notification.Attempts = attempts
+	nq.setStatus(notification, models.StatusFailed)
+
+	deadLetter, err := nq.store.GetDeadLetter(notification.ID)
+	if err != nil {
+		log.Printf("Failed to restore dead letter of notification %s: %v", notification.ID, err)
+		return
+	}
+	if n := len(deadLetter.Replays); n > 0 {
+		deadLetter.Replays = deadLetter.Replays[:n-1]
+	}
+	deadLetter.Replayed = false
+	if err := nq.store.SaveDeadLetter(deadLetter); err != nil {
+		log.Printf("Failed to restore dead letter of notification %s: %v", notification.ID, err)
+	}
+}
+
+// ReplayDeadLetters replays every dead letter that passes filter and has not
+// been replayed yet, and returns the IDs of the notifications queued again.
+// It stops at the first error, returning what was replayed up to then.
+func (nq *NotificationQueue) ReplayDeadLetters(ctx context.Context, filter store.DeadLetterFilter) ([]string, error) {
+	notReplayed := false
+	filter.Replayed = &notReplayed
+	deadLetters, err := nq.store.ListDeadLetters(filter, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	replayed := make([]string, 0, len(deadLetters))
+	for _, deadLetter := range deadLetters {
+		err := nq.ReplayDeadLetter(ctx, deadLetter.NotificationID)
+		if errors.Is(err, ErrDeadLetterReplayed) || errors.Is(err, store.ErrDeadLetterNotFound) {
+			// Replayed or purged since it was listed
+			continue
+		}
+		if err != nil {
+			return replayed, err
+		}
+		replayed = append(replayed, deadLetter.NotificationID)
+	}
+	return replayed, nil
+}
+
+// PurgeDeadLetters deletes the dead letters of the given notifications, or
+// when there are none, every dead letter that passes filter. It returns the
+// IDs of the notifications whose dead letters were deleted. The notifications
+// themselves stay failed.
+func (nq *NotificationQueue) PurgeDeadLetters(notificationIDs []string, filter store.DeadLetterFilter) ([]string, error) {
+	nq.deadMu.Lock()
+	defer nq.deadMu.Unlock()
+
+	var ids []string
+	if len(notificationIDs) > 0 {
+		for _, id := range notificationIDs {
+			if _, err := nq.store.GetDeadLetter(id); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	} else {
+		deadLetters, err := nq.store.ListDeadLetters(filter, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, deadLetter := range deadLetters {
+			ids = append(ids, deadLetter.NotificationID)
+		}
+	}
+	if len(ids) == 0 {
+		return []string{}, nil
+	}
+
+	if _, err := nq.store.DeleteDeadLetters(ids); err != nil {
+		return nil, err
+	}
+	log.Printf("Purged %d dead letters", len(ids))
+	return ids, nil
+}
@@ -0,0 +1,193 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/delivery"
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+// saveDeadLetters stores count failed notifications for user, each with a
+// dead letter after three attempts
+func saveDeadLetters(t *testing.T, st store.Store, user string, reason models.DeadLetterReason, count int) []*models.Notification {
+	t.Helper()
+	post, err := st.GetPost("post1")
+	if err != nil {
+		t.Fatalf("GetPost: %v", err)
+	}
+	notifications := make([]*models.Notification, count)
+	for i := range notifications {
+		n := models.NewNotification(user, post)
+		if err := st.SaveNotification(n); err != nil {
+			t.Fatalf("SaveNotification: %v", err)
+		}
+		n.Status = models.StatusFailed
+		n.Attempts = 3
+		if err := st.UpdateNotification(n); err != nil {
+			t.Fatalf("UpdateNotification: %v", err)
+		}
+		if err := st.SaveDeadLetter(&models.DeadLetter{
+			NotificationID: n.ID,
+			UserID:         n.UserID,
+			Reason:         reason,
+			LastError:      "boom",
+			Attempts:       3,
+			DeadAt:         time.Now(),
+		}); err != nil {
+			t.Fatalf("SaveDeadLetter: %v", err)
+		}
+		notifications[i] = n
+	}
+	return notifications
+}
+
+func getDeadLetter(t *testing.T, st store.Store, id string) *models.DeadLetter {
+	t.Helper()
+	deadLetter, err := st.GetDeadLetter(id)
+	if err != nil {
+		t.Fatalf("GetDeadLetter: %v", err)
+	}
+	return deadLetter
+}
+
+func TestReplayDeadLetter(t *testing.T) {
+	for name, open := range testStores {
+		t.Run(name, func(t *testing.T) {
+			st := open(t)
+			n := saveDeadLetters(t, st, "user2", models.DeadLetterRetriesExhausted, 1)[0]
+			nq := NewNotificationQueue(st, delivery.NewSimulatedDeliverer(0, 0, 0), 1)
+			nq.Start()
+			defer nq.Stop()
+
+			if err := nq.ReplayDeadLetter(context.Background(), n.ID); err != nil {
+				t.Fatalf("ReplayDeadLetter: %v", err)
+			}
+			waitForStatus(t, st, []*models.Notification{n}, models.StatusDelivered)
+
+			deadLetter := getDeadLetter(t, st, n.ID)
+			if !deadLetter.Replayed || len(deadLetter.Replays) != 1 {
+				t.Fatalf("dead letter replayed %v with %d replays, want one replay", deadLetter.Replayed, len(deadLetter.Replays))
+			}
+			if replay := deadLetter.Replays[0]; replay.Attempts != 3 || replay.LastError != "boom" || replay.Reason != models.DeadLetterRetriesExhausted {
+				t.Errorf("replay = %+v, want the state before the replay", replay)
+			}
+			if err := nq.ReplayDeadLetter(context.Background(), n.ID); !errors.Is(err, ErrDeadLetterReplayed) {
+				t.Errorf("second ReplayDeadLetter = %v, want ErrDeadLetterReplayed", err)
+			}
+			if err := nq.ReplayDeadLetter(context.Background(), "missing"); !errors.Is(err, store.ErrDeadLetterNotFound) {
+				t.Errorf("ReplayDeadLetter of a missing dead letter = %v, want ErrDeadLetterNotFound", err)
+			}
+		})
+	}
+}
+
+func TestReplayDeadLetterLeavesItDeadWhenNotQueued(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for name, open := range testStores {
+		for _, tt := range []struct {
+			name  string
+			ctx   context.Context
+			setup func(t *testing.T, nq *NotificationQueue, st store.Store)
+			want  error
+		}{
+			{
+				name: "cancelled on a full queue",
+				ctx:  cancelled,
+				setup: func(t *testing.T, nq *NotificationQueue, st store.Store) {
+					// Never started, so the buffer stays full
+					if _, err := nq.QueueNotifications(context.Background(), saveNotifications(t, st, cap(nq.queue))); err != nil {
+						t.Fatalf("QueueNotifications: %v", err)
+					}
+				},
+				want: context.Canceled,
+			},
+			{
+				name:  "stopped",
+				ctx:   context.Background(),
+				setup: func(t *testing.T, nq *NotificationQueue, st store.Store) { nq.Stop() },
+				want:  ErrQueueStopped,
+			},
+		} {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				st := open(t)
+				n := saveDeadLetters(t, st, "user2", models.DeadLetterRetriesExhausted, 1)[0]
+				nq := NewNotificationQueue(st, delivery.NewSimulatedDeliverer(0, 0, 0), 1)
+				tt.setup(t, nq, st)
+
+				if err := nq.ReplayDeadLetter(tt.ctx, n.ID); !errors.Is(err, tt.want) {
+					t.Fatalf("ReplayDeadLetter = %v, want %v", err, tt.want)
+				}
+
+				deadLetter := getDeadLetter(t, st, n.ID)
+				if deadLetter.Replayed || len(deadLetter.Replays) != 0 || deadLetter.Reason != models.DeadLetterRetriesExhausted || deadLetter.Attempts != 3 {
+					t.Errorf("dead letter = %+v, want it as it was before the replay", deadLetter)
+				}
+				stored, err := st.GetNotification(n.ID)
+				if err != nil {
+					t.Fatalf("GetNotification: %v", err)
+				}
+				if stored.Status != models.StatusFailed || stored.Attempts != 3 {
+					t.Errorf("notification is %v after %d attempts, want FAILED after 3", stored.Status, stored.Attempts)
+				}
+			})
+		}
+	}
+}
+
+func TestPurgeDeadLetters(t *testing.T) {
+	for name, open := range testStores {
+		t.Run(name, func(t *testing.T) {
+			st := open(t)
+			exhausted := saveDeadLetters(t, st, "user2", models.DeadLetterRetriesExhausted, 2)
+			permanent := saveDeadLetters(t, st, "user3", models.DeadLetterPermanentError, 2)
+			nq := NewNotificationQueue(st, delivery.NewSimulatedDeliverer(0, 0, 0), 1)
+
+			// By ID, skipping IDs without a dead letter
+			purged, err := nq.PurgeDeadLetters([]string{exhausted[0].ID, "missing"}, store.DeadLetterFilter{})
+			if err != nil {
+				t.Fatalf("PurgeDeadLetters: %v", err)
+			}
+			if len(purged) != 1 || purged[0] != exhausted[0].ID {
+				t.Errorf("purged %v, want only %s", purged, exhausted[0].ID)
+			}
+
+			// By filter
+			purged, err = nq.PurgeDeadLetters(nil, store.DeadLetterFilter{Reason: models.DeadLetterPermanentError})
+			if err != nil {
+				t.Fatalf("PurgeDeadLetters: %v", err)
+			}
+			if len(purged) != 2 {
+				t.Errorf("purged %v, want the two permanent errors", purged)
+			}
+
+			left, err := st.ListDeadLetters(store.DeadLetterFilter{}, 0)
+			if err != nil {
+				t.Fatalf("ListDeadLetters: %v", err)
+			}
+			if len(left) != 1 || left[0].NotificationID != exhausted[1].ID {
+				t.Errorf("%d dead letters left, want only %s", len(left), exhausted[1].ID)
+			}
+			// The notifications stay failed
+			for _, n := range append(exhausted, permanent...) {
+				stored, err := st.GetNotification(n.ID)
+				if err != nil {
+					t.Fatalf("GetNotification: %v", err)
+				}
+				if stored.Status != models.StatusFailed {
+					t.Errorf("notification %s is %v after purging, want FAILED", n.ID, stored.Status)
+				}
+			}
+
+			purged, err = nq.PurgeDeadLetters(nil, store.DeadLetterFilter{Reason: models.DeadLetterPermanentError})
+			if err != nil || len(purged) != 0 {
+				t.Errorf("purging again = %v, %v, want nothing purged", purged, err)
+			}
+		})
+	}
+}
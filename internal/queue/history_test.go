@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/delivery"
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+// fastRetries retries up to four times with a fixed 5ms backoff
+var fastRetries = &delivery.RetryPolicies{Default: delivery.RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   5 * time.Millisecond,
+	MaxDelay:    5 * time.Millisecond,
+	Jitter:      delivery.JitterNone,
+}}
+
+// failFirst fails its first n deliveries with err
+func failFirst(n int32, err error) funcDeliverer {
+	var calls atomic.Int32
+	return func(context.Context, *models.Notification) error {
+		if calls.Add(1) <= n {
+			return err
+		}
+		return nil
+	}
+}
+
+// deliverAndLoad delivers a pending notification with deliverer and returns
+// it as stored afterwards
+func deliverAndLoad(t *testing.T, st store.Store, deliverer delivery.Deliverer) *models.Notification {
+	t.Helper()
+	// Saved before the start so that only the pending recovery queues it
+	n := saveNotifications(t, st, 1)[0]
+	nq := NewNotificationQueue(st, deliverer, 1)
+	nq.SetRetryPolicies(fastRetries)
+	nq.Start()
+	defer nq.Stop()
+
+	waitForStatus(t, st, []*models.Notification{n}, models.StatusDelivered)
+	stored, err := st.GetNotification(n.ID)
+	if err != nil {
+		t.Fatalf("GetNotification: %v", err)
+	}
+	return stored
+}
+
+func TestDeliveryHistoryRecordsEachAttempt(t *testing.T) {
+	for name, open := range testStores {
+		t.Run(name, func(t *testing.T) {
+			st := open(t)
+			deliverer := delivery.NewMultiDeliverer(
+				delivery.WithChannel("webhook", failFirst(2, &delivery.HTTPStatusError{URL: "http://example.com", StatusCode: 503})),
+				delivery.WithChannel("email", failFirst(1, context.DeadlineExceeded)),
+			)
+			n := deliverAndLoad(t, st, deliverer)
+
+			want := []struct {
+				attempt int
+				channel string
+				outcome models.DeliveryOutcome
+				class   string
+			}{
+				{1, "webhook", models.OutcomeRetrying, "http_5xx"},
+				{1, "email", models.OutcomeRetrying, "timeout"},
+				{2, "webhook", models.OutcomeRetrying, "http_5xx"},
+				{2, "email", models.OutcomeDelivered, ""},
+				// The email got through, so only the webhook is retried
+				{3, "webhook", models.OutcomeDelivered, ""},
+			}
+			if n.Attempts != 2 || len(n.DeliveryAttempts) != len(want) {
+				t.Fatalf("%d failed attempts with %d history entries, want 2 and %d: %+v", n.Attempts, len(n.DeliveryAttempts), len(want), n.DeliveryAttempts)
+			}
+			for i, w := range want {
+				got := n.DeliveryAttempts[i]
+				if got.Attempt != w.attempt || got.Channel != w.channel || got.Outcome != w.outcome || got.ErrorClass != w.class {
+					t.Errorf("entry %d = attempt %d on %s %s (%s), want attempt %d on %s %s (%s)",
+						i, got.Attempt, got.Channel, got.Outcome, got.ErrorClass, w.attempt, w.channel, w.outcome, w.class)
+				}
+				if (got.Error != "") != (w.class != "") {
+					t.Errorf("entry %d error %q, want one only for a failure", i, got.Error)
+				}
+				wantBackoff := time.Duration(0)
+				if w.outcome == models.OutcomeRetrying {
+					wantBackoff = 5 * time.Millisecond
+				}
+				if got.Backoff != wantBackoff {
+					t.Errorf("entry %d backoff %v, want %v", i, got.Backoff, wantBackoff)
+				}
+				if got.StartedAt.IsZero() || got.FinishedAt.Before(got.StartedAt) {
+					t.Errorf("entry %d ran from %v to %v", i, got.StartedAt, got.FinishedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestDeliveryHistoryOfUnnamedDeliverer(t *testing.T) {
+	for name, open := range testStores {
+		t.Run(name, func(t *testing.T) {
+			n := deliverAndLoad(t, open(t), failFirst(1, errors.New("connection reset")))
+
+			if len(n.DeliveryAttempts) != 2 {
+				t.Fatalf("%d history entries, want one per attempt: %+v", len(n.DeliveryAttempts), n.DeliveryAttempts)
+			}
+			first, second := n.DeliveryAttempts[0], n.DeliveryAttempts[1]
+			if first.Attempt != 1 || first.Channel != "" || first.Outcome != models.OutcomeRetrying || first.ErrorClass != "other" || first.Error != "connection reset" {
+				t.Errorf("first entry = %+v, want a retried failure of class other", first)
+			}
+			if second.Attempt != 2 || second.Outcome != models.OutcomeDelivered || second.ErrorClass != "" {
+				t.Errorf("second entry = %+v, want the delivery", second)
+			}
+		})
+	}
+}
@@ -2,33 +2,96 @@ package queue
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"math"
-	"math/rand"
 	"sync"
 	"time"
 
+	"github.com/suyashXD/DNDS/internal/delivery"
+	"github.com/suyashXD/DNDS/internal/events"
 	"github.com/suyashXD/DNDS/internal/models"
 	"github.com/suyashXD/DNDS/internal/store"
 )
 
 const (
-	maxRetries       = 3
-	initialBackoff   = 100 * time.Millisecond
-	failureRate      = 0.1 // 10% failure rate
-	maxWorkers       = 10  // Maximum number of concurrent workers
+	maxWorkers        = 10    // Maximum number of concurrent workers
+	defaultSpillLimit = 10000 // Notifications OverflowSpill parks before rejecting
 )
 
+var (
+	// ErrQueueFull is returned when notifications are rejected by
+	// OverflowReject, or by OverflowSpill once the overflow list is full
+	ErrQueueFull = errors.New("notification queue is full")
+	// ErrQueueStopped is returned when notifications are queued after Stop
+	// or Shutdown
+	ErrQueueStopped = errors.New("notification queue stopped")
+)
+
+// OverflowPolicy decides what happens to notifications that do not fit in
+// the queue
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for space until the caller's context is done
+	OverflowBlock OverflowPolicy = iota
+	// OverflowReject fails notifications that do not fit with ErrQueueFull
+	OverflowReject
+	// OverflowSpill parks notifications that do not fit in an overflow list
+	// that is drained into the queue as space frees up. Once the list reaches
+	// its limit, notifications are rejected as with OverflowReject.
+	OverflowSpill
+)
+
+// ParseOverflowPolicy parses the name of an overflow policy
+func ParseOverflowPolicy(name string) (OverflowPolicy, error) {
+	switch name {
+	case "block":
+		return OverflowBlock, nil
+	case "reject":
+		return OverflowReject, nil
+	case "spill":
+		return OverflowSpill, nil
+	default:
+		return 0, fmt.Errorf("unknown overflow policy %q", name)
+	}
+}
+
+// String returns the name ParseOverflowPolicy accepts
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowReject:
+		return "reject"
+	case OverflowSpill:
+		return "spill"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+}
+
 // NotificationQueue handles the queuing and processing of notifications
 type NotificationQueue struct {
-	store        *store.MemoryStore
-	queue        chan *models.Notification
-	wg           sync.WaitGroup
-	workerCount  int
-	metrics      *Metrics
-	ctx          context.Context
-	cancel       context.CancelFunc
+	store         store.Store
+	deliverer     delivery.Deliverer
+	queue         chan *models.Notification
+	wg            sync.WaitGroup
+	workerCount   int
+	metrics       *Metrics
+	ctx           context.Context
+	cancel        context.CancelFunc
+	stopping      chan struct{}
+	stopOnce      sync.Once
+	events        *events.Bus
+	overflow      OverflowPolicy
+	spillMu       sync.Mutex
+	spilled       []*models.Notification
+	spillLimit    int
+	spillReady    chan struct{}
+	retries       *retryScheduler
+	retryPolicies *delivery.RetryPolicies
+	deadMu        sync.Mutex // serializes dead-lettering with replays and purges
 }
 
 // Metrics tracks statistics about notification deliveries
@@ -36,87 +99,349 @@ type Metrics struct {
 	TotalSent      int64
 	FailedAttempts int64
 	TotalRetries   int64
+	Dropped        int64
 	mu             sync.RWMutex
 	deliveryTimes  []time.Duration
 }
 
-// NewNotificationQueue creates a new notification queue with the specified store
-func NewNotificationQueue(store *store.MemoryStore, workerCount int) *NotificationQueue {
+// NewNotificationQueue creates a new notification queue that delivers through deliverer
+func NewNotificationQueue(store store.Store, deliverer delivery.Deliverer, workerCount int) *NotificationQueue {
 	if workerCount <= 0 {
 		workerCount = maxWorkers
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
-	
+
 	return &NotificationQueue{
 		store:       store,
+		deliverer:   deliverer,
 		queue:       make(chan *models.Notification, 1000), // Buffer size of 1000
 		workerCount: workerCount,
 		metrics: &Metrics{
 			deliveryTimes: make([]time.Duration, 0),
 		},
-		ctx:    ctx,
-		cancel: cancel,
+		ctx:           ctx,
+		cancel:        cancel,
+		stopping:      make(chan struct{}),
+		events:        events.NewBus(),
+		spillReady:    make(chan struct{}, 1),
+		spillLimit:    defaultSpillLimit,
+		retries:       newRetryScheduler(),
+		retryPolicies: delivery.DefaultRetryPolicies(),
 	}
 }
 
-// Start begins processing notifications with the worker pool
+// SetRetryPolicies changes how failed deliveries are retried. The default is
+// delivery.DefaultRetryPolicies.
+func (nq *NotificationQueue) SetRetryPolicies(policies *delivery.RetryPolicies) {
+	nq.retryPolicies = policies
+}
+
+// SetOverflowPolicy changes how notifications that do not fit in the queue
+// are handled. The default is OverflowBlock.
+func (nq *NotificationQueue) SetOverflowPolicy(policy OverflowPolicy) {
+	nq.overflow = policy
+}
+
+// SetSpillLimit changes how many notifications OverflowSpill parks before it
+// rejects the rest with ErrQueueFull. The default is 10000.
+func (nq *NotificationQueue) SetSpillLimit(limit int) {
+	nq.spillMu.Lock()
+	defer nq.spillMu.Unlock()
+	nq.spillLimit = limit
+}
+
+// Events returns the bus the queue publishes notification additions and
+// status transitions to
+func (nq *NotificationQueue) Events() *events.Bus {
+	return nq.events
+}
+
+// Start begins processing notifications with the worker pool and resumes any
+// notifications the store still holds in a non-terminal state. The pending
+// set is loaded before Start returns, so notifications queued afterwards are
+// not recovered and delivered a second time.
 func (nq *NotificationQueue) Start() {
+	pending, err := nq.store.GetPendingNotifications()
+	if err != nil {
+		log.Printf("Failed to load pending notifications: %v", err)
+	}
+
 	for i := 0; i < nq.workerCount; i++ {
 		nq.wg.Add(1)
 		go nq.worker(i)
 	}
 	log.Printf("Started notification queue with %d workers", nq.workerCount)
+
+	nq.wg.Add(1)
+	go nq.recoverPending(pending)
+
+	nq.wg.Add(1)
+	go nq.drainSpilled()
+
+	nq.wg.Add(1)
+	go func() {
+		defer nq.wg.Done()
+		nq.retries.run(nq.stopping, nq.requeue)
+	}()
+}
+
+// recoverPending re-enqueues notifications left queued or retrying by a
+// previous run. It blocks on a full queue rather than dropping them. Retries
+// that are not due yet go back to the retry scheduler.
+func (nq *NotificationQueue) recoverPending(pending []*models.Notification) {
+	defer nq.wg.Done()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	now := time.Now()
+	for _, notification := range pending {
+		if notification.Status == models.StatusRetrying && notification.NextAttemptAt.After(now) {
+			nq.retries.schedule(notification, notification.NextAttemptAt)
+			continue
+		}
+		select {
+		case nq.queue <- notification:
+		case <-nq.stopping:
+			return
+		}
+	}
+	log.Printf("Recovered %d pending notifications", len(pending))
 }
 
-// Stop gracefully shuts down the queue
+// Stop shuts down the queue without draining it. Notifications still
+// buffered or waiting for a retry stay pending in the store and are recovered
+// on the next start.
 func (nq *NotificationQueue) Stop() {
+	nq.stopIntake()
 	nq.cancel()
 	nq.wg.Wait()
-	close(nq.queue)
 	log.Println("Notification queue stopped")
 }
 
-// QueueNotification adds a notification to the processing queue
-func (nq *NotificationQueue) QueueNotification(notification *models.Notification) {
+// Shutdown stops accepting notifications and lets the workers deliver what is
+// already buffered. If ctx is done first, in-flight deliveries are cancelled
+// and the rest stays pending in the store for the next start, as with Stop.
+// Scheduled retries are not waited for; they keep their due time in the
+// store.
+func (nq *NotificationQueue) Shutdown(ctx context.Context) error {
+	nq.stopIntake()
+
+	done := make(chan struct{})
+	go func() {
+		nq.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		log.Printf("Notification queue drain interrupted, %d notifications left for the next start", len(nq.queue))
+	}
+
+	nq.cancel()
+	<-done
+	log.Println("Notification queue stopped")
+	return err
+}
+
+// stopIntake makes every later attempt to queue a notification fail with
+// ErrQueueStopped and wakes callers blocked on a full queue. The channel is
+// never closed, so a racing send cannot panic.
+func (nq *NotificationQueue) stopIntake() {
+	nq.stopOnce.Do(func() {
+		close(nq.stopping)
+	})
+}
+
+// QueueNotification adds a new notification to the processing queue,
+// handling a full queue according to the overflow policy
+func (nq *NotificationQueue) QueueNotification(ctx context.Context, notification *models.Notification) error {
+	_, err := nq.QueueNotifications(ctx, []*models.Notification{notification})
+	return err
+}
+
+// QueueNotifications adds multiple notifications to the queue, handling a full
+// queue according to the overflow policy. It returns how many were queued;
+// the rest are marked failed and the error says why.
+func (nq *NotificationQueue) QueueNotifications(ctx context.Context, notifications []*models.Notification) (int, error) {
+	return nq.queueNotifications(ctx, notifications, nq.overflow)
+}
+
+// QueueNotificationsWait is QueueNotifications with OverflowBlock, whatever
+// the configured policy
+func (nq *NotificationQueue) QueueNotificationsWait(ctx context.Context, notifications []*models.Notification) (int, error) {
+	return nq.queueNotifications(ctx, notifications, OverflowBlock)
+}
+
+func (nq *NotificationQueue) queueNotifications(ctx context.Context, notifications []*models.Notification, policy OverflowPolicy) (int, error) {
+	for i, notification := range notifications {
+		nq.events.Publish(events.NotificationAdded, notification, models.StatusUnknown)
+		if err := nq.push(ctx, notification, policy); err != nil {
+			nq.drop(notifications[i:], err)
+			return i, err
+		}
+	}
+	return len(notifications), nil
+}
+
+// push hands a notification to the workers, handling a full queue according
+// to policy
+func (nq *NotificationQueue) push(ctx context.Context, notification *models.Notification, policy OverflowPolicy) error {
+	select {
+	case <-nq.stopping:
+		return ErrQueueStopped
+	default:
+	}
+
+	// Once anything is spilled, later notifications queue up behind it
+	if policy == OverflowSpill {
+		if spilled, err := nq.spill(notification, false); spilled || err != nil {
+			return err
+		}
+	}
+
 	select {
 	case nq.queue <- notification:
-		// Successfully queued
+		return nil
 	default:
-		// Queue is full, handle gracefully
-		log.Printf("Queue is full, notification %s dropped", notification.ID)
+	}
+
+	switch policy {
+	case OverflowReject:
+		return ErrQueueFull
+	case OverflowSpill:
+		_, err := nq.spill(notification, true)
+		return err
+	}
+
+	select {
+	case nq.queue <- notification:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-nq.stopping:
+		return ErrQueueStopped
 	}
 }
 
-// QueueNotifications adds multiple notifications to the queue
-func (nq *NotificationQueue) QueueNotifications(notifications []*models.Notification) int {
-	queued := 0
-	for _, notification := range notifications {
+// spill appends a notification to the overflow list. Unless force is set it
+// only does so when the list is not empty. A full list fails with
+// ErrQueueFull.
+func (nq *NotificationQueue) spill(notification *models.Notification, force bool) (bool, error) {
+	nq.spillMu.Lock()
+	defer nq.spillMu.Unlock()
+
+	if !force && len(nq.spilled) == 0 {
+		return false, nil
+	}
+	if len(nq.spilled) >= nq.spillLimit {
+		return false, ErrQueueFull
+	}
+	nq.spilled = append(nq.spilled, notification)
+
+	select {
+	case nq.spillReady <- struct{}{}:
+	default:
+	}
+	return true, nil
+}
+
+// drainSpilled moves spilled notifications into the queue as space frees up.
+// Notifications still spilled at shutdown stay queued in the store and are
+// recovered on the next start.
+func (nq *NotificationQueue) drainSpilled() {
+	defer nq.wg.Done()
+
+	for {
 		select {
-		case nq.queue <- notification:
-			queued++
-		default:
-			// Queue is full
-			log.Printf("Queue is full, notification %s dropped", notification.ID)
+		case <-nq.spillReady:
+		case <-nq.stopping:
+			return
+		}
+
+		for {
+			nq.spillMu.Lock()
+			if len(nq.spilled) == 0 {
+				nq.spillMu.Unlock()
+				break
+			}
+			notification := nq.spilled[0]
+			nq.spillMu.Unlock()
+
+			select {
+			case nq.queue <- notification:
+			case <-nq.stopping:
+				return
+			}
+
+			nq.spillMu.Lock()
+			nq.spilled[0] = nil
+			nq.spilled = nq.spilled[1:]
+			nq.spillMu.Unlock()
+		}
+	}
+}
+
+// drop marks notifications that could not be queued as failed, so they are
+// not left looking queued in the store, and dead-letters them unless the
+// caller gave up waiting for space. Once the queue is stopping they are left
+// queued instead, for the next start to recover.
+func (nq *NotificationQueue) drop(notifications []*models.Notification, err error) {
+	if errors.Is(err, ErrQueueStopped) {
+		log.Printf("Queue stopping, %d notifications left for the next start", len(notifications))
+		return
+	}
+	log.Printf("Dropped %d notifications: %v", len(notifications), err)
+
+	nq.metrics.mu.Lock()
+	nq.metrics.Dropped += int64(len(notifications))
+	nq.metrics.mu.Unlock()
+
+	cancelled := errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+	for _, notification := range notifications {
+		nq.setStatus(notification, models.StatusFailed)
+		if !cancelled {
+			nq.deadLetter(notification, models.DeadLetterDropped, err)
 		}
 	}
-	return queued
 }
 
-// worker processes notifications from the queue
+// requeue hands a notification back to the workers for a retry, waiting for
+// space rather than dropping it. Once the queue is stopping the notification
+// is left retrying in the store instead.
+func (nq *NotificationQueue) requeue(notification *models.Notification) {
+	select {
+	case <-nq.stopping:
+		return
+	default:
+	}
+
+	select {
+	case nq.queue <- notification:
+	case <-nq.stopping:
+	}
+}
+
+// worker processes notifications from the queue. Once intake stops it
+// drains the buffer and exits; cancellation makes it exit at once.
 func (nq *NotificationQueue) worker(id int) {
 	defer nq.wg.Done()
-	
+
 	log.Printf("Worker %d started", id)
-	
+
 	for {
 		select {
-		case notification, ok := <-nq.queue:
-			if !ok {
-				log.Printf("Worker %d shutting down", id)
-				return
-			}
+		case notification := <-nq.queue:
 			nq.processNotification(notification)
+		case <-nq.stopping:
+			nq.drain()
+			log.Printf("Worker %d shutting down", id)
+			return
 		case <-nq.ctx.Done():
 			log.Printf("Worker %d received shutdown signal", id)
 			return
@@ -124,82 +449,134 @@ func (nq *NotificationQueue) worker(id int) {
 	}
 }
 
+// drain processes buffered notifications until the buffer is empty or the
+// queue is cancelled
+func (nq *NotificationQueue) drain() {
+	for nq.ctx.Err() == nil {
+		select {
+		case notification := <-nq.queue:
+			nq.processNotification(notification)
+		default:
+			return
+		}
+	}
+}
+
 // processNotification handles the delivery of a notification with retry logic
 func (nq *NotificationQueue) processNotification(notification *models.Notification) {
 	startTime := time.Now()
-	
-	// Simulate processing delay (10-50ms)
-	time.Sleep(time.Duration(10+rand.Intn(40)) * time.Millisecond)
-	
-	// Simulate random failures (10% chance)
-	if rand.Float64() < failureRate {
+	attempt := notification.Attempts + 1
+	recorder := &delivery.AttemptRecorder{}
+
+	if err := nq.deliverer.Deliver(delivery.WithAttemptRecorder(nq.ctx, recorder), notification); err != nil {
+		if nq.ctx.Err() != nil {
+			// Cut off by shutdown; the notification stays pending in the
+			// store without counting the attempt
+			return
+		}
+
 		nq.metrics.mu.Lock()
 		nq.metrics.FailedAttempts++
 		nq.metrics.mu.Unlock()
-		
+
 		notification.Attempts++
-		
-		if notification.Attempts <= maxRetries {
-			// Calculate exponential backoff
-			backoff := time.Duration(math.Pow(2, float64(notification.Attempts-1))) * initialBackoff
-			
-			log.Printf("Notification %s to user %s failed (attempt %d/%d), retrying in %v",
-				notification.ID, notification.UserID, notification.Attempts, maxRetries, backoff)
-			
-			notification.Status = models.StatusRetrying
-			err := nq.store.UpdateNotification(notification)
-			if err != nil {
-				log.Printf("Failed to update notification status: %v", err)
-			}
-			
-			nq.metrics.mu.Lock()
-			nq.metrics.TotalRetries++
-			nq.metrics.mu.Unlock()
-			
-			// Schedule retry after backoff
-			go func(n *models.Notification, d time.Duration) {
-				time.Sleep(d)
-				nq.QueueNotification(n)
-			}(notification, backoff)
-			
-			return
-		} else {
-			// Max retries reached
-			log.Printf("Notification %s to user %s failed permanently after %d attempts", 
-				notification.ID, notification.UserID, notification.Attempts)
-			
-			notification.Status = models.StatusFailed
-			err := nq.store.UpdateNotification(notification)
-			if err != nil {
-				log.Printf("Failed to update notification status: %v", err)
-			}
-			
+
+		policy := nq.retryPolicies.For(notification, err)
+		if !policy.ShouldRetry(err, notification.Attempts) {
+			log.Printf("Notification %s to user %s failed permanently after %d attempts: %v",
+				notification.ID, notification.UserID, notification.Attempts, err)
+
+			recordAttempts(notification, attempt, recorder.Attempts(), startTime, err, models.OutcomeFailed, 0)
+			nq.setStatus(notification, models.StatusFailed)
+			nq.deadLetter(notification, deadLetterReason(err), err)
+
 			return
 		}
+
+		backoff := policy.Backoff(notification.Attempts)
+
+		log.Printf("Notification %s to user %s failed (attempt %d/%d): %v, retrying in %v",
+			notification.ID, notification.UserID, notification.Attempts, policy.MaxAttempts, err, backoff)
+
+		recordAttempts(notification, attempt, recorder.Attempts(), startTime, err, models.OutcomeRetrying, backoff)
+		notification.NextAttemptAt = time.Now().Add(backoff)
+		nq.setStatus(notification, models.StatusRetrying)
+
+		nq.metrics.mu.Lock()
+		nq.metrics.TotalRetries++
+		nq.metrics.mu.Unlock()
+
+		// Hand the retry to the scheduler
+		nq.retries.schedule(notification, notification.NextAttemptAt)
+
+		return
 	}
-	
+
 	// Successful delivery
-	notification.Status = models.StatusDelivered
-	err := nq.store.UpdateNotification(notification)
-	if err != nil {
-		log.Printf("Failed to update notification status: %v", err)
-	}
-	
+	recordAttempts(notification, attempt, recorder.Attempts(), startTime, nil, models.OutcomeDelivered, 0)
+	notification.DeliveredAt = time.Now()
+	nq.setStatus(notification, models.StatusDelivered)
+
 	// Record metrics
 	deliveryTime := time.Since(startTime)
 	nq.metrics.mu.Lock()
 	nq.metrics.TotalSent++
 	nq.metrics.deliveryTimes = append(nq.metrics.deliveryTimes, deliveryTime)
 	nq.metrics.mu.Unlock()
-	
+
 	fmt.Printf("Notification sent to User%s for Post%s\n", notification.UserID, notification.PostID)
 }
 
+// recordAttempts adds the channel attempts of one delivery to the
+// notification's history. Channels that failed get outcome and backoff, the
+// others are delivered. A deliverer without channel names records nothing,
+// so the delivery as a whole is recorded instead.
+func recordAttempts(notification *models.Notification, attempt int, attempts []models.DeliveryAttempt, startTime time.Time, err error, outcome models.DeliveryOutcome, backoff time.Duration) {
+	if len(attempts) == 0 {
+		whole := models.DeliveryAttempt{StartedAt: startTime, FinishedAt: time.Now()}
+		if err != nil {
+			whole.Error = err.Error()
+			whole.ErrorClass = string(delivery.Classify(err))
+		}
+		attempts = []models.DeliveryAttempt{whole}
+	}
+	for i := range attempts {
+		attempts[i].Attempt = attempt
+		if attempts[i].Error == "" {
+			attempts[i].Outcome = models.OutcomeDelivered
+			continue
+		}
+		attempts[i].Outcome = outcome
+		if outcome == models.OutcomeRetrying {
+			attempts[i].Backoff = backoff
+		}
+	}
+	notification.DeliveryAttempts = append(notification.DeliveryAttempts, attempts...)
+}
+
+// setStatus persists a status transition and announces it on the event bus.
+// Attempts and the delivery history are persisted along with it, so it also
+// records a failed attempt that leaves the status unchanged.
+func (nq *NotificationQueue) setStatus(notification *models.Notification, status models.NotificationStatus) {
+	previous := notification.Status
+	notification.Status = status
+	if status != models.StatusRetrying {
+		notification.NextAttemptAt = time.Time{}
+	}
+	if err := nq.store.UpdateNotification(notification); err != nil {
+		log.Printf("Failed to update notification status: %v", err)
+	}
+
+	if previous != status {
+		nq.events.Publish(events.NotificationStatusChanged, notification, previous)
+	}
+}
+
 // GetMetrics returns the current metrics
 func (nq *NotificationQueue) GetMetrics() map[string]interface{} {
 	nq.metrics.mu.RLock()
 	defer nq.metrics.mu.RUnlock()
-	
+
 	var avgDeliveryTime time.Duration
 	if len(nq.metrics.deliveryTimes) > 0 {
 		var sum time.Duration
@@ -208,13 +585,29 @@ func (nq *NotificationQueue) GetMetrics() map[string]interface{} {
 		}
 		avgDeliveryTime = sum / time.Duration(len(nq.metrics.deliveryTimes))
 	}
-	
+
+	var nextRetryAt string
+	if due, ok := nq.retries.nextDue(); ok {
+		nextRetryAt = due.Format(time.RFC3339Nano)
+	}
+
 	return map[string]interface{}{
 		"total_sent":        nq.metrics.TotalSent,
 		"failed_attempts":   nq.metrics.FailedAttempts,
 		"total_retries":     nq.metrics.TotalRetries,
 		"avg_delivery_time": avgDeliveryTime.String(),
+		"dropped":           nq.metrics.Dropped,
 		"queue_size":        len(nq.queue),
+		"spilled":           nq.spilledCount(),
+		"pending_retries":   nq.retries.pending(),
+		"next_retry_at":     nextRetryAt,
 		"worker_count":      nq.workerCount,
 	}
-}
\ No newline at end of file
+}
+
+// spilledCount returns how many notifications wait in the overflow list
+func (nq *NotificationQueue) spilledCount() int {
+	nq.spillMu.Lock()
+	defer nq.spillMu.Unlock()
+	return len(nq.spilled)
+}
@@ -0,0 +1,344 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/delivery"
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+// testStores opens each backend the tests that depend on persistence run
+// against
+var testStores = map[string]func(t *testing.T) store.Store{
+	"memory": func(t *testing.T) store.Store { return store.NewMemoryStore(true) },
+	"sqlite": func(t *testing.T) store.Store {
+		s, err := store.OpenSQLite(filepath.Join(t.TempDir(), "dnds.sqlite"), true)
+		if err != nil {
+			t.Fatalf("OpenSQLite: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	},
+}
+
+// saveNotifications stores count notifications for user2 on post1
+func saveNotifications(t *testing.T, st store.Store, count int) []*models.Notification {
+	t.Helper()
+	post, err := st.GetPost("post1")
+	if err != nil {
+		t.Fatalf("GetPost: %v", err)
+	}
+	notifications := make([]*models.Notification, count)
+	for i := range notifications {
+		notifications[i] = models.NewNotification("user2", post)
+		if err := st.SaveNotification(notifications[i]); err != nil {
+			t.Fatalf("SaveNotification: %v", err)
+		}
+	}
+	return notifications
+}
+
+// funcDeliverer delivers with a function
+type funcDeliverer func(ctx context.Context, notification *models.Notification) error
+
+func (f funcDeliverer) Deliver(ctx context.Context, notification *models.Notification) error {
+	return f(ctx, notification)
+}
+
+// waitForStatus waits until every notification has status in the store
+func waitForStatus(t *testing.T, st store.Store, notifications []*models.Notification, status models.NotificationStatus) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for _, n := range notifications {
+		for {
+			stored, err := st.GetNotification(n.ID)
+			if err != nil {
+				t.Fatalf("GetNotification: %v", err)
+			}
+			if stored.Status == status {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("notification %s is %v, want %v", n.ID, stored.Status, status)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+}
+
+func TestOverflowPolicies(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		policy      OverflowPolicy
+		ctx         context.Context
+		wantErr     error
+		wantSpilled int
+		wantDropped int64
+		deadLetters bool // whether the dropped notifications are dead-lettered
+	}{
+		{OverflowReject, context.Background(), ErrQueueFull, 0, 5, true},
+		{OverflowSpill, context.Background(), nil, 5, 0, false},
+		{OverflowBlock, cancelled, context.Canceled, 0, 5, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			st := store.NewMemoryStore(true)
+			// Never started, so nothing drains the buffer or the overflow list
+			nq := NewNotificationQueue(st, delivery.NewSimulatedDeliverer(0, 0, 0), 1)
+			nq.SetOverflowPolicy(tt.policy)
+
+			notifications := saveNotifications(t, st, cap(nq.queue)+5)
+			queued, err := nq.QueueNotifications(tt.ctx, notifications)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("QueueNotifications = %v, want %v", err, tt.wantErr)
+			}
+			if want := len(notifications) - int(tt.wantDropped); queued != want {
+				t.Errorf("queued %d, want %d", queued, want)
+			}
+			metrics := nq.GetMetrics()
+			if metrics["spilled"] != tt.wantSpilled || metrics["dropped"] != tt.wantDropped {
+				t.Errorf("spilled %v and dropped %v, want %d and %d", metrics["spilled"], metrics["dropped"], tt.wantSpilled, tt.wantDropped)
+			}
+
+			// Dropped notifications are not left looking queued
+			for i, n := range notifications[queued:] {
+				stored, err := st.GetNotification(n.ID)
+				if err != nil {
+					t.Fatalf("GetNotification: %v", err)
+				}
+				if stored.Status != models.StatusFailed {
+					t.Errorf("dropped notification %d is %v, want FAILED", i, stored.Status)
+				}
+				if _, err := st.GetDeadLetter(n.ID); (err == nil) != tt.deadLetters {
+					t.Errorf("GetDeadLetter for dropped notification %d = %v, want a dead letter %v", i, err, tt.deadLetters)
+				}
+			}
+		})
+	}
+}
+
+func TestParseOverflowPolicy(t *testing.T) {
+	for _, policy := range []OverflowPolicy{OverflowBlock, OverflowReject, OverflowSpill} {
+		if got, err := ParseOverflowPolicy(policy.String()); err != nil || got != policy {
+			t.Errorf("ParseOverflowPolicy(%q) = %v, %v", policy.String(), got, err)
+		}
+	}
+	if _, err := ParseOverflowPolicy("drop"); err == nil {
+		t.Error("ParseOverflowPolicy accepted an unknown policy")
+	}
+}
+
+func TestSpillLimitRejects(t *testing.T) {
+	st := store.NewMemoryStore(true)
+	// Never started, so nothing drains the buffer or the overflow list
+	nq := NewNotificationQueue(st, delivery.NewSimulatedDeliverer(0, 0, 0), 1)
+	nq.SetOverflowPolicy(OverflowSpill)
+	nq.SetSpillLimit(3)
+
+	notifications := saveNotifications(t, st, cap(nq.queue)+5)
+	queued, err := nq.QueueNotifications(context.Background(), notifications)
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("QueueNotifications = %v, want ErrQueueFull once the overflow list is full", err)
+	}
+	if queued != cap(nq.queue)+3 {
+		t.Errorf("queued %d, want the buffer plus 3 spilled", queued)
+	}
+	if spilled := nq.GetMetrics()["spilled"]; spilled != 3 {
+		t.Errorf("spilled = %v, want 3", spilled)
+	}
+
+	for i, n := range notifications[queued:] {
+		stored, err := st.GetNotification(n.ID)
+		if err != nil {
+			t.Fatalf("GetNotification: %v", err)
+		}
+		if stored.Status != models.StatusFailed {
+			t.Errorf("rejected notification %d is %v, want FAILED", i, stored.Status)
+		}
+	}
+}
+
+func TestShutdownDeliversBuffered(t *testing.T) {
+	st := store.NewMemoryStore(true)
+	release := make(chan struct{})
+	nq := NewNotificationQueue(st, funcDeliverer(func(ctx context.Context, _ *models.Notification) error {
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}), 2)
+	nq.Start()
+
+	notifications := saveNotifications(t, st, 20)
+	if _, err := nq.QueueNotifications(context.Background(), notifications); err != nil {
+		t.Fatalf("QueueNotifications: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- nq.Shutdown(ctx) }()
+
+	// Intake is closed before the workers get to deliver anything
+	waitForStopping(t, nq)
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Shutdown = %v, want the buffer drained before the deadline", err)
+	}
+	waitForStatus(t, st, notifications, models.StatusDelivered)
+}
+
+func TestShutdownLeavesUndeliveredPendingForTheNextStart(t *testing.T) {
+	st := store.NewMemoryStore(true)
+	notifications := saveNotifications(t, st, 6)
+
+	// One notification is waiting out its backoff
+	retrying := notifications[5]
+	retrying.Status = models.StatusRetrying
+	retrying.Attempts = 1
+	retrying.NextAttemptAt = time.Now().Add(300 * time.Millisecond)
+	if err := st.UpdateNotification(retrying); err != nil {
+		t.Fatalf("UpdateNotification: %v", err)
+	}
+
+	// Deliveries hang until shutdown cuts them off
+	started := make(chan struct{}, len(notifications))
+	nq := NewNotificationQueue(st, funcDeliverer(func(ctx context.Context, _ *models.Notification) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}), 1)
+	nq.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := nq.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown = %v, want the deadline to cut the drain short", err)
+	}
+
+	for _, n := range notifications {
+		stored, err := st.GetNotification(n.ID)
+		if err != nil {
+			t.Fatalf("GetNotification: %v", err)
+		}
+		want, wantAttempts := models.StatusQueued, 0
+		if n == retrying {
+			want, wantAttempts = models.StatusRetrying, 1
+		}
+		if stored.Status != want || stored.Attempts != wantAttempts {
+			t.Errorf("after shutdown %s is %v after %d attempts, want %v after %d", n.ID, stored.Status, stored.Attempts, want, wantAttempts)
+		}
+	}
+
+	// The next start picks all of them up, the retry once it is due
+	next := NewNotificationQueue(st, delivery.NewSimulatedDeliverer(0, 0, 0), 2)
+	next.Start()
+	defer next.Stop()
+	waitForStatus(t, st, notifications, models.StatusDelivered)
+}
+
+// slowPendingStore takes a while to load the pending notifications and
+// closes loaded once it has
+type slowPendingStore struct {
+	store.Store
+	loaded chan struct{}
+}
+
+func (s slowPendingStore) GetPendingNotifications() ([]*models.Notification, error) {
+	defer close(s.loaded)
+	time.Sleep(100 * time.Millisecond)
+	return s.Store.GetPendingNotifications()
+}
+
+func TestStartDoesNotRecoverLaterNotifications(t *testing.T) {
+	st := slowPendingStore{store.NewMemoryStore(true), make(chan struct{})}
+	pending := saveNotifications(t, st, 5)
+
+	var mu sync.Mutex
+	deliveries := make(map[string]int)
+	nq := NewNotificationQueue(st, funcDeliverer(func(_ context.Context, n *models.Notification) error {
+		// Nothing is delivered before the pending set is loaded
+		<-st.loaded
+		mu.Lock()
+		defer mu.Unlock()
+		deliveries[n.ID]++
+		return nil
+	}), 2)
+	nq.Start()
+	defer nq.Stop()
+
+	// Saved and queued right after the start, like PublishPost does, so the
+	// pending recovery must not pick them up as well
+	published := saveNotifications(t, st, 50)
+	if _, err := nq.QueueNotifications(context.Background(), published); err != nil {
+		t.Fatalf("QueueNotifications: %v", err)
+	}
+	all := append(pending, published...)
+	waitForStatus(t, st, all, models.StatusDelivered)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, n := range all {
+		if deliveries[n.ID] != 1 {
+			t.Errorf("%s delivered %d times, want once", n.ID, deliveries[n.ID])
+		}
+	}
+}
+
+func TestQueueAfterShutdown(t *testing.T) {
+	st := store.NewMemoryStore(true)
+	nq := NewNotificationQueue(st, delivery.NewSimulatedDeliverer(0, 0, 0), 2)
+	nq.Start()
+
+	// Publishers racing the shutdown either get in or see ErrQueueStopped
+	notifications := saveNotifications(t, st, 200)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(batch []*models.Notification) {
+			defer wg.Done()
+			for _, n := range batch {
+				if err := nq.QueueNotification(context.Background(), n); err != nil && !errors.Is(err, ErrQueueStopped) {
+					t.Errorf("QueueNotification during shutdown = %v", err)
+				}
+			}
+		}(notifications[i*50 : (i+1)*50])
+	}
+	if err := nq.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	wg.Wait()
+
+	late := saveNotifications(t, st, 1)[0]
+	if err := nq.QueueNotification(context.Background(), late); !errors.Is(err, ErrQueueStopped) {
+		t.Fatalf("QueueNotification after Shutdown = %v, want ErrQueueStopped", err)
+	}
+	stored, err := st.GetNotification(late.ID)
+	if err != nil {
+		t.Fatalf("GetNotification: %v", err)
+	}
+	if stored.Status != models.StatusQueued {
+		t.Errorf("rejected notification is %v, want it left QUEUED for the next start", stored.Status)
+	}
+}
+
+// waitForStopping waits until the queue has stopped taking notifications
+func waitForStopping(t *testing.T, nq *NotificationQueue) {
+	t.Helper()
+	select {
+	case <-nq.stopping:
+	case <-time.After(5 * time.Second):
+		t.Fatal("queue did not stop taking notifications")
+	}
+}
@@ -0,0 +1,131 @@
+package queue
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+// retryScheduler holds notifications waiting out their backoff and releases
+// each one when it is due. A single goroutine sleeps until the earliest due
+// time instead of one goroutine per retry.
+type retryScheduler struct {
+	mu    sync.Mutex
+	items retryHeap
+	wake  chan struct{}
+}
+
+type retryItem struct {
+	notification *models.Notification
+	due          time.Time
+}
+
+// retryHeap is a min-heap of retries ordered by due time
+type retryHeap []retryItem
+
+func (h retryHeap) Len() int           { return len(h) }
+func (h retryHeap) Less(i, j int) bool { return h[i].due.Before(h[j].due) }
+func (h retryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *retryHeap) Push(x interface{}) {
+	*h = append(*h, x.(retryItem))
+}
+
+func (h *retryHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = retryItem{}
+	*h = old[:len(old)-1]
+	return item
+}
+
+func newRetryScheduler() *retryScheduler {
+	return &retryScheduler{wake: make(chan struct{}, 1)}
+}
+
+// schedule adds a notification to be released at due
+func (s *retryScheduler) schedule(notification *models.Notification, due time.Time) {
+	s.mu.Lock()
+	heap.Push(&s.items, retryItem{notification: notification, due: due})
+	earliest := s.items[0].notification == notification
+	s.mu.Unlock()
+
+	// Only a new earliest retry changes how long run sleeps
+	if earliest {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// pending returns how many retries are scheduled
+func (s *retryScheduler) pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+// nextDue returns when the earliest retry is due
+func (s *retryScheduler) nextDue() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.items) == 0 {
+		return time.Time{}, false
+	}
+	return s.items[0].due, true
+}
+
+// popDue removes and returns the earliest retry if it is due by now
+func (s *retryScheduler) popDue(now time.Time) (*models.Notification, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.items) == 0 || s.items[0].due.After(now) {
+		return nil, false
+	}
+	return heap.Pop(&s.items).(retryItem).notification, true
+}
+
+// run hands due notifications to release until stop is closed. Retries still
+// scheduled then are left to the store, where they are persisted as
+// retrying along with their due time.
+func (s *retryScheduler) run(stop <-chan struct{}, release func(*models.Notification)) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			notification, ok := s.popDue(time.Now())
+			if !ok {
+				break
+			}
+			release(notification)
+		}
+
+		wait := time.Hour
+		if due, ok := s.nextDue(); ok {
+			wait = time.Until(due)
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-timer.C:
+		case <-s.wake:
+		case <-stop:
+			return
+		}
+	}
+}
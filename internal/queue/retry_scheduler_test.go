@@ -0,0 +1,152 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+// runScheduler runs s until the returned stop function is called, sending
+// released notifications to the channel
+func runScheduler(s *retryScheduler) (<-chan *models.Notification, func()) {
+	released := make(chan *models.Notification, 16)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.run(stop, func(n *models.Notification) { released <- n })
+	}()
+	return released, func() {
+		close(stop)
+		<-done
+	}
+}
+
+func receive(t *testing.T, released <-chan *models.Notification) *models.Notification {
+	t.Helper()
+	select {
+	case n := <-released:
+		return n
+	case <-time.After(5 * time.Second):
+		t.Fatal("no retry released")
+		return nil
+	}
+}
+
+func TestRetrySchedulerReleasesInDueOrder(t *testing.T) {
+	s := newRetryScheduler()
+	released, stop := runScheduler(s)
+	defer stop()
+
+	now := time.Now()
+	late := &models.Notification{ID: "late"}
+	early := &models.Notification{ID: "early"}
+	middle := &models.Notification{ID: "middle"}
+	s.schedule(late, now.Add(60*time.Millisecond))
+	s.schedule(early, now.Add(20*time.Millisecond))
+	s.schedule(middle, now.Add(40*time.Millisecond))
+
+	for _, want := range []*models.Notification{early, middle, late} {
+		got := receive(t, released)
+		if got != want {
+			t.Fatalf("released %s, want %s", got.ID, want.ID)
+		}
+		if time.Now().Before(now.Add(20 * time.Millisecond)) {
+			t.Fatalf("released %s before it was due", got.ID)
+		}
+	}
+	if pending := s.pending(); pending != 0 {
+		t.Errorf("pending = %d after releasing everything", pending)
+	}
+}
+
+func TestRetrySchedulerKeepsRetriesAcrossStop(t *testing.T) {
+	s := newRetryScheduler()
+	released, stop := runScheduler(s)
+	n := &models.Notification{ID: "retry"}
+	s.schedule(n, time.Now().Add(50*time.Millisecond))
+	stop()
+
+	select {
+	case got := <-released:
+		t.Fatalf("released %s after stop", got.ID)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if pending := s.pending(); pending != 1 {
+		t.Fatalf("pending = %d after stop, want the retry kept", pending)
+	}
+
+	// Overdue by the time the scheduler runs again
+	released, stop = runScheduler(s)
+	defer stop()
+	if got := receive(t, released); got != n {
+		t.Fatalf("released %s after restart, want %s", got.ID, n.ID)
+	}
+}
+
+func TestRetrySchedulerReleasesOverdueAtOnce(t *testing.T) {
+	s := newRetryScheduler()
+	overdue := &models.Notification{ID: "overdue"}
+	future := &models.Notification{ID: "future"}
+	s.schedule(future, time.Now().Add(time.Hour))
+	s.schedule(overdue, time.Now().Add(-time.Minute))
+
+	released, stop := runScheduler(s)
+	defer stop()
+	if got := receive(t, released); got != overdue {
+		t.Fatalf("released %s, want the overdue retry", got.ID)
+	}
+	if due, ok := s.nextDue(); !ok || !due.After(time.Now()) {
+		t.Errorf("next due %v, %v, want the future retry still scheduled", due, ok)
+	}
+}
+
+func TestStartLoadsRetriesFromTheStore(t *testing.T) {
+	st := store.NewMemoryStore(true)
+	notifications := saveNotifications(t, st, 2)
+	overdue, future := notifications[0], notifications[1]
+	for n, due := range map[*models.Notification]time.Time{
+		overdue: time.Now().Add(-time.Minute),
+		future:  time.Now().Add(time.Hour),
+	} {
+		n.Status = models.StatusRetrying
+		n.Attempts = 1
+		n.NextAttemptAt = due
+		if err := st.UpdateNotification(n); err != nil {
+			t.Fatalf("UpdateNotification: %v", err)
+		}
+	}
+
+	delivered := make(chan string, 2)
+	nq := NewNotificationQueue(st, funcDeliverer(func(_ context.Context, n *models.Notification) error {
+		delivered <- n.ID
+		return nil
+	}), 1)
+	nq.Start()
+	defer nq.Stop()
+
+	waitForStatus(t, st, []*models.Notification{overdue}, models.StatusDelivered)
+	if got := <-delivered; got != overdue.ID {
+		t.Fatalf("delivered %s first, want the overdue retry", got)
+	}
+
+	// The retry that is not due yet waits in the scheduler with its due time
+	deadline := time.Now().Add(5 * time.Second)
+	for nq.retries.pending() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("pending retries = %d, want the future one scheduled", nq.retries.pending())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if due, _ := nq.retries.nextDue(); !due.Equal(future.NextAttemptAt) {
+		t.Errorf("future retry due %v, want %v", due, future.NextAttemptAt)
+	}
+	select {
+	case id := <-delivered:
+		t.Errorf("delivered %s before it was due", id)
+	default:
+	}
+}
@@ -0,0 +1,232 @@
+package realtime
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/suyashXD/DNDS/internal/auth"
+	"github.com/suyashXD/DNDS/internal/events"
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+const (
+	// writeWait is how long a single write to a client may take
+	writeWait = 10 * time.Second
+	// pongWait is how long a client may stay silent before it is dropped
+	pongWait = 60 * time.Second
+	// pingPeriod must be shorter than pongWait so a healthy client always
+	// answers in time
+	pingPeriod = pongWait * 9 / 10
+	// sendBuffer is the number of events queued per connection; a client
+	// that falls this far behind is disconnected
+	sendBuffer = 64
+)
+
+// Hub keeps track of WebSocket sessions and pushes delivered notifications to
+// every session of the recipient. A user may have any number of sessions,
+// each with its own subscription to the event bus.
+type Hub struct {
+	auth     auth.Authenticator
+	bus      *events.Bus
+	upgrader websocket.Upgrader
+
+	mu       sync.RWMutex
+	sessions map[string]map[*session]struct{}
+	closed   bool
+}
+
+// session is one WebSocket connection
+type session struct {
+	hub    *Hub
+	userID string
+	conn   *websocket.Conn
+	sub    *events.Subscription
+	once   sync.Once
+	done   chan struct{}
+}
+
+// NewHub creates a hub that admits connections authenticated by authenticator
+// and pushes the deliveries announced on bus
+func NewHub(authenticator auth.Authenticator, bus *events.Bus) *Hub {
+	return &Hub{
+		auth: authenticator,
+		bus:  bus,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			// Clients authenticate with a token, not cookies, so cross-origin
+			// connections cannot ride on a user's session
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		sessions: make(map[string]map[*session]struct{}),
+	}
+}
+
+// ServeHTTP authenticates the request and upgrades it to a WebSocket
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.auth.Authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied to the client
+		log.Printf("WebSocket upgrade failed for user %s: %v", userID, err)
+		return
+	}
+
+	s := &session{
+		hub:    h,
+		userID: userID,
+		conn:   conn,
+		sub:    h.bus.Subscribe(userID, sendBuffer),
+		done:   make(chan struct{}),
+	}
+	if !h.register(s) {
+		s.sub.Unsubscribe()
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+			time.Now().Add(writeWait))
+		conn.Close()
+		return
+	}
+
+	go s.writePump()
+	go s.readPump()
+}
+
+// SessionCount returns the number of open sessions for a user
+func (h *Hub) SessionCount(userID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.sessions[userID])
+}
+
+// Close disconnects every session and refuses new ones
+func (h *Hub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	var all []*session
+	for _, sessions := range h.sessions {
+		for s := range sessions {
+			all = append(all, s)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, s := range all {
+		s.close()
+	}
+}
+
+func (h *Hub) register(s *session) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return false
+	}
+	if h.sessions[s.userID] == nil {
+		h.sessions[s.userID] = make(map[*session]struct{})
+	}
+	h.sessions[s.userID][s] = struct{}{}
+	return true
+}
+
+func (h *Hub) unregister(s *session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.sessions[s.userID], s)
+	if len(h.sessions[s.userID]) == 0 {
+		delete(h.sessions, s.userID)
+	}
+}
+
+// close tears the session down; safe to call more than once
+func (s *session) close() {
+	s.once.Do(func() {
+		s.hub.unregister(s)
+		s.sub.Unsubscribe()
+		close(s.done)
+	})
+}
+
+// readPump consumes client frames so pongs and close frames are processed.
+// Clients are not expected to send data.
+func (s *session) readPump() {
+	defer s.close()
+
+	s.conn.SetReadLimit(512)
+	s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		if _, _, err := s.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writePump is the only goroutine writing to the connection. It sends
+// delivered notifications and keeps the connection alive with pings.
+func (s *session) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		s.conn.Close()
+		s.close()
+	}()
+
+	for {
+		select {
+		case event, ok := <-s.sub.C():
+			if !ok {
+				// Either the client fell behind and the bus dropped it rather
+				// than block delivery, or the session is closing
+				if s.sub.Err() != nil {
+					log.Printf("WebSocket session for user %s is too slow, disconnecting", s.userID)
+					return
+				}
+				s.writeClose()
+				return
+			}
+			if !isDelivery(event) {
+				continue
+			}
+			s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := s.conn.WriteJSON(NewMessage(&event.Notification)); err != nil {
+				return
+			}
+		case <-ticker.C:
+			s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := s.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-s.done:
+			s.writeClose()
+			return
+		}
+	}
+}
+
+// writeClose tells the client the session is over
+func (s *session) writeClose() {
+	s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	s.conn.WriteMessage(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
+
+// isDelivery reports whether event marks a notification delivered
+func isDelivery(event events.Event) bool {
+	return event.Type == events.NotificationStatusChanged && event.Notification.Status == models.StatusDelivered
+}
@@ -0,0 +1,155 @@
+package realtime
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/suyashXD/DNDS/internal/auth"
+	"github.com/suyashXD/DNDS/internal/events"
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+func newHubServer(t *testing.T, bus *events.Bus) (*Hub, *httptest.Server, *auth.TokenAuthenticator) {
+	t.Helper()
+	authenticator := auth.NewTokenAuthenticator(testSecret)
+	hub := NewHub(authenticator, bus)
+	server := httptest.NewServer(hub)
+	t.Cleanup(func() {
+		hub.Close()
+		server.Close()
+	})
+	return hub, server, authenticator
+}
+
+// dial opens a WebSocket session with token
+func dial(t *testing.T, server *httptest.Server, token string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "?token=" + token
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// waitFor polls cond until it holds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) *Message {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg Message
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("ReadJSON: %v", err)
+	}
+	return &msg
+}
+
+// delivered returns a delivered notification for userID
+func delivered(userID string) *models.Notification {
+	n := models.NewNotification(userID, &models.Post{ID: "post1", AuthorID: "user1"})
+	n.Status = models.StatusDelivered
+	return n
+}
+
+func TestHubRejectsBadTokens(t *testing.T) {
+	hub, server, authenticator := newHubServer(t, events.NewBus())
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	for _, query := range []string{
+		"",
+		"?userId=user2",
+		"?token=" + authenticator.Token("user2") + "x",
+		"?token=" + auth.NewTokenAuthenticator("other").Token("user2"),
+	} {
+		_, resp, err := websocket.DefaultDialer.Dial(url+query, nil)
+		if err == nil {
+			t.Fatalf("Dial%s succeeded", query)
+		}
+		if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Dial%s: %v, want %d", query, err, http.StatusUnauthorized)
+		}
+	}
+	if count := hub.SessionCount("user2"); count != 0 {
+		t.Errorf("SessionCount = %d after rejected connections", count)
+	}
+}
+
+func TestHubFansOutToEverySessionOfTheRecipient(t *testing.T) {
+	bus := events.NewBus()
+	hub, server, authenticator := newHubServer(t, bus)
+
+	first := dial(t, server, authenticator.Token("user2"))
+	second := dial(t, server, authenticator.Token("user2"))
+	other := dial(t, server, authenticator.Token("user3"))
+	waitFor(t, "sessions", func() bool { return hub.SessionCount("user2") == 2 && hub.SessionCount("user3") == 1 })
+
+	// Only deliveries are pushed, and only to the recipient
+	queued := delivered("user2")
+	queued.Status = models.StatusQueued
+	bus.Publish(events.NotificationAdded, queued, models.StatusUnknown)
+	bus.Publish(events.NotificationStatusChanged, queued, models.StatusUnknown)
+	own := delivered("user2")
+	bus.Publish(events.NotificationStatusChanged, own, models.StatusQueued)
+	theirs := delivered("user3")
+	bus.Publish(events.NotificationStatusChanged, theirs, models.StatusQueued)
+
+	for i, conn := range []*websocket.Conn{first, second} {
+		if msg := readMessage(t, conn); msg.ID != own.ID || msg.Status != "DELIVERED" {
+			t.Errorf("session %d got %+v, want delivered %s", i, msg, own.ID)
+		}
+	}
+	if msg := readMessage(t, other); msg.ID != theirs.ID {
+		t.Errorf("user3 got %s, want only their own %s", msg.ID, theirs.ID)
+	}
+}
+
+func TestHubCleansUpClosedSessions(t *testing.T) {
+	bus := events.NewBus()
+	hub, server, authenticator := newHubServer(t, bus)
+
+	leaving := dial(t, server, authenticator.Token("user2"))
+	staying := dial(t, server, authenticator.Token("user2"))
+	waitFor(t, "sessions", func() bool { return hub.SessionCount("user2") == 2 })
+
+	// A client hanging up ends its session and its bus subscription
+	leaving.Close()
+	waitFor(t, "the closed session to be dropped", func() bool {
+		return hub.SessionCount("user2") == 1 && bus.SubscriberCount() == 1
+	})
+
+	// The other session keeps receiving
+	n := delivered("user2")
+	bus.Publish(events.NotificationStatusChanged, n, models.StatusQueued)
+	if msg := readMessage(t, staying); msg.ID != n.ID {
+		t.Errorf("remaining session got %s, want %s", msg.ID, n.ID)
+	}
+
+	// Closing the hub disconnects everyone and refuses new sessions
+	hub.Close()
+	waitFor(t, "the hub to close", func() bool { return hub.SessionCount("user2") == 0 && bus.SubscriberCount() == 0 })
+	staying.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := staying.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("read after Close = %v, want a normal closure", err)
+	}
+	late := dial(t, server, authenticator.Token("user2"))
+	late.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := late.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("read on a session opened after Close = %v, want going away", err)
+	}
+}
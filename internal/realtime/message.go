@@ -0,0 +1,49 @@
+// Package realtime pushes delivered notifications to connected clients over
+// WebSockets and Server-Sent Events.
+package realtime
+
+import (
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+// Message is the JSON payload pushed to clients. Field names follow the
+// GraphQL Notification type.
+type Message struct {
+	ID        string `json:"id"`
+	UserID    string `json:"userId"`
+	PostID    string `json:"postId"`
+	AuthorID  string `json:"authorId"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"createdAt"`
+	Read      bool   `json:"read"`
+	Status    string `json:"status"`
+}
+
+// statusNames mirrors the GraphQL NotificationStatus enum
+var statusNames = map[models.NotificationStatus]string{
+	models.StatusUnknown:   "UNKNOWN",
+	models.StatusQueued:    "QUEUED",
+	models.StatusDelivered: "DELIVERED",
+	models.StatusFailed:    "FAILED",
+	models.StatusRetrying:  "RETRYING",
+}
+
+// NewMessage converts a notification to its wire form
+func NewMessage(n *models.Notification) *Message {
+	status, ok := statusNames[n.Status]
+	if !ok {
+		status = "UNKNOWN"
+	}
+	return &Message{
+		ID:        n.ID,
+		UserID:    n.UserID,
+		PostID:    n.PostID,
+		AuthorID:  n.AuthorID,
+		Content:   n.Content,
+		CreatedAt: n.CreatedAt.UTC().Format(time.RFC3339),
+		Read:      n.Read,
+		Status:    status,
+	}
+}
@@ -0,0 +1,197 @@
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/auth"
+	"github.com/suyashXD/DNDS/internal/events"
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+const (
+	// keepAliveInterval keeps proxies from timing out idle streams
+	keepAliveInterval = 30 * time.Second
+	// replayPage is how many notifications a reconnecting client's replay
+	// reads from the store at a time
+	replayPage = 500
+	// retryMillis is the reconnect delay suggested to EventSource clients
+	retryMillis = 3000
+)
+
+// SSEHandler streams delivered notifications as text/event-stream. Each event
+// carries the notification ID as its event ID, so a reconnecting client sends
+// it back in Last-Event-ID and is replayed everything delivered after it.
+type SSEHandler struct {
+	auth  auth.Authenticator
+	store store.Store
+	bus   *events.Bus
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSSEHandler creates an SSE handler that admits requests authenticated by
+// authenticator, streams the deliveries announced on bus and replays from
+// store
+func NewSSEHandler(authenticator auth.Authenticator, store store.Store, bus *events.Bus) *SSEHandler {
+	return &SSEHandler{
+		auth:  authenticator,
+		store: store,
+		bus:   bus,
+		done:  make(chan struct{}),
+	}
+}
+
+// Close ends every open stream so the HTTP server can shut down; clients will
+// reconnect elsewhere with Last-Event-ID
+func (h *SSEHandler) Close() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
+
+// ServeHTTP authenticates the request and streams the notifications of the
+// user its token belongs to
+func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.auth.Authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// EventSource sends the header on reconnect; the query parameter lets
+	// clients resume a brand-new connection
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+
+	// Subscribe before replaying so nothing delivered in between is lost
+	sub := h.bus.Subscribe(userID, sendBuffer)
+	defer sub.Unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", retryMillis)
+
+	sent := make(map[string]bool)
+	if lastEventID != "" {
+		missed, err := h.missedSince(userID, lastEventID)
+		if errors.Is(err, store.ErrNotificationNotFound) {
+			// The client has to refetch what it missed some other way
+			if _, err := fmt.Fprintf(w, "event: gap\ndata: {\"lastEventId\":%q}\n\n", lastEventID); err != nil {
+				return
+			}
+		} else if err != nil {
+			log.Printf("Failed to replay notifications for user %s: %v", userID, err)
+		}
+		for _, n := range missed {
+			if err := writeEvent(w, n); err != nil {
+				return
+			}
+			sent[n.ID] = true
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, ok := <-sub.C():
+			if !ok {
+				// Fell behind; the client reconnects and catches up through
+				// Last-Event-ID instead of silently missing events
+				return
+			}
+			if !isDelivery(event) || sent[event.Notification.ID] {
+				continue
+			}
+			if err := writeEvent(w, &event.Notification); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-h.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// missedSince returns the notifications delivered after lastID, in delivery
+// order, since that is the order they were streamed in: a notification
+// created before lastID but retried until after it is included. It fails
+// with store.ErrNotificationNotFound if lastID is not one of the user's
+// notifications, for instance because it was deleted.
+func (h *SSEHandler) missedSince(userID, lastID string) ([]*models.Notification, error) {
+	last, err := h.store.GetNotification(lastID)
+	if err != nil {
+		return nil, err
+	}
+	if last.UserID != userID {
+		return nil, store.ErrNotificationNotFound
+	}
+	since := last.DeliveredAt
+	if since.IsZero() {
+		// Delivered before delivery times were stored; nothing delivered
+		// after it can predate its creation
+		since = last.CreatedAt
+	}
+
+	var missed []*models.Notification
+	after := ""
+	for {
+		page, err := h.store.QueryNotifications(store.NotificationQuery{
+			UserID: userID,
+			Filter: store.NotificationFilter{
+				Statuses:       []models.NotificationStatus{models.StatusDelivered},
+				DeliveredAfter: since,
+			},
+			Order: store.OldestFirst,
+			After: after,
+			First: replayPage,
+		})
+		if err != nil {
+			return nil, err
+		}
+		missed = append(missed, page.Notifications...)
+		if !page.HasNext || len(page.Notifications) == 0 {
+			break
+		}
+		after = page.Notifications[len(page.Notifications)-1].ID
+	}
+
+	sort.SliceStable(missed, func(i, j int) bool { return missed[i].DeliveredAt.Before(missed[j].DeliveredAt) })
+	return missed, nil
+}
+
+// writeEvent writes one notification event
+func writeEvent(w http.ResponseWriter, n *models.Notification) error {
+	data, err := json.Marshal(NewMessage(n))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: notification\ndata: %s\n\n", n.ID, data)
+	return err
+}
@@ -0,0 +1,196 @@
+package realtime
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/auth"
+	"github.com/suyashXD/DNDS/internal/events"
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+const testSecret = "test-secret"
+
+// sseEvent is one event read off a stream
+type sseEvent struct {
+	id, event, data string
+}
+
+// readEvents connects to url and returns the first n events the server sends
+func readEvents(t *testing.T, url string, header http.Header, n int) []sseEvent {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: status %d", url, resp.StatusCode)
+	}
+
+	var got []sseEvent
+	var current sseEvent
+	scanner := bufio.NewScanner(resp.Body)
+	for len(got) < n && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if current.event != "" {
+				got = append(got, current)
+			}
+			current = sseEvent{}
+		case strings.HasPrefix(line, "id: "):
+			current.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			current.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	if len(got) < n {
+		t.Fatalf("got %d events before the stream ended (%v), want %d", len(got), scanner.Err(), n)
+	}
+	return got
+}
+
+func newSSEServer(t *testing.T, st store.Store, bus *events.Bus) (*httptest.Server, *auth.TokenAuthenticator) {
+	t.Helper()
+	authenticator := auth.NewTokenAuthenticator(testSecret)
+	handler := NewSSEHandler(authenticator, st, bus)
+	server := httptest.NewServer(handler)
+	t.Cleanup(func() {
+		handler.Close()
+		server.Close()
+	})
+	return server, authenticator
+}
+
+func TestSSERequiresToken(t *testing.T) {
+	server, authenticator := newSSEServer(t, store.NewMemoryStore(true), events.NewBus())
+
+	for _, url := range []string{
+		server.URL + "?userId=user1",
+		server.URL + "?token=" + authenticator.Token("user1") + "x",
+		server.URL + "?token=" + auth.NewTokenAuthenticator("other").Token("user1"),
+	} {
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("GET %s: status %d, want %d", url, resp.StatusCode, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestSSEStreamsTheTokensUser(t *testing.T) {
+	bus := events.NewBus()
+	server, authenticator := newSSEServer(t, store.NewMemoryStore(true), bus)
+
+	post := &models.Post{ID: "post1", AuthorID: "user1"}
+	other := models.NewNotification("user3", post)
+	other.Status = models.StatusDelivered
+	own := models.NewNotification("user2", post)
+	own.Status = models.StatusDelivered
+
+	// Publish until the subscription is in place
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(10 * time.Millisecond):
+				bus.Publish(events.NotificationStatusChanged, other, models.StatusQueued)
+				bus.Publish(events.NotificationStatusChanged, own, models.StatusQueued)
+			}
+		}
+	}()
+
+	// userId is ignored; the token decides whose notifications are sent
+	got := readEvents(t, server.URL+"?userId=user3&token="+authenticator.Token("user2"), nil, 1)
+	if got[0].id != own.ID {
+		t.Errorf("streamed %s, want the token user's %s", got[0].id, own.ID)
+	}
+}
+
+func TestSSEResumeReplaysInDeliveryOrder(t *testing.T) {
+	st := store.NewMemoryStore(true)
+	server, authenticator := newSSEServer(t, st, events.NewBus())
+
+	post, err := st.GetPost("post1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now().Add(-time.Hour)
+	save := func(created, delivered time.Duration) *models.Notification {
+		n := models.NewNotification("user2", post)
+		n.CreatedAt = base.Add(created)
+		if err := st.SaveNotification(n); err != nil {
+			t.Fatalf("SaveNotification: %v", err)
+		}
+		n.Status = models.StatusDelivered
+		n.DeliveredAt = base.Add(delivered)
+		if err := st.UpdateNotification(n); err != nil {
+			t.Fatalf("UpdateNotification: %v", err)
+		}
+		return n
+	}
+	seenBefore := save(0, time.Second)
+	// Created before the last event the client saw, but retried and only
+	// delivered after it
+	retried := save(2*time.Second, 5*time.Second)
+	lastSeen := save(3*time.Second, 4*time.Second)
+	later := save(4*time.Second, 6*time.Second)
+
+	header := http.Header{"Last-Event-Id": {lastSeen.ID}}
+	got := readEvents(t, server.URL+"?token="+authenticator.Token("user2"), header, 2)
+	if got[0].id != retried.ID || got[1].id != later.ID {
+		t.Errorf("replayed %s, %s, want %s, %s", got[0].id, got[1].id, retried.ID, later.ID)
+	}
+	for _, event := range got {
+		if event.id == seenBefore.ID || event.id == lastSeen.ID {
+			t.Errorf("replayed %s, which was delivered before the last event seen", event.id)
+		}
+	}
+}
+
+func TestSSEResumeFromUnknownIDSendsGap(t *testing.T) {
+	st := store.NewMemoryStore(true)
+	server, authenticator := newSSEServer(t, st, events.NewBus())
+
+	post, err := st.GetPost("post1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another user's notification is as unknown as a deleted one
+	other := models.NewNotification("user3", post)
+	if err := st.SaveNotification(other); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, lastID := range []string{"deleted", other.ID} {
+		got := readEvents(t, server.URL+"?token="+authenticator.Token("user2")+"&lastEventId="+lastID, nil, 1)
+		if got[0].event != "gap" || !strings.Contains(got[0].data, lastID) {
+			t.Errorf("resuming after %s sent %+v, want a gap event", lastID, got[0])
+		}
+	}
+}
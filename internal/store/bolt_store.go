@@ -0,0 +1,1150 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+// Bucket layout of the bbolt store. followers/following hold one key per
+// edge (owner ID, 0x00, other ID), so both directions of the follower graph
+// are a prefix scan away.
+var (
+	bucketUsers               = []byte("users")
+	bucketFollowers           = []byte("followers")
+	bucketFollowing           = []byte("following")
+	bucketPosts               = []byte("posts")
+	bucketNotifications       = []byte("notifications")
+	bucketNotificationsByUser = []byte("notifications_by_user")
+	bucketPendingByTime       = []byte("pending_by_time")
+	bucketUnreadByUser        = []byte("unread_by_user")
+	bucketNotificationCounts  = []byte("notification_counts")
+	bucketDeadLetters         = []byte("dead_letters")
+)
+
+// boltUser is the stored form of a user; follower IDs live in their own buckets
+type boltUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// boltNotification wraps a notification with the sequence number that breaks
+// ties between notifications created in the same nanosecond
+type boltNotification struct {
+	*models.Notification
+	Seq uint64 `json:"seq"`
+}
+
+// BoltStore is a store backed by an embedded bbolt B+tree database. Every
+// entity lives in its own bucket, and notifications are indexed by
+// (user ID, creation time) so GetUserNotifications reads only what it returns.
+// unread_by_user uses the same keys for unread notifications only.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens (or creates) a bbolt database at path. Sample data is only
+// loaded into a database that holds no users yet.
+func NewBoltStore(path string, loadSampleData bool) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open bolt database: %w", err)
+	}
+
+	s := &BoltStore{db: db}
+	err = db.Update(func(tx *bolt.Tx) error {
+		// Databases created before the unread index or the counters existed
+		// need them built
+		backfillUnread := tx.Bucket(bucketUnreadByUser) == nil
+		backfillCounts := tx.Bucket(bucketNotificationCounts) == nil
+
+		for _, name := range [][]byte{
+			bucketUsers, bucketFollowers, bucketFollowing, bucketPosts,
+			bucketNotifications, bucketNotificationsByUser, bucketPendingByTime,
+			bucketUnreadByUser, bucketNotificationCounts, bucketDeadLetters,
+		} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return fmt.Errorf("create bucket %s: %w", name, err)
+			}
+		}
+
+		if backfillUnread {
+			if err := backfillUnreadIndex(tx); err != nil {
+				return fmt.Errorf("build unread index: %w", err)
+			}
+		}
+		if backfillCounts {
+			if err := backfillNotificationCounts(tx); err != nil {
+				return fmt.Errorf("build notification counts: %w", err)
+			}
+		}
+
+		if loadSampleData && tx.Bucket(bucketUsers).Stats().KeyN == 0 {
+			return s.loadSampleData(tx)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Close releases the database file
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// GetUser retrieves a user by ID
+func (s *BoltStore) GetUser(id string) (*models.User, error) {
+	var user *models.User
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		user, err = getBoltUser(tx, id)
+		return err
+	})
+	return user, err
+}
+
+// GetUsers retrieves several users by ID in one transaction
+func (s *BoltStore) GetUsers(ids []string) (map[string]*models.User, error) {
+	users := make(map[string]*models.User, len(ids))
+	err := s.db.View(func(tx *bolt.Tx) error {
+		for _, id := range ids {
+			user, err := getBoltUser(tx, id)
+			if errors.Is(err, ErrUserNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			users[id] = user
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetAllUsers returns all users
+func (s *BoltStore) GetAllUsers() []*models.User {
+	users := make([]*models.User, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketUsers).ForEach(func(k, _ []byte) error {
+			user, err := getBoltUser(tx, string(k))
+			if err != nil {
+				return err
+			}
+			users = append(users, user)
+			return nil
+		})
+	})
+	if err != nil {
+		log.Printf("Failed to list users: %v", err)
+	}
+	return users
+}
+
+// GetFollowers returns all followers for a user
+func (s *BoltStore) GetFollowers(userID string) ([]*models.User, error) {
+	var followers []*models.User
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketUsers).Get([]byte(userID)) == nil {
+			return ErrUserNotFound
+		}
+
+		ids := edgeIDs(tx.Bucket(bucketFollowers), userID)
+		followers = make([]*models.User, 0, len(ids))
+		for _, id := range ids {
+			follower, err := getBoltUser(tx, id)
+			if err == ErrUserNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			followers = append(followers, follower)
+		}
+		return nil
+	})
+	return followers, err
+}
+
+// ListUsers returns up to limit users with IDs after afterID, ordered by ID
+func (s *BoltStore) ListUsers(afterID string, limit int) ([]*models.User, error) {
+	users := make([]*models.User, 0, limit)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketUsers).Cursor()
+		k, _ := c.Seek([]byte(afterID))
+		if k != nil && string(k) == afterID {
+			k, _ = c.Next()
+		}
+		for ; k != nil && len(users) < limit; k, _ = c.Next() {
+			user, err := getBoltUser(tx, string(k))
+			if err != nil {
+				return err
+			}
+			users = append(users, user)
+		}
+		return nil
+	})
+	return users, err
+}
+
+// CreateUser adds a new user with no follow edges
+func (s *BoltStore) CreateUser(user *models.User) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		users := tx.Bucket(bucketUsers)
+		if users.Get([]byte(user.ID)) != nil {
+			return ErrUserExists
+		}
+		return putJSON(users, []byte(user.ID), &boltUser{ID: user.ID, Username: user.Username})
+	})
+}
+
+// UpdateUser updates a user's username
+func (s *BoltStore) UpdateUser(user *models.User) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := checkBoltUsers(tx, user.ID); err != nil {
+			return err
+		}
+		return putJSON(tx.Bucket(bucketUsers), []byte(user.ID), &boltUser{ID: user.ID, Username: user.Username})
+	})
+}
+
+// DeleteUser removes a user, every follow edge they are part of and the
+// notifications they received in one transaction. Posts and notifications
+// they authored are kept with the author cleared; there is no author index,
+// so that part scans both buckets.
+func (s *BoltStore) DeleteUser(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := checkBoltUsers(tx, id); err != nil {
+			return err
+		}
+
+		followers, following := tx.Bucket(bucketFollowers), tx.Bucket(bucketFollowing)
+		for _, followerID := range edgeIDs(followers, id) {
+			if err := followers.Delete(edgeKey(id, followerID)); err != nil {
+				return err
+			}
+			if err := following.Delete(edgeKey(followerID, id)); err != nil {
+				return err
+			}
+		}
+		for _, followeeID := range edgeIDs(following, id) {
+			if err := following.Delete(edgeKey(id, followeeID)); err != nil {
+				return err
+			}
+			if err := followers.Delete(edgeKey(followeeID, id)); err != nil {
+				return err
+			}
+		}
+		if err := tx.Bucket(bucketUsers).Delete([]byte(id)); err != nil {
+			return err
+		}
+
+		if err := deleteBoltUserNotifications(tx, id); err != nil {
+			return err
+		}
+		if err := deleteBoltUserDeadLetters(tx, id); err != nil {
+			return err
+		}
+		return anonymizeBoltAuthor(tx, id)
+	})
+}
+
+// Follow makes followerID follow followeeID by adding the edge to both
+// directional buckets. Putting an existing key is a no-op, so it is idempotent.
+func (s *BoltStore) Follow(followerID, followeeID string) error {
+	if followerID == followeeID {
+		return ErrSelfFollow
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := checkBoltUsers(tx, followerID, followeeID); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketFollowers).Put(edgeKey(followeeID, followerID), []byte{}); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketFollowing).Put(edgeKey(followerID, followeeID), []byte{})
+	})
+}
+
+// Unfollow removes the edge from followerID to followeeID, if there is one
+func (s *BoltStore) Unfollow(followerID, followeeID string) error {
+	if followerID == followeeID {
+		return ErrSelfFollow
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := checkBoltUsers(tx, followerID, followeeID); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketFollowers).Delete(edgeKey(followeeID, followerID)); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketFollowing).Delete(edgeKey(followerID, followeeID))
+	})
+}
+
+// SavePost stores a new post
+func (s *BoltStore) SavePost(post *models.Post) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(bucketPosts), []byte(post.ID), post)
+	})
+}
+
+// GetPost retrieves a post by ID
+func (s *BoltStore) GetPost(id string) (*models.Post, error) {
+	var post *models.Post
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketPosts).Get([]byte(id))
+		if data == nil {
+			return ErrPostNotFound
+		}
+		post = &models.Post{}
+		return json.Unmarshal(data, post)
+	})
+	return post, err
+}
+
+// GetPosts retrieves several posts by ID in one transaction
+func (s *BoltStore) GetPosts(ids []string) (map[string]*models.Post, error) {
+	posts := make(map[string]*models.Post, len(ids))
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketPosts)
+		for _, id := range ids {
+			data := b.Get([]byte(id))
+			if data == nil {
+				continue
+			}
+			post := &models.Post{}
+			if err := json.Unmarshal(data, post); err != nil {
+				return err
+			}
+			posts[id] = post
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// SaveNotification adds a notification for a user
+func (s *BoltStore) SaveNotification(notification *models.Notification) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return saveBoltNotification(tx, notification)
+	})
+}
+
+// SavePostWithNotifications stores a post and its notifications in one transaction
+func (s *BoltStore) SavePostWithNotifications(post *models.Post, notifications []*models.Notification) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := putJSON(tx.Bucket(bucketPosts), []byte(post.ID), post); err != nil {
+			return err
+		}
+		for _, notification := range notifications {
+			if err := saveBoltNotification(tx, notification); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateNotification updates a notification's delivery status, attempts,
+// delivery history, delivery time and next attempt time
+func (s *BoltStore) UpdateNotification(notification *models.Notification) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		stored, err := getBoltNotification(tx, notification.ID)
+		if err != nil {
+			return err
+		}
+
+		pending := tx.Bucket(bucketPendingByTime)
+		timeKey := timeIndexKey(stored.CreatedAt, stored.Seq)
+		if notification.Status.IsTerminal() {
+			if err := pending.Delete(timeKey); err != nil {
+				return err
+			}
+		} else if err := pending.Put(timeKey, []byte(notification.ID)); err != nil {
+			return err
+		}
+
+		if stored.Status != notification.Status {
+			previous := stored.Status
+			err := updateBoltCounts(tx, stored.UserID, func(counts *NotificationCounts) {
+				counts.add(previous, -1)
+				counts.add(notification.Status, 1)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		stored.Status = notification.Status
+		stored.Attempts = notification.Attempts
+		stored.DeliveryAttempts = notification.DeliveryAttempts
+		stored.NextAttemptAt = notification.NextAttemptAt
+		stored.DeliveredAt = notification.DeliveredAt
+		return putJSON(tx.Bucket(bucketNotifications), []byte(notification.ID), stored)
+	})
+}
+
+// GetNotification retrieves a notification by ID
+func (s *BoltStore) GetNotification(id string) (*models.Notification, error) {
+	var notification *models.Notification
+	err := s.db.View(func(tx *bolt.Tx) error {
+		stored, err := getBoltNotification(tx, id)
+		if err != nil {
+			return err
+		}
+		notification = stored.Notification
+		return nil
+	})
+	return notification, err
+}
+
+// MarkNotificationsRead marks the given notifications read in one transaction
+func (s *BoltStore) MarkNotificationsRead(ids []string) ([]*models.Notification, error) {
+	result := make([]*models.Notification, 0, len(ids))
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, id := range ids {
+			stored, err := getBoltNotification(tx, id)
+			if err != nil {
+				return err
+			}
+			if err := markBoltNotificationRead(tx, stored); err != nil {
+				return err
+			}
+			result = append(result, stored.Notification)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MarkAllNotificationsRead marks a user's unread notifications created before
+// the given time read
+func (s *BoltStore) MarkAllNotificationsRead(userID string, before time.Time) ([]*models.Notification, error) {
+	result := make([]*models.Notification, 0)
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		// The unread index is in creation order, so the walk stops at before.
+		// IDs are collected first because marking deletes from the index.
+		prefix := userIndexPrefix(userID)
+		upper := userIndexKey(userID, before, 0)
+		var ids []string
+		c := tx.Bucket(bucketUnreadByUser).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix) && bytes.Compare(k, upper) < 0; k, v = c.Next() {
+			ids = append(ids, string(v))
+		}
+
+		for _, id := range ids {
+			stored, err := getBoltNotification(tx, id)
+			if err != nil {
+				return err
+			}
+			if err := markBoltNotificationRead(tx, stored); err != nil {
+				return err
+			}
+			result = append(result, stored.Notification)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CountUnreadNotifications returns the number of unread notifications for a user
+func (s *BoltStore) CountUnreadNotifications(userID string) (int, error) {
+	counts, err := s.GetNotificationCounts(userID)
+	if err != nil {
+		return 0, err
+	}
+	return counts.Unread, nil
+}
+
+// GetNotificationCounts returns a user's notification counters
+func (s *BoltStore) GetNotificationCounts(userID string) (*NotificationCounts, error) {
+	var counts *NotificationCounts
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		counts, err = getBoltCounts(tx, userID)
+		return err
+	})
+	return counts, err
+}
+
+// GetUserNotifications returns notifications for a user
+func (s *BoltStore) GetUserNotifications(userID string, limit int) ([]*models.Notification, error) {
+	result := make([]*models.Notification, 0, limit)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		prefix := userIndexPrefix(userID)
+		c := tx.Bucket(bucketNotificationsByUser).Cursor()
+
+		// Walk the user's index range backwards from its upper bound so only
+		// the returned entries are visited
+		k, v := seekLast(c, prefix)
+		for ; k != nil && bytes.HasPrefix(k, prefix) && len(result) < limit; k, v = c.Prev() {
+			stored, err := getBoltNotification(tx, string(v))
+			if err != nil {
+				return err
+			}
+			result = append(result, stored.Notification)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// QueryNotifications returns a page of a user's notifications. Cursors are
+// resolved to their index keys, so the page is a range scan of the user's
+// index that skips notifications failing the filter.
+func (s *BoltStore) QueryNotifications(query NotificationQuery) (*NotificationPage, error) {
+	page := &NotificationPage{Notifications: make([]*models.Notification, 0)}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var afterKey, beforeKey []byte
+		if query.After != "" {
+			key, err := boltCursorKey(tx, query.UserID, query.After)
+			if err != nil {
+				return err
+			}
+			afterKey = key
+		}
+		if query.Before != "" {
+			key, err := boltCursorKey(tx, query.UserID, query.Before)
+			if err != nil {
+				return err
+			}
+			beforeKey = key
+		}
+
+		w := &boltWalk{
+			tx:     tx,
+			c:      tx.Bucket(bucketNotificationsByUser).Cursor(),
+			prefix: userIndexPrefix(query.UserID),
+			desc:   query.Order == NewestFirst,
+			filter: &query.Filter,
+		}
+		inRange := func(k []byte) bool {
+			return k != nil && bytes.HasPrefix(k, w.prefix) &&
+				(afterKey == nil || w.compare(k, afterKey) > 0) &&
+				(beforeKey == nil || w.compare(k, beforeKey) < 0)
+		}
+
+		var keys [][]byte
+		if query.Last > 0 && query.First == 0 {
+			// Only the end of the range is wanted, so walk back from it
+			k, v := w.last()
+			if beforeKey != nil {
+				k, v = w.seekBefore(beforeKey)
+			}
+			for ; inRange(k) && len(keys) < query.Last; k, v = w.prev() {
+				n, err := w.match(v)
+				if err != nil {
+					return err
+				}
+				if n != nil {
+					keys = append(keys, k)
+					page.Notifications = append(page.Notifications, n)
+				}
+			}
+			for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+				keys[i], keys[j] = keys[j], keys[i]
+				page.Notifications[i], page.Notifications[j] = page.Notifications[j], page.Notifications[i]
+			}
+		} else {
+			k, v := w.first()
+			if afterKey != nil {
+				k, v = w.seekAfter(afterKey)
+			}
+			for ; inRange(k) && (query.First == 0 || len(keys) < query.First); k, v = w.next() {
+				n, err := w.match(v)
+				if err != nil {
+					return err
+				}
+				if n != nil {
+					keys = append(keys, k)
+					page.Notifications = append(page.Notifications, n)
+				}
+			}
+			if query.Last > 0 && len(keys) > query.Last {
+				keys = keys[len(keys)-query.Last:]
+				page.Notifications = page.Notifications[len(page.Notifications)-query.Last:]
+			}
+		}
+
+		var err error
+		switch {
+		case len(keys) > 0:
+			k, v := w.seekBefore(keys[0])
+			if page.HasPrevious, err = w.anyMatch(k, v, w.prev); err != nil {
+				return err
+			}
+			k, v = w.seekAfter(keys[len(keys)-1])
+			page.HasNext, err = w.anyMatch(k, v, w.next)
+		case afterKey != nil:
+			k, v := w.c.Seek(afterKey)
+			if page.HasPrevious, err = w.anyMatch(k, v, w.prev); err != nil {
+				return err
+			}
+			k, v = w.seekAfter(afterKey)
+			page.HasNext, err = w.anyMatch(k, v, w.next)
+		default:
+			k, v := w.first()
+			page.HasNext, err = w.anyMatch(k, v, w.next)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// GetPendingNotifications returns all queued or retrying notifications, oldest first
+func (s *BoltStore) GetPendingNotifications() ([]*models.Notification, error) {
+	pending := make([]*models.Notification, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketPendingByTime).ForEach(func(_, v []byte) error {
+			stored, err := getBoltNotification(tx, string(v))
+			if err != nil {
+				return err
+			}
+			pending = append(pending, stored.Notification)
+			return nil
+		})
+	})
+	return pending, err
+}
+
+// SaveDeadLetter stores a dead letter, replacing the one recorded for the
+// same notification
+func (s *BoltStore) SaveDeadLetter(deadLetter *models.DeadLetter) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(bucketDeadLetters), []byte(deadLetter.NotificationID), deadLetter)
+	})
+}
+
+// GetDeadLetter retrieves the dead letter of a notification
+func (s *BoltStore) GetDeadLetter(notificationID string) (*models.DeadLetter, error) {
+	var deadLetter *models.DeadLetter
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketDeadLetters).Get([]byte(notificationID))
+		if data == nil {
+			return ErrDeadLetterNotFound
+		}
+		deadLetter = &models.DeadLetter{}
+		return json.Unmarshal(data, deadLetter)
+	})
+	return deadLetter, err
+}
+
+// ListDeadLetters returns up to limit dead letters that pass filter, most
+// recently dead first. Dead letters are not indexed, so it scans them all.
+func (s *BoltStore) ListDeadLetters(filter DeadLetterFilter, limit int) ([]*models.DeadLetter, error) {
+	deadLetters := make([]*models.DeadLetter, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketDeadLetters).ForEach(func(_, v []byte) error {
+			deadLetter := &models.DeadLetter{}
+			if err := json.Unmarshal(v, deadLetter); err != nil {
+				return err
+			}
+			if filter.Match(deadLetter) {
+				deadLetters = append(deadLetters, deadLetter)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sortDeadLetters(deadLetters, limit), nil
+}
+
+// DeleteDeadLetters removes the dead letters of the given notifications in
+// one transaction
+func (s *BoltStore) DeleteDeadLetters(notificationIDs []string) (int, error) {
+	deleted := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketDeadLetters)
+		for _, id := range notificationIDs {
+			if b.Get([]byte(id)) == nil {
+				continue
+			}
+			if err := b.Delete([]byte(id)); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// loadSampleData populates the database with sample data
+func (s *BoltStore) loadSampleData(tx *bolt.Tx) error {
+	users, posts := SampleData()
+
+	for _, user := range users {
+		if err := putJSON(tx.Bucket(bucketUsers), []byte(user.ID), &boltUser{ID: user.ID, Username: user.Username}); err != nil {
+			return err
+		}
+	}
+	for _, user := range users {
+		for _, followerID := range user.FollowerIDs {
+			if err := tx.Bucket(bucketFollowers).Put(edgeKey(user.ID, followerID), []byte{}); err != nil {
+				return err
+			}
+			if err := tx.Bucket(bucketFollowing).Put(edgeKey(followerID, user.ID), []byte{}); err != nil {
+				return err
+			}
+		}
+	}
+	for _, post := range posts {
+		if err := putJSON(tx.Bucket(bucketPosts), []byte(post.ID), post); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveBoltNotification writes a notification and its index entries
+func saveBoltNotification(tx *bolt.Tx, notification *models.Notification) error {
+	notifications := tx.Bucket(bucketNotifications)
+	seq, err := notifications.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	if err := putJSON(notifications, []byte(notification.ID), &boltNotification{Notification: notification, Seq: seq}); err != nil {
+		return err
+	}
+
+	id := []byte(notification.ID)
+	userKey := userIndexKey(notification.UserID, notification.CreatedAt, seq)
+	if err := tx.Bucket(bucketNotificationsByUser).Put(userKey, id); err != nil {
+		return err
+	}
+	if !notification.Read {
+		if err := tx.Bucket(bucketUnreadByUser).Put(userKey, id); err != nil {
+			return err
+		}
+	}
+	if err := updateBoltCounts(tx, notification.UserID, countNotification(notification)); err != nil {
+		return err
+	}
+	if !notification.Status.IsTerminal() {
+		return tx.Bucket(bucketPendingByTime).Put(timeIndexKey(notification.CreatedAt, seq), id)
+	}
+	return nil
+}
+
+// markBoltNotificationRead sets Read on a stored notification and drops it
+// from the unread index
+func markBoltNotificationRead(tx *bolt.Tx, stored *boltNotification) error {
+	if stored.Read {
+		return nil
+	}
+	stored.Read = true
+	userKey := userIndexKey(stored.UserID, stored.CreatedAt, stored.Seq)
+	if err := tx.Bucket(bucketUnreadByUser).Delete(userKey); err != nil {
+		return err
+	}
+	err := updateBoltCounts(tx, stored.UserID, func(counts *NotificationCounts) {
+		counts.Unread--
+	})
+	if err != nil {
+		return err
+	}
+	return putJSON(tx.Bucket(bucketNotifications), []byte(stored.ID), stored)
+}
+
+// deleteBoltUserNotifications removes every notification a user received,
+// along with their index entries
+func deleteBoltUserNotifications(tx *bolt.Tx, userID string) error {
+	byUser := tx.Bucket(bucketNotificationsByUser)
+	prefix := userIndexPrefix(userID)
+
+	// Collect first; deleting under a cursor skips keys
+	var ids []string
+	c := byUser.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		ids = append(ids, string(v))
+	}
+
+	for _, id := range ids {
+		stored, err := getBoltNotification(tx, id)
+		if err != nil {
+			return err
+		}
+		userKey := userIndexKey(stored.UserID, stored.CreatedAt, stored.Seq)
+		for _, del := range []struct {
+			bucket []byte
+			key    []byte
+		}{
+			{bucketNotificationsByUser, userKey},
+			{bucketUnreadByUser, userKey},
+			{bucketPendingByTime, timeIndexKey(stored.CreatedAt, stored.Seq)},
+			{bucketNotifications, []byte(id)},
+		} {
+			if err := tx.Bucket(del.bucket).Delete(del.key); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Bucket(bucketNotificationCounts).Delete([]byte(userID))
+}
+
+// deleteBoltUserDeadLetters removes the dead letters of the notifications a
+// user received
+func deleteBoltUserDeadLetters(tx *bolt.Tx, userID string) error {
+	b := tx.Bucket(bucketDeadLetters)
+
+	// Collect first; deleting under a cursor skips keys
+	var ids [][]byte
+	err := b.ForEach(func(k, v []byte) error {
+		var deadLetter models.DeadLetter
+		if err := json.Unmarshal(v, &deadLetter); err != nil {
+			return err
+		}
+		if deadLetter.UserID == userID {
+			ids = append(ids, append([]byte{}, k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := b.Delete(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// anonymizeBoltAuthor clears the author of every post and notification by userID
+func anonymizeBoltAuthor(tx *bolt.Tx, userID string) error {
+	var notifications []*boltNotification
+	err := tx.Bucket(bucketNotifications).ForEach(func(k, _ []byte) error {
+		stored, err := getBoltNotification(tx, string(k))
+		if err != nil {
+			return err
+		}
+		if stored.AuthorID == userID {
+			notifications = append(notifications, stored)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, stored := range notifications {
+		stored.AuthorID = ""
+		if err := putJSON(tx.Bucket(bucketNotifications), []byte(stored.ID), stored); err != nil {
+			return err
+		}
+	}
+
+	var posts []*models.Post
+	err = tx.Bucket(bucketPosts).ForEach(func(_, v []byte) error {
+		post := &models.Post{}
+		if err := json.Unmarshal(v, post); err != nil {
+			return err
+		}
+		if post.AuthorID == userID {
+			posts = append(posts, post)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		post.AuthorID = ""
+		if err := putJSON(tx.Bucket(bucketPosts), []byte(post.ID), post); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillUnreadIndex adds every unread notification to unread_by_user
+func backfillUnreadIndex(tx *bolt.Tx) error {
+	unread := tx.Bucket(bucketUnreadByUser)
+	return tx.Bucket(bucketNotifications).ForEach(func(k, _ []byte) error {
+		stored, err := getBoltNotification(tx, string(k))
+		if err != nil {
+			return err
+		}
+		if stored.Read {
+			return nil
+		}
+		return unread.Put(userIndexKey(stored.UserID, stored.CreatedAt, stored.Seq), k)
+	})
+}
+
+// backfillNotificationCounts builds every user's counters from their
+// notifications
+func backfillNotificationCounts(tx *bolt.Tx) error {
+	return tx.Bucket(bucketNotifications).ForEach(func(k, _ []byte) error {
+		stored, err := getBoltNotification(tx, string(k))
+		if err != nil {
+			return err
+		}
+		return updateBoltCounts(tx, stored.UserID, countNotification(stored.Notification))
+	})
+}
+
+// countNotification returns the counter update for a newly stored notification
+func countNotification(n *models.Notification) func(*NotificationCounts) {
+	return func(counts *NotificationCounts) {
+		counts.Total++
+		counts.add(n.Status, 1)
+		if !n.Read {
+			counts.Unread++
+		}
+	}
+}
+
+// getBoltCounts returns a user's counters, which are zero until the user
+// receives a notification
+func getBoltCounts(tx *bolt.Tx, userID string) (*NotificationCounts, error) {
+	counts := &NotificationCounts{ByStatus: make(map[models.NotificationStatus]int)}
+	data := tx.Bucket(bucketNotificationCounts).Get([]byte(userID))
+	if data == nil {
+		return counts, nil
+	}
+	if err := json.Unmarshal(data, counts); err != nil {
+		return nil, err
+	}
+	if counts.ByStatus == nil {
+		counts.ByStatus = make(map[models.NotificationStatus]int)
+	}
+	return counts, nil
+}
+
+// updateBoltCounts applies fn to a user's counters
+func updateBoltCounts(tx *bolt.Tx, userID string, fn func(*NotificationCounts)) error {
+	counts, err := getBoltCounts(tx, userID)
+	if err != nil {
+		return err
+	}
+	fn(counts)
+	return putJSON(tx.Bucket(bucketNotificationCounts), []byte(userID), counts)
+}
+
+func checkBoltUsers(tx *bolt.Tx, ids ...string) error {
+	users := tx.Bucket(bucketUsers)
+	for _, id := range ids {
+		if users.Get([]byte(id)) == nil {
+			return ErrUserNotFound
+		}
+	}
+	return nil
+}
+
+func getBoltUser(tx *bolt.Tx, id string) (*models.User, error) {
+	data := tx.Bucket(bucketUsers).Get([]byte(id))
+	if data == nil {
+		return nil, ErrUserNotFound
+	}
+
+	var stored boltUser
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	return &models.User{
+		ID:           stored.ID,
+		Username:     stored.Username,
+		FollowerIDs:  edgeIDs(tx.Bucket(bucketFollowers), id),
+		FollowingIDs: edgeIDs(tx.Bucket(bucketFollowing), id),
+	}, nil
+}
+
+func getBoltNotification(tx *bolt.Tx, id string) (*boltNotification, error) {
+	data := tx.Bucket(bucketNotifications).Get([]byte(id))
+	if data == nil {
+		return nil, ErrNotificationNotFound
+	}
+
+	stored := &boltNotification{Notification: &models.Notification{}}
+	if err := json.Unmarshal(data, stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// boltCursorKey returns the notifications_by_user key of one of a user's
+// notifications
+func boltCursorKey(tx *bolt.Tx, userID, id string) ([]byte, error) {
+	stored, err := getBoltNotification(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if stored.UserID != userID {
+		return nil, ErrNotificationNotFound
+	}
+	return userIndexKey(userID, stored.CreatedAt, stored.Seq), nil
+}
+
+// boltWalk moves over one user's notifications_by_user entries in the order
+// of a NotificationQuery
+type boltWalk struct {
+	tx     *bolt.Tx
+	c      *bolt.Cursor
+	prefix []byte
+	desc   bool
+	filter *NotificationFilter
+}
+
+// compare compares two index keys in walk order
+func (w *boltWalk) compare(a, b []byte) int {
+	if w.desc {
+		return bytes.Compare(b, a)
+	}
+	return bytes.Compare(a, b)
+}
+
+func (w *boltWalk) first() ([]byte, []byte) {
+	if w.desc {
+		return seekLast(w.c, w.prefix)
+	}
+	return w.c.Seek(w.prefix)
+}
+
+func (w *boltWalk) last() ([]byte, []byte) {
+	if w.desc {
+		return w.c.Seek(w.prefix)
+	}
+	return seekLast(w.c, w.prefix)
+}
+
+func (w *boltWalk) next() ([]byte, []byte) {
+	if w.desc {
+		return w.c.Prev()
+	}
+	return w.c.Next()
+}
+
+func (w *boltWalk) prev() ([]byte, []byte) {
+	if w.desc {
+		return w.c.Next()
+	}
+	return w.c.Prev()
+}
+
+// seekAfter positions the walk on the entry following key, which must exist
+func (w *boltWalk) seekAfter(key []byte) ([]byte, []byte) {
+	w.c.Seek(key)
+	return w.next()
+}
+
+// seekBefore positions the walk on the entry preceding key, which must exist
+func (w *boltWalk) seekBefore(key []byte) ([]byte, []byte) {
+	w.c.Seek(key)
+	return w.prev()
+}
+
+// match loads the notification an index entry points at, or returns nil if
+// it fails the filter
+func (w *boltWalk) match(id []byte) (*models.Notification, error) {
+	stored, err := getBoltNotification(w.tx, string(id))
+	if err != nil {
+		return nil, err
+	}
+	if !w.filter.Match(stored.Notification) {
+		return nil, nil
+	}
+	return stored.Notification, nil
+}
+
+// anyMatch reports whether a matching entry is found stepping from k with step
+func (w *boltWalk) anyMatch(k, v []byte, step func() ([]byte, []byte)) (bool, error) {
+	for ; k != nil && bytes.HasPrefix(k, w.prefix); k, v = step() {
+		n, err := w.match(v)
+		if err != nil || n != nil {
+			return n != nil, err
+		}
+	}
+	return false, nil
+}
+
+func putJSON(b *bolt.Bucket, key []byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, data)
+}
+
+// edgeKey is owner ID, 0x00, other ID
+func edgeKey(ownerID, otherID string) []byte {
+	key := make([]byte, 0, len(ownerID)+1+len(otherID))
+	key = append(key, ownerID...)
+	key = append(key, 0)
+	return append(key, otherID...)
+}
+
+// edgeIDs returns the other side of every edge owned by ownerID
+func edgeIDs(b *bolt.Bucket, ownerID string) []string {
+	prefix := edgeKey(ownerID, "")
+	ids := make([]string, 0)
+	c := b.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		ids = append(ids, string(k[len(prefix):]))
+	}
+	return ids
+}
+
+// userIndexPrefix is user ID, 0x00
+func userIndexPrefix(userID string) []byte {
+	return edgeKey(userID, "")
+}
+
+// userIndexKey is user ID, 0x00, big-endian creation time, big-endian sequence
+func userIndexKey(userID string, createdAt time.Time, seq uint64) []byte {
+	return append(userIndexPrefix(userID), timeIndexKey(createdAt, seq)...)
+}
+
+// timeIndexKey is big-endian creation time followed by big-endian sequence
+func timeIndexKey(createdAt time.Time, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(createdAt.UnixNano()))
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
+
+// seekLast positions c on the last key that starts with prefix, which must
+// end in the 0x00 separator
+func seekLast(c *bolt.Cursor, prefix []byte) ([]byte, []byte) {
+	upper := append([]byte{}, prefix...)
+	upper[len(upper)-1] = 0x01
+	if k, _ := c.Seek(upper); k == nil {
+		return c.Last()
+	}
+	return c.Prev()
+}
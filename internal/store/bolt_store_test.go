@@ -0,0 +1,30 @@
+package store_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/suyashXD/DNDS/internal/store"
+	"github.com/suyashXD/DNDS/internal/store/storetest"
+)
+
+func openBoltStore(t *testing.T, dir string) store.Store {
+	t.Helper()
+	s, err := store.NewBoltStore(filepath.Join(dir, "dnds.db"), true)
+	if err != nil {
+		t.Fatalf("NewBoltStore: %v", err)
+	}
+	return s
+}
+
+func TestBoltStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		s := openBoltStore(t, t.TempDir())
+		t.Cleanup(func() { s.(*store.BoltStore).Close() })
+		return s
+	})
+}
+
+func TestBoltStorePersistence(t *testing.T) {
+	storetest.RunPersistence(t, openBoltStore)
+}
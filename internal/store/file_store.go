@@ -0,0 +1,573 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+const (
+	walFileName      = "wal.log"
+	snapshotFileName = "snapshot.json"
+
+	// defaultSnapshotEvery is the number of WAL records after which the log is
+	// compacted into a fresh snapshot
+	defaultSnapshotEvery = 1000
+)
+
+// ErrCorruptWAL is returned when opening a FileStore whose write-ahead log has
+// an unreadable record before its end. Only an incomplete final record, as left
+// by a torn write, is discarded on its own.
+var ErrCorruptWAL = errors.New("corrupt wal")
+
+// WAL operations
+const (
+	opSavePost           = "save_post"
+	opSaveNotification   = "save_notification"
+	opPublishPost        = "publish_post"
+	opUpdateNotification = "update_notification"
+	opMarkRead           = "mark_read"
+	opFollow             = "follow"
+	opUnfollow           = "unfollow"
+	opCreateUser         = "create_user"
+	opUpdateUser         = "update_user"
+	opDeleteUser         = "delete_user"
+	opSaveDeadLetter     = "save_dead_letter"
+	opDeleteDeadLetters  = "delete_dead_letters"
+)
+
+// walRecord is a single line of the write-ahead log
+type walRecord struct {
+	Seq           uint64                 `json:"seq"`
+	Op            string                 `json:"op"`
+	User          *models.User           `json:"user,omitempty"`
+	Post          *models.Post           `json:"post,omitempty"`
+	Notification  *models.Notification   `json:"notification,omitempty"`
+	Notifications []*models.Notification `json:"notifications,omitempty"`
+	IDs           []string               `json:"ids,omitempty"`
+	FollowerID    string                 `json:"follower_id,omitempty"`
+	FolloweeID    string                 `json:"followee_id,omitempty"`
+	UserID        string                 `json:"user_id,omitempty"`
+	DeadLetter    *models.DeadLetter     `json:"dead_letter,omitempty"`
+}
+
+// snapshot is the compacted state of the store at WAL sequence Seq
+type snapshot struct {
+	Seq           uint64                 `json:"seq"`
+	Users         []*models.User         `json:"users"`
+	Posts         []*models.Post         `json:"posts"`
+	Notifications []*models.Notification `json:"notifications"`
+	DeadLetters   []*models.DeadLetter   `json:"dead_letters"`
+}
+
+// FileStore is a durable store that keeps its working set in a MemoryStore and
+// records every write in an on-disk write-ahead log. The log is periodically
+// compacted into a snapshot, and both are replayed when the store is opened.
+type FileStore struct {
+	*MemoryStore
+
+	dir           string
+	wal           *os.File
+	seq           uint64
+	walRecords    int
+	snapshotEvery int
+	mu            sync.Mutex // serializes WAL appends and snapshots
+}
+
+// NewFileStore opens (or creates) a file-backed store in dir. Sample data is
+// only loaded when the directory holds no previous state.
+func NewFileStore(dir string, loadSampleData bool) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	fs := &FileStore{
+		MemoryStore:   NewMemoryStore(false),
+		dir:           dir,
+		snapshotEvery: defaultSnapshotEvery,
+	}
+
+	hasSnapshot, err := fs.loadSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	walSize, err := fs.replayWAL()
+	if err != nil {
+		return nil, err
+	}
+
+	fs.wal, err = os.OpenFile(fs.path(walFileName), os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open wal: %w", err)
+	}
+	// Drop an incomplete final record left by a torn write
+	if err := fs.wal.Truncate(walSize); err != nil {
+		fs.wal.Close()
+		return nil, fmt.Errorf("truncate wal: %w", err)
+	}
+	if _, err := fs.wal.Seek(walSize, io.SeekStart); err != nil {
+		fs.wal.Close()
+		return nil, fmt.Errorf("seek wal: %w", err)
+	}
+
+	if !hasSnapshot && fs.seq == 0 && loadSampleData {
+		fs.MemoryStore.loadSampleData()
+		if err := fs.Snapshot(); err != nil {
+			fs.wal.Close()
+			return nil, err
+		}
+	}
+
+	return fs, nil
+}
+
+// SavePost stores a new post
+func (fs *FileStore) SavePost(post *models.Post) error {
+	return fs.apply(&walRecord{Op: opSavePost, Post: post})
+}
+
+// SaveNotification adds a notification for a user
+func (fs *FileStore) SaveNotification(notification *models.Notification) error {
+	return fs.apply(&walRecord{Op: opSaveNotification, Notification: notification})
+}
+
+// SavePostWithNotifications stores a post and its notifications as a single
+// WAL record, so a crash can never leave half of a fan-out behind
+func (fs *FileStore) SavePostWithNotifications(post *models.Post, notifications []*models.Notification) error {
+	return fs.apply(&walRecord{Op: opPublishPost, Post: post, Notifications: notifications})
+}
+
+// UpdateNotification updates a notification's status
+func (fs *FileStore) UpdateNotification(notification *models.Notification) error {
+	return fs.apply(&walRecord{Op: opUpdateNotification, Notification: notification})
+}
+
+// MarkNotificationsRead marks the given notifications read
+func (fs *FileStore) MarkNotificationsRead(ids []string) ([]*models.Notification, error) {
+	if err := fs.apply(&walRecord{Op: opMarkRead, IDs: ids}); err != nil {
+		return nil, err
+	}
+	return fs.getNotifications(ids)
+}
+
+// MarkAllNotificationsRead marks a user's unread notifications created before
+// the given time read. The matching IDs are resolved up front and logged as a
+// plain mark_read record, so replay does not depend on the time of replay.
+func (fs *FileStore) MarkAllNotificationsRead(userID string, before time.Time) ([]*models.Notification, error) {
+	ids := fs.unreadBefore(userID, before)
+	if len(ids) == 0 {
+		return []*models.Notification{}, nil
+	}
+	return fs.MarkNotificationsRead(ids)
+}
+
+// CreateUser adds a new user with no follow edges
+func (fs *FileStore) CreateUser(user *models.User) error {
+	return fs.apply(&walRecord{Op: opCreateUser, User: user})
+}
+
+// UpdateUser updates a user's username
+func (fs *FileStore) UpdateUser(user *models.User) error {
+	return fs.apply(&walRecord{Op: opUpdateUser, User: user})
+}
+
+// DeleteUser removes a user along with their edges and notifications
+func (fs *FileStore) DeleteUser(id string) error {
+	return fs.apply(&walRecord{Op: opDeleteUser, UserID: id})
+}
+
+// Follow makes followerID follow followeeID
+func (fs *FileStore) Follow(followerID, followeeID string) error {
+	return fs.apply(&walRecord{Op: opFollow, FollowerID: followerID, FolloweeID: followeeID})
+}
+
+// Unfollow removes the edge from followerID to followeeID
+func (fs *FileStore) Unfollow(followerID, followeeID string) error {
+	return fs.apply(&walRecord{Op: opUnfollow, FollowerID: followerID, FolloweeID: followeeID})
+}
+
+// SaveDeadLetter stores a dead letter, replacing the one recorded for the
+// same notification
+func (fs *FileStore) SaveDeadLetter(deadLetter *models.DeadLetter) error {
+	return fs.apply(&walRecord{Op: opSaveDeadLetter, DeadLetter: deadLetter})
+}
+
+// DeleteDeadLetters removes the dead letters of the given notifications. The
+// IDs that exist are resolved up front, so the record only holds those.
+func (fs *FileStore) DeleteDeadLetters(notificationIDs []string) (int, error) {
+	ids := fs.existingDeadLetters(notificationIDs)
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := fs.apply(&walRecord{Op: opDeleteDeadLetters, IDs: ids}); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// Snapshot writes the current state to disk and truncates the WAL
+func (fs *FileStore) Snapshot() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return fs.snapshotLocked()
+}
+
+// Close flushes the WAL and releases the underlying file
+func (fs *FileStore) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.wal == nil {
+		return nil
+	}
+	err := fs.wal.Sync()
+	if closeErr := fs.wal.Close(); err == nil {
+		err = closeErr
+	}
+	fs.wal = nil
+	return err
+}
+
+// apply appends a record to the WAL and then applies it to the in-memory state
+func (fs *FileStore) apply(rec *walRecord) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.wal == nil {
+		return errors.New("file store is closed")
+	}
+
+	// Validate before logging so the WAL never holds a record that fails on replay
+	switch rec.Op {
+	case opUpdateNotification:
+		if err := fs.checkNotificationsExist(rec.Notification.ID); err != nil {
+			return err
+		}
+	case opMarkRead:
+		if err := fs.checkNotificationsExist(rec.IDs...); err != nil {
+			return err
+		}
+	case opFollow, opUnfollow:
+		if err := fs.checkEdge(rec.FollowerID, rec.FolloweeID); err != nil {
+			return err
+		}
+	case opCreateUser:
+		if fs.userExists(rec.User.ID) {
+			return ErrUserExists
+		}
+	case opUpdateUser, opDeleteUser:
+		id := rec.UserID
+		if rec.User != nil {
+			id = rec.User.ID
+		}
+		if !fs.userExists(id) {
+			return ErrUserNotFound
+		}
+	}
+
+	rec.Seq = fs.seq + 1
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encode wal record: %w", err)
+	}
+	line = append(line, '\n')
+
+	if _, err := fs.wal.Write(line); err != nil {
+		return fmt.Errorf("append wal: %w", err)
+	}
+	if err := fs.wal.Sync(); err != nil {
+		return fmt.Errorf("sync wal: %w", err)
+	}
+	fs.seq = rec.Seq
+	fs.walRecords++
+
+	if err := fs.applyRecord(rec); err != nil {
+		return err
+	}
+
+	if fs.walRecords >= fs.snapshotEvery {
+		if err := fs.snapshotLocked(); err != nil {
+			// The WAL still holds everything, so a failed compaction is not fatal
+			log.Printf("Failed to compact wal: %v", err)
+		}
+	}
+	return nil
+}
+
+// applyRecord applies a WAL record to the in-memory state
+func (fs *FileStore) applyRecord(rec *walRecord) error {
+	switch rec.Op {
+	case opSavePost:
+		return fs.MemoryStore.SavePost(rec.Post)
+	case opSaveNotification:
+		return fs.MemoryStore.SaveNotification(rec.Notification)
+	case opPublishPost:
+		return fs.MemoryStore.SavePostWithNotifications(rec.Post, rec.Notifications)
+	case opUpdateNotification:
+		return fs.MemoryStore.UpdateNotification(rec.Notification)
+	case opMarkRead:
+		_, err := fs.MemoryStore.MarkNotificationsRead(rec.IDs)
+		return err
+	case opFollow:
+		return fs.MemoryStore.Follow(rec.FollowerID, rec.FolloweeID)
+	case opUnfollow:
+		return fs.MemoryStore.Unfollow(rec.FollowerID, rec.FolloweeID)
+	case opCreateUser:
+		return fs.MemoryStore.CreateUser(rec.User)
+	case opUpdateUser:
+		return fs.MemoryStore.UpdateUser(rec.User)
+	case opDeleteUser:
+		return fs.MemoryStore.DeleteUser(rec.UserID)
+	case opSaveDeadLetter:
+		return fs.MemoryStore.SaveDeadLetter(rec.DeadLetter)
+	case opDeleteDeadLetters:
+		_, err := fs.MemoryStore.DeleteDeadLetters(rec.IDs)
+		return err
+	default:
+		return fmt.Errorf("unknown wal operation %q", rec.Op)
+	}
+}
+
+// checkNotificationsExist reports the error applying an update to ids would return
+func (fs *FileStore) checkNotificationsExist(ids ...string) error {
+	fs.MemoryStore.mu.RLock()
+	defer fs.MemoryStore.mu.RUnlock()
+
+	for _, id := range ids {
+		if _, exists := fs.MemoryStore.byID[id]; !exists {
+			return ErrNotificationNotFound
+		}
+	}
+	return nil
+}
+
+func (fs *FileStore) userExists(id string) bool {
+	fs.MemoryStore.mu.RLock()
+	defer fs.MemoryStore.mu.RUnlock()
+
+	_, exists := fs.MemoryStore.users[id]
+	return exists
+}
+
+// checkEdge reports the error following or unfollowing would return
+func (fs *FileStore) checkEdge(followerID, followeeID string) error {
+	if followerID == followeeID {
+		return ErrSelfFollow
+	}
+
+	fs.MemoryStore.mu.RLock()
+	defer fs.MemoryStore.mu.RUnlock()
+
+	_, _, err := fs.MemoryStore.edgeUsers(followerID, followeeID)
+	return err
+}
+
+// unreadBefore returns the IDs of a user's unread notifications created before t
+func (fs *FileStore) unreadBefore(userID string, t time.Time) []string {
+	fs.MemoryStore.mu.RLock()
+	defer fs.MemoryStore.mu.RUnlock()
+
+	var ids []string
+	for _, n := range fs.MemoryStore.notifications[userID] {
+		if !n.Read && n.CreatedAt.Before(t) {
+			ids = append(ids, n.ID)
+		}
+	}
+	return ids
+}
+
+// existingDeadLetters returns the IDs that have a dead letter
+func (fs *FileStore) existingDeadLetters(notificationIDs []string) []string {
+	fs.MemoryStore.mu.RLock()
+	defer fs.MemoryStore.mu.RUnlock()
+
+	var ids []string
+	for _, id := range notificationIDs {
+		if _, exists := fs.MemoryStore.deadLetters[id]; exists {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// getNotifications returns copies of the notifications with the given IDs
+func (fs *FileStore) getNotifications(ids []string) ([]*models.Notification, error) {
+	result := make([]*models.Notification, 0, len(ids))
+	for _, id := range ids {
+		n, err := fs.MemoryStore.GetNotification(id)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
+// snapshotLocked writes a snapshot atomically and resets the WAL.
+// fs.mu must be held.
+func (fs *FileStore) snapshotLocked() error {
+	snap := fs.capture()
+
+	tmp, err := os.CreateTemp(fs.dir, snapshotFileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := json.NewEncoder(tmp).Encode(snap); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), fs.path(snapshotFileName)); err != nil {
+		return fmt.Errorf("install snapshot: %w", err)
+	}
+
+	// Records up to snap.Seq are now covered by the snapshot, and replay skips
+	// them even if we crash before the truncate below
+	if err := fs.wal.Truncate(0); err != nil {
+		return fmt.Errorf("truncate wal: %w", err)
+	}
+	if _, err := fs.wal.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("seek wal: %w", err)
+	}
+	fs.walRecords = 0
+	return nil
+}
+
+// capture copies the in-memory state into a snapshot
+func (fs *FileStore) capture() *snapshot {
+	m := fs.MemoryStore
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snap := &snapshot{
+		Seq:           fs.seq,
+		Users:         make([]*models.User, 0, len(m.users)),
+		Posts:         make([]*models.Post, 0, len(m.posts)),
+		Notifications: make([]*models.Notification, 0),
+		DeadLetters:   make([]*models.DeadLetter, 0, len(m.deadLetters)),
+	}
+	for _, user := range m.users {
+		snap.Users = append(snap.Users, copyUser(user))
+	}
+	for _, post := range m.posts {
+		snap.Posts = append(snap.Posts, post)
+	}
+	// Per-user slices are written in order so replay preserves recency.
+	// Notifications are copied because they are updated in place.
+	for _, notifications := range m.notifications {
+		for _, n := range notifications {
+			snap.Notifications = append(snap.Notifications, copyNotification(n))
+		}
+	}
+	for _, deadLetter := range m.deadLetters {
+		snap.DeadLetters = append(snap.DeadLetters, copyDeadLetter(deadLetter))
+	}
+	return snap
+}
+
+// loadSnapshot restores the last snapshot, if there is one
+func (fs *FileStore) loadSnapshot() (bool, error) {
+	f, err := os.Open(fs.path(snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	var snap snapshot
+	if err := json.NewDecoder(f).Decode(&snap); err != nil {
+		return false, fmt.Errorf("decode snapshot: %w", err)
+	}
+
+	m := fs.MemoryStore
+	for _, user := range snap.Users {
+		m.users[user.ID] = user
+		if _, ok := m.notifications[user.ID]; !ok {
+			m.notifications[user.ID] = []*models.Notification{}
+		}
+	}
+	// Snapshots written before follows were recorded only carry FollowerIDs,
+	// which has always been the source of truth
+	m.rebuildFollowing()
+	for _, post := range snap.Posts {
+		m.posts[post.ID] = post
+	}
+	for _, n := range snap.Notifications {
+		m.addNotification(n)
+	}
+	for _, deadLetter := range snap.DeadLetters {
+		m.deadLetters[deadLetter.NotificationID] = deadLetter
+	}
+	fs.seq = snap.Seq
+
+	return true, nil
+}
+
+// replayWAL applies every complete WAL record newer than the snapshot and
+// returns the offset just past the last one. A complete record that cannot
+// be parsed fails with ErrCorruptWAL.
+func (fs *FileStore) replayWAL() (int64, error) {
+	f, err := os.Open(fs.path(walFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("open wal: %w", err)
+	}
+	defer f.Close()
+
+	var offset int64
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				log.Printf("Discarding incomplete wal record at offset %d", offset)
+			}
+			return offset, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("read wal: %w", err)
+		}
+
+		var rec walRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return 0, fmt.Errorf("%w: record at offset %d: %v", ErrCorruptWAL, offset, err)
+		}
+		offset += int64(len(line))
+
+		if rec.Seq <= fs.seq {
+			continue
+		}
+		if err := fs.applyRecord(&rec); err != nil {
+			return 0, fmt.Errorf("replay wal record %d: %w", rec.Seq, err)
+		}
+		fs.seq = rec.Seq
+		fs.walRecords++
+	}
+}
+
+func (fs *FileStore) path(name string) string {
+	return filepath.Join(fs.dir, name)
+}
@@ -0,0 +1,108 @@
+package store_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+	"github.com/suyashXD/DNDS/internal/store/storetest"
+)
+
+func openFileStore(t *testing.T, dir string) store.Store {
+	t.Helper()
+	s, err := store.NewFileStore(dir, true)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	return s
+}
+
+func TestFileStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		s := openFileStore(t, t.TempDir())
+		t.Cleanup(func() { s.(*store.FileStore).Close() })
+		return s
+	})
+}
+
+func TestFileStorePersistence(t *testing.T) {
+	storetest.RunPersistence(t, openFileStore)
+}
+
+// appendWAL writes raw bytes to the end of the store's write-ahead log
+func appendWAL(t *testing.T, dir, data string) {
+	t.Helper()
+	f, err := os.OpenFile(filepath.Join(dir, "wal.log"), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileStoreDiscardsTornFinalRecord(t *testing.T) {
+	dir := t.TempDir()
+	s := openFileStore(t, dir).(*store.FileStore)
+	if err := s.SavePost(&models.Post{ID: "kept", AuthorID: "user1"}); err != nil {
+		t.Fatalf("SavePost: %v", err)
+	}
+	s.Close()
+	appendWAL(t, dir, `{"seq":99,"op":"save_po`)
+
+	s = openFileStore(t, dir).(*store.FileStore)
+	if _, err := s.GetPost("kept"); err != nil {
+		t.Errorf("GetPost after a torn write: %v", err)
+	}
+	// The torn record is cut off, so later writes land on a clean line
+	if err := s.SavePost(&models.Post{ID: "later", AuthorID: "user1"}); err != nil {
+		t.Fatalf("SavePost: %v", err)
+	}
+	s.Close()
+
+	s = openFileStore(t, dir).(*store.FileStore)
+	defer s.Close()
+	for _, id := range []string{"kept", "later"} {
+		if _, err := s.GetPost(id); err != nil {
+			t.Errorf("GetPost(%s) after reopening: %v", id, err)
+		}
+	}
+}
+
+func TestFileStoreRejectsCorruptWAL(t *testing.T) {
+	for name, corrupt := range map[string]string{
+		"mid-log":            "garbage\n" + `{"seq":100,"op":"save_post","post":{"id":"after","author_id":"user1"}}` + "\n",
+		"complete last line": "garbage\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := openFileStore(t, dir).(*store.FileStore)
+			if err := s.SavePost(&models.Post{ID: "before", AuthorID: "user1"}); err != nil {
+				t.Fatalf("SavePost: %v", err)
+			}
+			s.Close()
+			appendWAL(t, dir, corrupt)
+			wal := filepath.Join(dir, "wal.log")
+			before, err := os.ReadFile(wal)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := store.NewFileStore(dir, true); !errors.Is(err, store.ErrCorruptWAL) {
+				t.Fatalf("NewFileStore = %v, want ErrCorruptWAL", err)
+			}
+			// Nothing after the corruption is thrown away
+			after, err := os.ReadFile(wal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(after) != string(before) {
+				t.Errorf("wal changed from %d to %d bytes, want it left for repair", len(before), len(after))
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"errors"
+	"sort"
 	"sync"
 	"time"
 
@@ -9,17 +10,25 @@ import (
 )
 
 var (
-	ErrUserNotFound        = errors.New("user not found")
-	ErrPostNotFound        = errors.New("post not found")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrPostNotFound         = errors.New("post not found")
 	ErrNotificationNotFound = errors.New("notification not found")
+	ErrDeadLetterNotFound   = errors.New("dead letter not found")
+	ErrSelfFollow           = errors.New("users cannot follow themselves")
+	ErrUserExists           = errors.New("user already exists")
 )
 
-// MemoryStore implements an in-memory data store for the application
+// MemoryStore implements an in-memory data store for the application.
+// Notifications are copied on the way in and out, so callers such as the
+// queue workers can keep mutating their own copies without racing readers.
 
 type MemoryStore struct {
 	users         map[string]*models.User
 	posts         map[string]*models.Post
 	notifications map[string][]*models.Notification
+	byID          map[string]*models.Notification
+	counts        map[string]*NotificationCounts
+	deadLetters   map[string]*models.DeadLetter
 	mu            sync.RWMutex
 }
 
@@ -30,6 +39,9 @@ func NewMemoryStore(loadSampleData bool) *MemoryStore {
 		users:         make(map[string]*models.User),
 		posts:         make(map[string]*models.Post),
 		notifications: make(map[string][]*models.Notification),
+		byID:          make(map[string]*models.Notification),
+		counts:        make(map[string]*NotificationCounts),
+		deadLetters:   make(map[string]*models.DeadLetter),
 	}
 
 	if loadSampleData {
@@ -49,7 +61,21 @@ func (s *MemoryStore) GetUser(id string) (*models.User, error) {
 	if !exists {
 		return nil, ErrUserNotFound
 	}
-	return user, nil
+	return copyUser(user), nil
+}
+
+// GetUsers retrieves several users by ID under one lock
+func (s *MemoryStore) GetUsers(ids []string) (map[string]*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make(map[string]*models.User, len(ids))
+	for _, id := range ids {
+		if user, exists := s.users[id]; exists {
+			users[id] = copyUser(user)
+		}
+	}
+	return users, nil
 }
 
 // GetAllUsers returns all users
@@ -60,11 +86,116 @@ func (s *MemoryStore) GetAllUsers() []*models.User {
 
 	users := make([]*models.User, 0, len(s.users))
 	for _, user := range s.users {
-		users = append(users, user)
+		users = append(users, copyUser(user))
 	}
 	return users
 }
 
+// ListUsers returns up to limit users with IDs after afterID, ordered by ID
+func (s *MemoryStore) ListUsers(afterID string, limit int) ([]*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.users))
+	for id := range s.users {
+		if id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	users := make([]*models.User, 0, len(ids))
+	for _, id := range ids {
+		users = append(users, copyUser(s.users[id]))
+	}
+	return users, nil
+}
+
+// CreateUser adds a new user with no follow edges
+func (s *MemoryStore) CreateUser(user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[user.ID]; exists {
+		return ErrUserExists
+	}
+	s.users[user.ID] = &models.User{
+		ID:           user.ID,
+		Username:     user.Username,
+		FollowerIDs:  []string{},
+		FollowingIDs: []string{},
+	}
+	s.notifications[user.ID] = []*models.Notification{}
+	return nil
+}
+
+// UpdateUser updates a user's username
+func (s *MemoryStore) UpdateUser(user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.users[user.ID]
+	if !exists {
+		return ErrUserNotFound
+	}
+	stored.Username = user.Username
+	return nil
+}
+
+// DeleteUser removes a user, every follow edge they are part of and the
+// notifications they received. Posts and notifications they authored are kept
+// with the author cleared.
+func (s *MemoryStore) DeleteUser(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[id]
+	if !exists {
+		return ErrUserNotFound
+	}
+
+	for _, followerID := range user.FollowerIDs {
+		if follower, ok := s.users[followerID]; ok {
+			follower.FollowingIDs = removeID(follower.FollowingIDs, id)
+		}
+	}
+	for _, followeeID := range user.FollowingIDs {
+		if followee, ok := s.users[followeeID]; ok {
+			followee.FollowerIDs = removeID(followee.FollowerIDs, id)
+		}
+	}
+	delete(s.users, id)
+
+	for _, n := range s.notifications[id] {
+		delete(s.byID, n.ID)
+	}
+	delete(s.notifications, id)
+	delete(s.counts, id)
+	for notificationID, deadLetter := range s.deadLetters {
+		if deadLetter.UserID == id {
+			delete(s.deadLetters, notificationID)
+		}
+	}
+
+	for _, n := range s.byID {
+		if n.AuthorID == id {
+			n.AuthorID = ""
+		}
+	}
+	for postID, post := range s.posts {
+		if post.AuthorID == id {
+			// Posts are handed out by pointer, so replace rather than mutate
+			anonymized := *post
+			anonymized.AuthorID = ""
+			s.posts[postID] = &anonymized
+		}
+	}
+	return nil
+}
+
 // GetFollowers returns all followers for a user
 
 func (s *MemoryStore) GetFollowers(userID string) ([]*models.User, error) {
@@ -79,12 +210,81 @@ func (s *MemoryStore) GetFollowers(userID string) ([]*models.User, error) {
 	followers := make([]*models.User, 0, len(user.FollowerIDs))
 	for _, id := range user.FollowerIDs {
 		if follower, ok := s.users[id]; ok {
-			followers = append(followers, follower)
+			followers = append(followers, copyUser(follower))
 		}
 	}
 	return followers, nil
 }
 
+// Follow makes followerID follow followeeID, updating both sides of the edge.
+// Following someone twice is a no-op.
+func (s *MemoryStore) Follow(followerID, followeeID string) error {
+	if followerID == followeeID {
+		return ErrSelfFollow
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	follower, followee, err := s.edgeUsers(followerID, followeeID)
+	if err != nil {
+		return err
+	}
+	if !containsID(followee.FollowerIDs, followerID) {
+		followee.FollowerIDs = append(followee.FollowerIDs, followerID)
+	}
+	if !containsID(follower.FollowingIDs, followeeID) {
+		follower.FollowingIDs = append(follower.FollowingIDs, followeeID)
+	}
+	return nil
+}
+
+// Unfollow removes the edge from followerID to followeeID, if there is one
+func (s *MemoryStore) Unfollow(followerID, followeeID string) error {
+	if followerID == followeeID {
+		return ErrSelfFollow
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	follower, followee, err := s.edgeUsers(followerID, followeeID)
+	if err != nil {
+		return err
+	}
+	followee.FollowerIDs = removeID(followee.FollowerIDs, followerID)
+	follower.FollowingIDs = removeID(follower.FollowingIDs, followeeID)
+	return nil
+}
+
+// rebuildFollowing recomputes every FollowingIDs from the FollowerIDs lists.
+// s.mu must be held.
+func (s *MemoryStore) rebuildFollowing() {
+	for _, user := range s.users {
+		user.FollowingIDs = []string{}
+	}
+	for _, user := range s.users {
+		for _, followerID := range user.FollowerIDs {
+			if follower, ok := s.users[followerID]; ok {
+				follower.FollowingIDs = append(follower.FollowingIDs, user.ID)
+			}
+		}
+	}
+}
+
+// edgeUsers looks up both ends of a follow edge. s.mu must be held.
+func (s *MemoryStore) edgeUsers(followerID, followeeID string) (*models.User, *models.User, error) {
+	follower, exists := s.users[followerID]
+	if !exists {
+		return nil, nil, ErrUserNotFound
+	}
+	followee, exists := s.users[followeeID]
+	if !exists {
+		return nil, nil, ErrUserNotFound
+	}
+	return follower, followee, nil
+}
+
 // SavePost stores a new post
 
 func (s *MemoryStore) SavePost(post *models.Post) error {
@@ -107,33 +307,140 @@ func (s *MemoryStore) GetPost(id string) (*models.Post, error) {
 	return post, nil
 }
 
+// GetPosts retrieves several posts by ID under one lock
+func (s *MemoryStore) GetPosts(ids []string) (map[string]*models.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := make(map[string]*models.Post, len(ids))
+	for _, id := range ids {
+		if post, exists := s.posts[id]; exists {
+			posts[id] = post
+		}
+	}
+	return posts, nil
+}
+
 // SaveNotification adds a notification for a user
 func (s *MemoryStore) SaveNotification(notification *models.Notification) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.notifications[notification.UserID] = append(s.notifications[notification.UserID], notification)
+	s.addNotification(notification)
+	return nil
+}
+
+// SavePostWithNotifications stores a post and its notifications under one lock
+func (s *MemoryStore) SavePostWithNotifications(post *models.Post, notifications []*models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.posts[post.ID] = post
+	for _, notification := range notifications {
+		s.addNotification(notification)
+	}
 	return nil
 }
 
-// UpdateNotification updates a notification's status
+// UpdateNotification updates a notification's delivery status, attempts,
+// delivery history, delivery time and next attempt time. Read is left alone
+// so a stale copy held by a worker cannot undo a read.
 func (s *MemoryStore) UpdateNotification(notification *models.Notification) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	userNotifications, exists := s.notifications[notification.UserID]
+	stored, exists := s.byID[notification.ID]
 	if !exists {
-		return ErrUserNotFound
+		return ErrNotificationNotFound
+	}
+
+	if stored.Status != notification.Status {
+		counts := s.counts[stored.UserID]
+		counts.add(stored.Status, -1)
+		counts.add(notification.Status, 1)
+	}
+	stored.Status = notification.Status
+	stored.Attempts = notification.Attempts
+	stored.DeliveryAttempts = append([]models.DeliveryAttempt(nil), notification.DeliveryAttempts...)
+	stored.NextAttemptAt = notification.NextAttemptAt
+	stored.DeliveredAt = notification.DeliveredAt
+	return nil
+}
+
+// GetNotification retrieves a notification by ID
+func (s *MemoryStore) GetNotification(id string) (*models.Notification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	notification, exists := s.byID[id]
+	if !exists {
+		return nil, ErrNotificationNotFound
+	}
+	return copyNotification(notification), nil
+}
+
+// MarkNotificationsRead marks the given notifications read
+func (s *MemoryStore) MarkNotificationsRead(ids []string) ([]*models.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Check every ID first so an unknown one leaves the rest untouched
+	for _, id := range ids {
+		if _, exists := s.byID[id]; !exists {
+			return nil, ErrNotificationNotFound
+		}
 	}
 
-	for i, n := range userNotifications {
-		if n.ID == notification.ID {
-			userNotifications[i] = notification
-			return nil
+	result := make([]*models.Notification, 0, len(ids))
+	for _, id := range ids {
+		notification := s.byID[id]
+		s.markRead(notification)
+		result = append(result, copyNotification(notification))
+	}
+	return result, nil
+}
+
+// MarkAllNotificationsRead marks a user's unread notifications created before
+// the given time read
+func (s *MemoryStore) MarkAllNotificationsRead(userID string, before time.Time) ([]*models.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]*models.Notification, 0)
+	for _, notification := range s.notifications[userID] {
+		if notification.Read || !notification.CreatedAt.Before(before) {
+			continue
 		}
+		s.markRead(notification)
+		result = append(result, copyNotification(notification))
+	}
+	return result, nil
+}
+
+// CountUnreadNotifications returns the number of unread notifications for a user
+func (s *MemoryStore) CountUnreadNotifications(userID string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if counts, exists := s.counts[userID]; exists {
+		return counts.Unread, nil
 	}
+	return 0, nil
+}
 
-	return ErrNotificationNotFound
+// GetNotificationCounts returns a user's notification counters
+func (s *MemoryStore) GetNotificationCounts(userID string) (*NotificationCounts, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := &NotificationCounts{ByStatus: make(map[models.NotificationStatus]int)}
+	if counts, exists := s.counts[userID]; exists {
+		result.Total, result.Unread = counts.Total, counts.Unread
+		for status, count := range counts.ByStatus {
+			result.ByStatus[status] = count
+		}
+	}
+	return result, nil
 }
 
 // GetUserNotifications returns notifications for a user
@@ -149,18 +456,272 @@ func (s *MemoryStore) GetUserNotifications(userID string, limit int) ([]*models.
 	// Return the most recent notifications up to the limit
 	result := make([]*models.Notification, 0, limit)
 	count := 0
-	
+
 	// Start from the end (most recent) and work backwards
 	for i := len(notifications) - 1; i >= 0 && count < limit; i-- {
-		result = append(result, notifications[i])
+		result = append(result, copyNotification(notifications[i]))
 		count++
 	}
 
 	return result, nil
 }
 
+// QueryNotifications returns a page of a user's notifications
+func (s *MemoryStore) QueryNotifications(query NotificationQuery) (*NotificationPage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Cursors are placed by insertion index, so they stay valid even if the
+	// notification no longer matches the filter
+	notifications := s.notifications[query.UserID]
+	var matched []int
+	for i := range notifications {
+		if query.Filter.Match(notifications[i]) {
+			matched = append(matched, i)
+		}
+	}
+	if query.Order == NewestFirst {
+		for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+			matched[i], matched[j] = matched[j], matched[i]
+		}
+	}
+
+	// precedes reports whether insertion index a comes before b in the order
+	precedes := func(a, b int) bool {
+		if query.Order == NewestFirst {
+			return a > b
+		}
+		return a < b
+	}
+	// upTo counts the matches at or before insertion index i in the order
+	upTo := func(i int) int {
+		return sort.Search(len(matched), func(p int) bool { return precedes(i, matched[p]) })
+	}
+
+	lo, hi := 0, len(matched)
+	if query.After != "" {
+		i, err := s.insertionIndex(query.UserID, query.After)
+		if err != nil {
+			return nil, err
+		}
+		lo = upTo(i)
+	}
+	if query.Before != "" {
+		i, err := s.insertionIndex(query.UserID, query.Before)
+		if err != nil {
+			return nil, err
+		}
+		hi = upTo(i)
+		if hi > 0 && matched[hi-1] == i {
+			hi--
+		}
+	}
+	if hi < lo {
+		hi = lo
+	}
+	if query.First > 0 && hi-lo > query.First {
+		hi = lo + query.First
+	}
+	if query.Last > 0 && hi-lo > query.Last {
+		lo = hi - query.Last
+	}
+
+	page := &NotificationPage{
+		Notifications: make([]*models.Notification, 0, hi-lo),
+		HasNext:       hi < len(matched),
+		HasPrevious:   lo > 0,
+	}
+	for _, i := range matched[lo:hi] {
+		page.Notifications = append(page.Notifications, copyNotification(notifications[i]))
+	}
+	return page, nil
+}
+
+// insertionIndex returns where one of a user's notifications sits in their
+// notifications slice. s.mu must be held.
+func (s *MemoryStore) insertionIndex(userID, id string) (int, error) {
+	if n, exists := s.byID[id]; !exists || n.UserID != userID {
+		return 0, ErrNotificationNotFound
+	}
+
+	notifications := s.notifications[userID]
+	for i := len(notifications) - 1; i >= 0; i-- {
+		if notifications[i].ID == id {
+			return i, nil
+		}
+	}
+	return 0, ErrNotificationNotFound
+}
+
+// GetPendingNotifications returns all queued or retrying notifications, oldest first
+func (s *MemoryStore) GetPendingNotifications() ([]*models.Notification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pending := make([]*models.Notification, 0)
+	for _, notifications := range s.notifications {
+		for _, n := range notifications {
+			if !n.Status.IsTerminal() {
+				pending = append(pending, copyNotification(n))
+			}
+		}
+	}
+
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+	return pending, nil
+}
+
+// SaveDeadLetter stores a copy of a dead letter, replacing the one recorded
+// for the same notification
+func (s *MemoryStore) SaveDeadLetter(deadLetter *models.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deadLetters[deadLetter.NotificationID] = copyDeadLetter(deadLetter)
+	return nil
+}
+
+// GetDeadLetter retrieves the dead letter of a notification
+func (s *MemoryStore) GetDeadLetter(notificationID string) (*models.DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deadLetter, exists := s.deadLetters[notificationID]
+	if !exists {
+		return nil, ErrDeadLetterNotFound
+	}
+	return copyDeadLetter(deadLetter), nil
+}
+
+// ListDeadLetters returns up to limit dead letters that pass filter, most
+// recently dead first
+func (s *MemoryStore) ListDeadLetters(filter DeadLetterFilter, limit int) ([]*models.DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deadLetters := make([]*models.DeadLetter, 0)
+	for _, deadLetter := range s.deadLetters {
+		if filter.Match(deadLetter) {
+			deadLetters = append(deadLetters, copyDeadLetter(deadLetter))
+		}
+	}
+	return sortDeadLetters(deadLetters, limit), nil
+}
+
+// DeleteDeadLetters removes the dead letters of the given notifications
+func (s *MemoryStore) DeleteDeadLetters(notificationIDs []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for _, id := range notificationIDs {
+		if _, exists := s.deadLetters[id]; exists {
+			delete(s.deadLetters, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// addNotification stores a copy of notification. s.mu must be held.
+func (s *MemoryStore) addNotification(notification *models.Notification) {
+	stored := copyNotification(notification)
+	s.notifications[stored.UserID] = append(s.notifications[stored.UserID], stored)
+	s.byID[stored.ID] = stored
+
+	counts, exists := s.counts[stored.UserID]
+	if !exists {
+		counts = &NotificationCounts{ByStatus: make(map[models.NotificationStatus]int)}
+		s.counts[stored.UserID] = counts
+	}
+	counts.Total++
+	counts.add(stored.Status, 1)
+	if !stored.Read {
+		counts.Unread++
+	}
+}
+
+// markRead sets Read on a stored notification. s.mu must be held.
+func (s *MemoryStore) markRead(notification *models.Notification) {
+	if notification.Read {
+		return
+	}
+	notification.Read = true
+	s.counts[notification.UserID].Unread--
+}
+
+// add adjusts the count of a status, dropping it once it reaches zero
+func (c *NotificationCounts) add(status models.NotificationStatus, delta int) {
+	c.ByStatus[status] += delta
+	if c.ByStatus[status] == 0 {
+		delete(c.ByStatus, status)
+	}
+}
+
+func copyUser(user *models.User) *models.User {
+	c := *user
+	c.FollowerIDs = append([]string{}, user.FollowerIDs...)
+	c.FollowingIDs = append([]string{}, user.FollowingIDs...)
+	return &c
+}
+
+func containsID(ids []string, id string) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
+
+// removeID returns ids without id, in a new slice
+func removeID(ids []string, id string) []string {
+	result := make([]string, 0, len(ids))
+	for _, existing := range ids {
+		if existing != id {
+			result = append(result, existing)
+		}
+	}
+	return result
+}
+
+func copyNotification(notification *models.Notification) *models.Notification {
+	c := *notification
+	c.DeliveryAttempts = append([]models.DeliveryAttempt(nil), notification.DeliveryAttempts...)
+	return &c
+}
+
+func copyDeadLetter(deadLetter *models.DeadLetter) *models.DeadLetter {
+	c := *deadLetter
+	c.AttemptedAt = append([]time.Time{}, deadLetter.AttemptedAt...)
+	c.Replays = append([]models.DeadLetterReplay{}, deadLetter.Replays...)
+	return &c
+}
+
 // loadSampleData populates the store with sample data
 func (s *MemoryStore) loadSampleData() {
+	users, posts := SampleData()
+
+	// Map users to the store
+	for _, user := range users {
+		s.users[user.ID] = user
+	}
+
+	// Map posts to the store
+	for _, post := range posts {
+		s.posts[post.ID] = post
+	}
+
+	// Initialize empty notification lists for each user
+	for _, user := range users {
+		s.notifications[user.ID] = []*models.Notification{}
+	}
+}
+
+// SampleData returns the demo users and posts every backend seeds itself with
+func SampleData() ([]*models.User, []*models.Post) {
 	// Create users
 	users := []*models.User{
 		{ID: "user1", Username: "alice", FollowerIDs: []string{}, FollowingIDs: []string{}},
@@ -175,19 +736,26 @@ func (s *MemoryStore) loadSampleData() {
 	// Set up follower relationships
 	// User1 (Alice) is followed by everyone
 	users[0].FollowerIDs = []string{"user2", "user3", "user4", "user5", "user6", "user7"}
-	
+
 	// User2 (Bob) is followed by some users
 	users[1].FollowerIDs = []string{"user1", "user3", "user5"}
-	
+
 	// User3 (Charlie) is followed by some users
 	users[2].FollowerIDs = []string{"user1", "user2", "user4"}
 
 	// User4 (Dave) is followed by some users
 	users[3].FollowerIDs = []string{"user2", "user5", "user7"}
 
-	// Map users to the store
+	// Derive the other direction of every edge
+	byID := make(map[string]*models.User, len(users))
 	for _, user := range users {
-		s.users[user.ID] = user
+		byID[user.ID] = user
+	}
+	for _, user := range users {
+		for _, followerID := range user.FollowerIDs {
+			follower := byID[followerID]
+			follower.FollowingIDs = append(follower.FollowingIDs, user.ID)
+		}
 	}
 
 	// Create some sample posts
@@ -212,13 +780,5 @@ func (s *MemoryStore) loadSampleData() {
 		},
 	}
 
-	// Map posts to the store
-	for _, post := range posts {
-		s.posts[post.ID] = post
-	}
-
-	// Initialize empty notification lists for each user
-	for _, user := range users {
-		s.notifications[user.ID] = []*models.Notification{}
-	}
-}
\ No newline at end of file
+	return users, posts
+}
@@ -0,0 +1,44 @@
+-- Initial schema: users, the follower graph, posts and notifications.
+-- Timestamps are stored as Unix nanoseconds.
+
+CREATE TABLE users (
+    id       TEXT PRIMARY KEY,
+    username TEXT NOT NULL
+);
+
+-- One row per edge: follower_id follows followee_id
+CREATE TABLE follows (
+    followee_id TEXT NOT NULL REFERENCES users (id),
+    follower_id TEXT NOT NULL REFERENCES users (id),
+    PRIMARY KEY (followee_id, follower_id)
+);
+
+CREATE INDEX follows_follower_idx ON follows (follower_id);
+
+CREATE TABLE posts (
+    id         TEXT PRIMARY KEY,
+    author_id  TEXT NOT NULL,
+    content    TEXT NOT NULL,
+    created_at INTEGER NOT NULL
+);
+
+CREATE INDEX posts_author_idx ON posts (author_id, created_at);
+
+-- seq is the insertion order and breaks ties between equal created_at values,
+-- so (created_at, seq) is a unique keyset for pagination
+CREATE TABLE notifications (
+    seq        INTEGER PRIMARY KEY AUTOINCREMENT,
+    id         TEXT NOT NULL UNIQUE,
+    user_id    TEXT NOT NULL,
+    post_id    TEXT NOT NULL,
+    author_id  TEXT NOT NULL,
+    content    TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    is_read    INTEGER NOT NULL DEFAULT 0,
+    status     INTEGER NOT NULL,
+    attempts   INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX notifications_user_idx ON notifications (user_id, created_at, seq);
+CREATE INDEX notifications_status_idx ON notifications (status, created_at, seq);
+CREATE INDEX notifications_post_idx ON notifications (post_id);
@@ -0,0 +1,4 @@
+-- Partial index over unread notifications for unread counts and
+-- mark-all-read, which only ever look at unread rows
+
+CREATE INDEX notifications_unread_idx ON notifications (user_id, created_at, seq) WHERE is_read = 0;
@@ -0,0 +1,50 @@
+-- Per-user, per-status notification counters kept up to date by triggers,
+-- so unread badges and status counts never scan a user's notifications
+
+CREATE TABLE notification_counts (
+    user_id TEXT NOT NULL,
+    status  INTEGER NOT NULL,
+    total   INTEGER NOT NULL,
+    unread  INTEGER NOT NULL,
+    PRIMARY KEY (user_id, status)
+);
+
+INSERT INTO notification_counts (user_id, status, total, unread)
+SELECT user_id, status, COUNT(*), SUM(is_read = 0)
+FROM notifications
+GROUP BY user_id, status;
+
+CREATE TRIGGER notifications_count_insert AFTER INSERT ON notifications
+BEGIN
+    INSERT INTO notification_counts (user_id, status, total, unread)
+    VALUES (NEW.user_id, NEW.status, 1, NEW.is_read = 0)
+    ON CONFLICT (user_id, status) DO UPDATE SET
+        total = total + 1,
+        unread = unread + excluded.unread;
+END;
+
+CREATE TRIGGER notifications_count_update AFTER UPDATE OF user_id, status, is_read ON notifications
+BEGIN
+    UPDATE notification_counts SET
+        total = total - 1,
+        unread = unread - (OLD.is_read = 0)
+    WHERE user_id = OLD.user_id AND status = OLD.status;
+    DELETE FROM notification_counts
+    WHERE user_id = OLD.user_id AND status = OLD.status AND total = 0;
+
+    INSERT INTO notification_counts (user_id, status, total, unread)
+    VALUES (NEW.user_id, NEW.status, 1, NEW.is_read = 0)
+    ON CONFLICT (user_id, status) DO UPDATE SET
+        total = total + 1,
+        unread = unread + excluded.unread;
+END;
+
+CREATE TRIGGER notifications_count_delete AFTER DELETE ON notifications
+BEGIN
+    UPDATE notification_counts SET
+        total = total - 1,
+        unread = unread - (OLD.is_read = 0)
+    WHERE user_id = OLD.user_id AND status = OLD.status;
+    DELETE FROM notification_counts
+    WHERE user_id = OLD.user_id AND status = OLD.status AND total = 0;
+END;
@@ -0,0 +1,4 @@
+-- When a retrying notification is next due, in Unix nanoseconds; 0 if none,
+-- so scheduled retries keep their backoff across restarts
+
+ALTER TABLE notifications ADD COLUMN next_attempt_at INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- What a notification is about, so retry policies can differ per type.
+-- Every notification so far announced a new post.
+
+ALTER TABLE notifications ADD COLUMN type TEXT NOT NULL DEFAULT 'new_post';
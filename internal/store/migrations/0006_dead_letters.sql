@@ -0,0 +1,18 @@
+-- Notifications that failed for good, kept for inspection and replay.
+-- attempted_at and replays hold JSON arrays, since they are only ever read
+-- back whole.
+
+CREATE TABLE dead_letters (
+    notification_id TEXT PRIMARY KEY,
+    user_id         TEXT NOT NULL,
+    reason          TEXT NOT NULL,
+    last_error      TEXT NOT NULL,
+    attempts        INTEGER NOT NULL,
+    attempted_at    TEXT NOT NULL,
+    dead_at         INTEGER NOT NULL,
+    replayed        INTEGER NOT NULL DEFAULT 0,
+    replays         TEXT NOT NULL
+);
+
+CREATE INDEX dead_letters_dead_at_idx ON dead_letters (dead_at);
+CREATE INDEX dead_letters_user_idx ON dead_letters (user_id, dead_at);
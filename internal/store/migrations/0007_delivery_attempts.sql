@@ -0,0 +1,4 @@
+-- The history of every delivery attempt of a notification, as a JSON array
+-- read back whole like the dead letters' attempt times.
+
+ALTER TABLE notifications ADD COLUMN delivery_attempts TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1,6 @@
+-- When a notification was delivered, in Unix nanoseconds; 0 until then.
+-- Lets a reconnecting SSE client be replayed in delivery order.
+
+ALTER TABLE notifications ADD COLUMN delivered_at INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX notifications_delivered_idx ON notifications (user_id, delivered_at);
@@ -0,0 +1,1013 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the pure-Go "sqlite" driver
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migration is a single versioned schema change
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// SQLStore is a store backed by database/sql. Its queries stick to the SQL
+// understood by SQLite; OpenSQLite opens a database with the pure-Go driver.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the SQLite database at path (":memory:" for a throwaway
+// database) and returns a migrated store on top of it
+func OpenSQLite(path string, loadSampleData bool) (*SQLStore, error) {
+	dsn := path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite database: %w", err)
+	}
+	// SQLite allows a single writer; serializing connections avoids SQLITE_BUSY
+	// and keeps an in-memory database shared across calls
+	db.SetMaxOpenConns(1)
+
+	s, err := NewSQLStore(db, loadSampleData)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewSQLStore applies any pending migrations to db and returns a store on top
+// of it. Sample data is only loaded into a database that holds no users yet.
+func NewSQLStore(db *sql.DB, loadSampleData bool) (*SQLStore, error) {
+	s := &SQLStore{db: db}
+
+	if err := s.migrate(); err != nil {
+		return nil, err
+	}
+
+	if loadSampleData {
+		var count int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+			return nil, fmt.Errorf("count users: %w", err)
+		}
+		if count == 0 {
+			if err := s.loadSampleData(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// Close closes the underlying database
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+// GetUser retrieves a user by ID
+func (s *SQLStore) GetUser(id string) (*models.User, error) {
+	user := &models.User{}
+	err := s.db.QueryRow(`SELECT id, username FROM users WHERE id = ?`, id).Scan(&user.ID, &user.Username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attachEdges([]*models.User{user}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUsers retrieves several users by ID in one query
+func (s *SQLStore) GetUsers(ids []string) (map[string]*models.User, error) {
+	users := make(map[string]*models.User, len(ids))
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	found, err := s.queryUsers(`SELECT id, username FROM users WHERE id IN (`+placeholders(len(ids))+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachEdges(found); err != nil {
+		return nil, err
+	}
+	for _, user := range found {
+		users[user.ID] = user
+	}
+	return users, nil
+}
+
+// GetAllUsers returns all users
+func (s *SQLStore) GetAllUsers() []*models.User {
+	users, err := s.queryUsers(`SELECT id, username FROM users ORDER BY id`)
+	if err == nil {
+		err = s.attachEdges(users)
+	}
+	if err != nil {
+		log.Printf("Failed to list users: %v", err)
+		return []*models.User{}
+	}
+	return users
+}
+
+// ListUsers returns up to limit users with IDs after afterID, ordered by ID
+func (s *SQLStore) ListUsers(afterID string, limit int) ([]*models.User, error) {
+	users, err := s.queryUsers(`SELECT id, username FROM users WHERE id > ? ORDER BY id LIMIT ?`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachEdges(users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// CreateUser adds a new user with no follow edges
+func (s *SQLStore) CreateUser(user *models.User) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		err := userExists(tx, user.ID)
+		if err == nil {
+			return ErrUserExists
+		}
+		if !errors.Is(err, ErrUserNotFound) {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO users (id, username) VALUES (?, ?)`, user.ID, user.Username)
+		return err
+	})
+}
+
+// UpdateUser updates a user's username
+func (s *SQLStore) UpdateUser(user *models.User) error {
+	res, err := s.db.Exec(`UPDATE users SET username = ? WHERE id = ?`, user.Username, user.ID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// DeleteUser removes a user, every follow edge they are part of and the
+// notifications they received in one transaction. Posts and notifications
+// they authored are kept with the author cleared.
+func (s *SQLStore) DeleteUser(id string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := userExists(tx, id); err != nil {
+			return err
+		}
+		for _, stmt := range []string{
+			`DELETE FROM follows WHERE followee_id = ?1 OR follower_id = ?1`,
+			`DELETE FROM notifications WHERE user_id = ?`,
+			`DELETE FROM dead_letters WHERE user_id = ?`,
+			`UPDATE notifications SET author_id = '' WHERE author_id = ?`,
+			`UPDATE posts SET author_id = '' WHERE author_id = ?`,
+			`DELETE FROM users WHERE id = ?`,
+		} {
+			if _, err := tx.Exec(stmt, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetFollowers returns all followers for a user
+func (s *SQLStore) GetFollowers(userID string) ([]*models.User, error) {
+	if err := userExists(s.db, userID); err != nil {
+		return nil, err
+	}
+
+	followers, err := s.queryUsers(`
+		SELECT u.id, u.username
+		FROM follows f
+		JOIN users u ON u.id = f.follower_id
+		WHERE f.followee_id = ?
+		ORDER BY u.id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachEdges(followers); err != nil {
+		return nil, err
+	}
+	return followers, nil
+}
+
+// Follow makes followerID follow followeeID. The follows primary key makes
+// repeated follows a no-op.
+func (s *SQLStore) Follow(followerID, followeeID string) error {
+	if followerID == followeeID {
+		return ErrSelfFollow
+	}
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := edgeUsersExist(tx, followerID, followeeID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`
+			INSERT INTO follows (followee_id, follower_id) VALUES (?, ?)
+			ON CONFLICT (followee_id, follower_id) DO NOTHING`, followeeID, followerID)
+		return err
+	})
+}
+
+// Unfollow removes the edge from followerID to followeeID, if there is one
+func (s *SQLStore) Unfollow(followerID, followeeID string) error {
+	if followerID == followeeID {
+		return ErrSelfFollow
+	}
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := edgeUsersExist(tx, followerID, followeeID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`DELETE FROM follows WHERE followee_id = ? AND follower_id = ?`, followeeID, followerID)
+		return err
+	})
+}
+
+// SavePost stores a new post
+func (s *SQLStore) SavePost(post *models.Post) error {
+	return insertPost(s.db, post)
+}
+
+// GetPost retrieves a post by ID
+func (s *SQLStore) GetPost(id string) (*models.Post, error) {
+	post := &models.Post{}
+	var createdAt int64
+	err := s.db.QueryRow(`SELECT id, author_id, content, created_at FROM posts WHERE id = ?`, id).
+		Scan(&post.ID, &post.AuthorID, &post.Content, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPostNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	post.CreatedAt = time.Unix(0, createdAt)
+	return post, nil
+}
+
+// GetPosts retrieves several posts by ID in one query
+func (s *SQLStore) GetPosts(ids []string) (map[string]*models.Post, error) {
+	posts := make(map[string]*models.Post, len(ids))
+	if len(ids) == 0 {
+		return posts, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := s.db.Query(`SELECT id, author_id, content, created_at FROM posts WHERE id IN (`+placeholders(len(ids))+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		post := &models.Post{}
+		var createdAt int64
+		if err := rows.Scan(&post.ID, &post.AuthorID, &post.Content, &createdAt); err != nil {
+			return nil, err
+		}
+		post.CreatedAt = time.Unix(0, createdAt)
+		posts[post.ID] = post
+	}
+	return posts, rows.Err()
+}
+
+// SaveNotification adds a notification for a user
+func (s *SQLStore) SaveNotification(notification *models.Notification) error {
+	return insertNotification(s.db, notification)
+}
+
+// SavePostWithNotifications stores a post and the notifications it fans out to
+// in a single transaction
+func (s *SQLStore) SavePostWithNotifications(post *models.Post, notifications []*models.Notification) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := insertPost(tx, post); err != nil {
+			return err
+		}
+		for _, notification := range notifications {
+			if err := insertNotification(tx, notification); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateNotification updates a notification's delivery status, attempts,
+// delivery history, delivery time and next attempt time
+func (s *SQLStore) UpdateNotification(notification *models.Notification) error {
+	deliveryAttempts, err := json.Marshal(notification.DeliveryAttempts)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.Exec(`UPDATE notifications SET status = ?, attempts = ?, next_attempt_at = ?, delivery_attempts = ?, delivered_at = ? WHERE id = ?`,
+		int(notification.Status), notification.Attempts, unixNano(notification.NextAttemptAt), string(deliveryAttempts), unixNano(notification.DeliveredAt),
+		notification.ID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// GetNotification retrieves a notification by ID
+func (s *SQLStore) GetNotification(id string) (*models.Notification, error) {
+	notifications, err := queryNotifications(s.db, `SELECT `+notificationColumns+` FROM notifications WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(notifications) == 0 {
+		return nil, ErrNotificationNotFound
+	}
+	return notifications[0], nil
+}
+
+// MarkNotificationsRead marks the given notifications read in one transaction
+func (s *SQLStore) MarkNotificationsRead(ids []string) ([]*models.Notification, error) {
+	if len(ids) == 0 {
+		return []*models.Notification{}, nil
+	}
+
+	var result []*models.Notification
+	err := s.withTx(func(tx *sql.Tx) error {
+		for _, id := range ids {
+			res, err := tx.Exec(`UPDATE notifications SET is_read = 1 WHERE id = ?`, id)
+			if err != nil {
+				return err
+			}
+			affected, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if affected == 0 {
+				return ErrNotificationNotFound
+			}
+		}
+
+		args := make([]interface{}, len(ids))
+		for i, id := range ids {
+			args[i] = id
+		}
+		notifications, err := queryNotifications(tx, `
+			SELECT `+notificationColumns+`
+			FROM notifications
+			WHERE id IN (`+placeholders(len(ids))+`)`, args...)
+		if err != nil {
+			return err
+		}
+
+		// Return them in the order they were asked for
+		byID := make(map[string]*models.Notification, len(notifications))
+		for _, n := range notifications {
+			byID[n.ID] = n
+		}
+		result = make([]*models.Notification, 0, len(ids))
+		for _, id := range ids {
+			result = append(result, byID[id])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MarkAllNotificationsRead marks a user's unread notifications created before
+// the given time read
+func (s *SQLStore) MarkAllNotificationsRead(userID string, before time.Time) ([]*models.Notification, error) {
+	var result []*models.Notification
+	err := s.withTx(func(tx *sql.Tx) error {
+		var err error
+		result, err = queryNotifications(tx, `
+			SELECT `+notificationColumns+`
+			FROM notifications
+			WHERE user_id = ? AND is_read = 0 AND created_at < ?
+			ORDER BY created_at, seq`, userID, before.UnixNano())
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			UPDATE notifications SET is_read = 1
+			WHERE user_id = ? AND is_read = 0 AND created_at < ?`, userID, before.UnixNano())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, n := range result {
+		n.Read = true
+	}
+	return result, nil
+}
+
+// CountUnreadNotifications returns the number of unread notifications for a user
+func (s *SQLStore) CountUnreadNotifications(userID string) (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COALESCE(SUM(unread), 0) FROM notification_counts WHERE user_id = ?`, userID).Scan(&count)
+	return count, err
+}
+
+// GetNotificationCounts returns a user's notification counters, which
+// triggers keep in the notification_counts table
+func (s *SQLStore) GetNotificationCounts(userID string) (*NotificationCounts, error) {
+	rows, err := s.db.Query(`SELECT status, total, unread FROM notification_counts WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := &NotificationCounts{ByStatus: make(map[models.NotificationStatus]int)}
+	for rows.Next() {
+		var status, total, unread int
+		if err := rows.Scan(&status, &total, &unread); err != nil {
+			return nil, err
+		}
+		counts.ByStatus[models.NotificationStatus(status)] = total
+		counts.Total += total
+		counts.Unread += unread
+	}
+	return counts, rows.Err()
+}
+
+// GetUserNotifications returns notifications for a user
+func (s *SQLStore) GetUserNotifications(userID string, limit int) ([]*models.Notification, error) {
+	return queryNotifications(s.db, `
+		SELECT `+notificationColumns+`
+		FROM notifications
+		WHERE user_id = ?
+		ORDER BY created_at DESC, seq DESC
+		LIMIT ?`, userID, limit)
+}
+
+// QueryNotifications returns a page of a user's notifications. Cursors are
+// compared by their (created_at, seq) keyset, which keeps pages stable while
+// new notifications are inserted.
+func (s *SQLStore) QueryNotifications(query NotificationQuery) (*NotificationPage, error) {
+	page := &NotificationPage{}
+	err := s.withTx(func(tx *sql.Tx) error {
+		for _, id := range []string{query.After, query.Before} {
+			if id == "" {
+				continue
+			}
+			var exists int
+			err := tx.QueryRow(`SELECT 1 FROM notifications WHERE id = ? AND user_id = ?`, id, query.UserID).Scan(&exists)
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotificationNotFound
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		// next and prev compare keysets in the order of the query
+		next, prev, order, reverse := "<", ">", "DESC", "ASC"
+		if query.Order == OldestFirst {
+			next, prev, order, reverse = ">", "<", "ASC", "DESC"
+		}
+		keyset := func(op string) string {
+			return ` AND (created_at, seq) ` + op + ` (SELECT created_at, seq FROM notifications WHERE id = ?)`
+		}
+		matching, matchingArgs := notificationFilterSQL(query.UserID, &query.Filter)
+
+		where, args := matching, append([]interface{}{}, matchingArgs...)
+		if query.After != "" {
+			where += keyset(next)
+			args = append(args, query.After)
+		}
+		if query.Before != "" {
+			where += keyset(prev)
+			args = append(args, query.Before)
+		}
+
+		// Only the end of the range is wanted with last alone, so scan from it
+		scan, limit := order, query.First
+		if query.Last > 0 && query.First == 0 {
+			scan, limit = reverse, query.Last
+		}
+		if limit == 0 {
+			limit = -1
+		}
+		notifications, err := queryNotifications(tx, `
+			SELECT `+notificationColumns+`
+			FROM notifications
+			WHERE `+where+`
+			ORDER BY created_at `+scan+`, seq `+scan+`
+			LIMIT ?`, append(args, limit)...)
+		if err != nil {
+			return err
+		}
+		if scan != order {
+			for i, j := 0, len(notifications)-1; i < j; i, j = i+1, j-1 {
+				notifications[i], notifications[j] = notifications[j], notifications[i]
+			}
+		}
+		if query.Last > 0 && len(notifications) > query.Last {
+			notifications = notifications[len(notifications)-query.Last:]
+		}
+		page.Notifications = notifications
+
+		exists := func(cond string, args ...interface{}) (bool, error) {
+			var exists bool
+			err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM notifications WHERE `+matching+cond+`)`,
+				append(append([]interface{}{}, matchingArgs...), args...)...).Scan(&exists)
+			return exists, err
+		}
+		switch {
+		case len(notifications) > 0:
+			if page.HasPrevious, err = exists(keyset(prev), notifications[0].ID); err != nil {
+				return err
+			}
+			page.HasNext, err = exists(keyset(next), notifications[len(notifications)-1].ID)
+		case query.After != "":
+			if page.HasPrevious, err = exists(keyset(prev+"="), query.After); err != nil {
+				return err
+			}
+			page.HasNext, err = exists(keyset(next), query.After)
+		default:
+			page.HasNext, err = exists("")
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// GetPendingNotifications returns all queued or retrying notifications, oldest first
+func (s *SQLStore) GetPendingNotifications() ([]*models.Notification, error) {
+	return queryNotifications(s.db, `
+		SELECT `+notificationColumns+`
+		FROM notifications
+		WHERE status NOT IN (?, ?)
+		ORDER BY created_at, seq`, int(models.StatusDelivered), int(models.StatusFailed))
+}
+
+// SaveDeadLetter stores a dead letter, replacing the one recorded for the
+// same notification
+func (s *SQLStore) SaveDeadLetter(deadLetter *models.DeadLetter) error {
+	attemptedAt, err := json.Marshal(deadLetter.AttemptedAt)
+	if err != nil {
+		return err
+	}
+	replays, err := json.Marshal(deadLetter.Replays)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO dead_letters (`+deadLetterColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (notification_id) DO UPDATE SET
+			user_id = excluded.user_id, reason = excluded.reason, last_error = excluded.last_error,
+			attempts = excluded.attempts, attempted_at = excluded.attempted_at, dead_at = excluded.dead_at,
+			replayed = excluded.replayed, replays = excluded.replays`,
+		deadLetter.NotificationID, deadLetter.UserID, string(deadLetter.Reason), deadLetter.LastError, deadLetter.Attempts,
+		string(attemptedAt), deadLetter.DeadAt.UnixNano(), deadLetter.Replayed, string(replays))
+	return err
+}
+
+// GetDeadLetter retrieves the dead letter of a notification
+func (s *SQLStore) GetDeadLetter(notificationID string) (*models.DeadLetter, error) {
+	deadLetters, err := queryDeadLetters(s.db, `SELECT `+deadLetterColumns+` FROM dead_letters WHERE notification_id = ?`, notificationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(deadLetters) == 0 {
+		return nil, ErrDeadLetterNotFound
+	}
+	return deadLetters[0], nil
+}
+
+// ListDeadLetters returns up to limit dead letters that pass filter, most
+// recently dead first
+func (s *SQLStore) ListDeadLetters(filter DeadLetterFilter, limit int) ([]*models.DeadLetter, error) {
+	where, args := `1 = 1`, []interface{}{}
+	if filter.UserID != "" {
+		where += ` AND user_id = ?`
+		args = append(args, filter.UserID)
+	}
+	if filter.Reason != "" {
+		where += ` AND reason = ?`
+		args = append(args, string(filter.Reason))
+	}
+	if filter.Replayed != nil {
+		where += ` AND replayed = ?`
+		args = append(args, *filter.Replayed)
+	}
+	if !filter.DeadSince.IsZero() {
+		where += ` AND dead_at >= ?`
+		args = append(args, filter.DeadSince.UnixNano())
+	}
+	if !filter.DeadBefore.IsZero() {
+		where += ` AND dead_at < ?`
+		args = append(args, filter.DeadBefore.UnixNano())
+	}
+
+	query := `SELECT ` + deadLetterColumns + ` FROM dead_letters WHERE ` + where + ` ORDER BY dead_at DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	return queryDeadLetters(s.db, query, args...)
+}
+
+// DeleteDeadLetters removes the dead letters of the given notifications
+func (s *SQLStore) DeleteDeadLetters(notificationIDs []string) (int, error) {
+	if len(notificationIDs) == 0 {
+		return 0, nil
+	}
+
+	args := make([]interface{}, len(notificationIDs))
+	for i, id := range notificationIDs {
+		args[i] = id
+	}
+	res, err := s.db.Exec(`DELETE FROM dead_letters WHERE notification_id IN (`+placeholders(len(notificationIDs))+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
+
+// migrate applies every embedded migration newer than the recorded schema version
+func (s *SQLStore) migrate() error {
+	if _, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at INTEGER NOT NULL
+		)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		err := s.withTx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(m.sql); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+				m.version, m.name, time.Now().UnixNano())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("apply migration %04d_%s: %w", m.version, m.name, err)
+		}
+		log.Printf("Applied migration %04d_%s", m.version, m.name)
+	}
+	return nil
+}
+
+// loadMigrations reads the embedded migrations, ordered by version. Files are
+// named <version>_<name>.sql.
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]migration, 0, len(entries))
+	for _, entry := range entries {
+		base := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s is not named <version>_<name>.sql", entry.Name())
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has invalid version: %w", entry.Name(), err)
+		}
+
+		data, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{version: version, name: name, sql: string(data)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
+
+// loadSampleData populates the database with sample data
+func (s *SQLStore) loadSampleData() error {
+	users, posts := SampleData()
+
+	return s.withTx(func(tx *sql.Tx) error {
+		for _, user := range users {
+			if _, err := tx.Exec(`INSERT INTO users (id, username) VALUES (?, ?)`, user.ID, user.Username); err != nil {
+				return err
+			}
+		}
+		for _, user := range users {
+			for _, followerID := range user.FollowerIDs {
+				if _, err := tx.Exec(`INSERT INTO follows (followee_id, follower_id) VALUES (?, ?)`, user.ID, followerID); err != nil {
+					return err
+				}
+			}
+		}
+		for _, post := range posts {
+			if err := insertPost(tx, post); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// withTx runs fn in a transaction, committing if it returns nil
+func (s *SQLStore) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func userExists(q execer, id string) error {
+	var exists int
+	err := q.QueryRow(`SELECT 1 FROM users WHERE id = ?`, id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+func edgeUsersExist(q execer, ids ...string) error {
+	for _, id := range ids {
+		if err := userExists(q, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLStore) queryUsers(query string, args ...interface{}) ([]*models.User, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*models.User, 0)
+	for rows.Next() {
+		user := &models.User{}
+		if err := rows.Scan(&user.ID, &user.Username); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// attachEdges fills FollowerIDs and FollowingIDs for users in two queries
+func (s *SQLStore) attachEdges(users []*models.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*models.User, len(users))
+	args := make([]interface{}, 0, len(users))
+	for _, user := range users {
+		user.FollowerIDs = []string{}
+		user.FollowingIDs = []string{}
+		byID[user.ID] = user
+		args = append(args, user.ID)
+	}
+	in := placeholders(len(args))
+
+	rows, err := s.db.Query(`
+		SELECT followee_id, follower_id FROM follows
+		WHERE followee_id IN (`+in+`) OR follower_id IN (`+in+`)
+		ORDER BY followee_id, follower_id`, append(args, args...)...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var followeeID, followerID string
+		if err := rows.Scan(&followeeID, &followerID); err != nil {
+			return err
+		}
+		if user, ok := byID[followeeID]; ok {
+			user.FollowerIDs = append(user.FollowerIDs, followerID)
+		}
+		if user, ok := byID[followerID]; ok {
+			user.FollowingIDs = append(user.FollowingIDs, followeeID)
+		}
+	}
+	return rows.Err()
+}
+
+const notificationColumns = `id, type, user_id, post_id, author_id, content, created_at, is_read, status, attempts, next_attempt_at, delivery_attempts, delivered_at`
+
+func queryNotifications(q execer, query string, args ...interface{}) ([]*models.Notification, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := make([]*models.Notification, 0)
+	for rows.Next() {
+		n := &models.Notification{}
+		var createdAt, nextAttemptAt, deliveredAt int64
+		var status int
+		var notificationType, deliveryAttempts string
+		if err := rows.Scan(&n.ID, &notificationType, &n.UserID, &n.PostID, &n.AuthorID, &n.Content, &createdAt, &n.Read, &status, &n.Attempts, &nextAttemptAt, &deliveryAttempts, &deliveredAt); err != nil {
+			return nil, err
+		}
+		n.Type = models.NotificationType(notificationType)
+		n.CreatedAt = time.Unix(0, createdAt)
+		n.Status = models.NotificationStatus(status)
+		if nextAttemptAt != 0 {
+			n.NextAttemptAt = time.Unix(0, nextAttemptAt)
+		}
+		if deliveredAt != 0 {
+			n.DeliveredAt = time.Unix(0, deliveredAt)
+		}
+		if err := json.Unmarshal([]byte(deliveryAttempts), &n.DeliveryAttempts); err != nil {
+			return nil, fmt.Errorf("decode delivery attempts of %s: %w", n.ID, err)
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+const deadLetterColumns = `notification_id, user_id, reason, last_error, attempts, attempted_at, dead_at, replayed, replays`
+
+func queryDeadLetters(q execer, query string, args ...interface{}) ([]*models.DeadLetter, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deadLetters := make([]*models.DeadLetter, 0)
+	for rows.Next() {
+		d := &models.DeadLetter{}
+		var reason, attemptedAt, replays string
+		var deadAt int64
+		if err := rows.Scan(&d.NotificationID, &d.UserID, &reason, &d.LastError, &d.Attempts, &attemptedAt, &deadAt, &d.Replayed, &replays); err != nil {
+			return nil, err
+		}
+		d.Reason = models.DeadLetterReason(reason)
+		d.DeadAt = time.Unix(0, deadAt)
+		if err := json.Unmarshal([]byte(attemptedAt), &d.AttemptedAt); err != nil {
+			return nil, fmt.Errorf("decode attempt times of %s: %w", d.NotificationID, err)
+		}
+		if err := json.Unmarshal([]byte(replays), &d.Replays); err != nil {
+			return nil, fmt.Errorf("decode replays of %s: %w", d.NotificationID, err)
+		}
+		deadLetters = append(deadLetters, d)
+	}
+	return deadLetters, rows.Err()
+}
+
+// notificationFilterSQL returns the WHERE clause matching a user's
+// notifications that pass filter
+func notificationFilterSQL(userID string, filter *NotificationFilter) (string, []interface{}) {
+	where, args := `user_id = ?`, []interface{}{userID}
+	if len(filter.Statuses) > 0 {
+		where += ` AND status IN (` + placeholders(len(filter.Statuses)) + `)`
+		for _, status := range filter.Statuses {
+			args = append(args, int(status))
+		}
+	}
+	if filter.Read != nil {
+		read := 0
+		if *filter.Read {
+			read = 1
+		}
+		where += ` AND is_read = ?`
+		args = append(args, read)
+	}
+	if filter.AuthorID != "" {
+		where += ` AND author_id = ?`
+		args = append(args, filter.AuthorID)
+	}
+	if filter.PostID != "" {
+		where += ` AND post_id = ?`
+		args = append(args, filter.PostID)
+	}
+	if !filter.CreatedSince.IsZero() {
+		where += ` AND created_at >= ?`
+		args = append(args, filter.CreatedSince.UnixNano())
+	}
+	if !filter.CreatedBefore.IsZero() {
+		where += ` AND created_at < ?`
+		args = append(args, filter.CreatedBefore.UnixNano())
+	}
+	if !filter.DeliveredAfter.IsZero() {
+		where += ` AND delivered_at > ?`
+		args = append(args, filter.DeliveredAfter.UnixNano())
+	}
+	return where, args
+}
+
+func insertPost(q execer, post *models.Post) error {
+	_, err := q.Exec(`
+		INSERT INTO posts (id, author_id, content, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET author_id = excluded.author_id, content = excluded.content, created_at = excluded.created_at`,
+		post.ID, post.AuthorID, post.Content, post.CreatedAt.UnixNano())
+	return err
+}
+
+func insertNotification(q execer, n *models.Notification) error {
+	deliveryAttempts, err := json.Marshal(n.DeliveryAttempts)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec(`
+		INSERT INTO notifications (`+notificationColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		n.ID, string(n.Type), n.UserID, n.PostID, n.AuthorID, n.Content, n.CreatedAt.UnixNano(), n.Read, int(n.Status), n.Attempts, unixNano(n.NextAttemptAt),
+		string(deliveryAttempts), unixNano(n.DeliveredAt))
+	return err
+}
+
+// unixNano converts an optional time to its column value; zero stays 0
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// placeholders returns "?, ?, ..." with n placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,30 @@
+package store_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/suyashXD/DNDS/internal/store"
+	"github.com/suyashXD/DNDS/internal/store/storetest"
+)
+
+func openSQLStore(t *testing.T, dir string) store.Store {
+	t.Helper()
+	s, err := store.OpenSQLite(filepath.Join(dir, "dnds.sqlite"), true)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	return s
+}
+
+func TestSQLStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		s := openSQLStore(t, t.TempDir())
+		t.Cleanup(func() { s.(*store.SQLStore).Close() })
+		return s
+	})
+}
+
+func TestSQLStorePersistence(t *testing.T) {
+	storetest.RunPersistence(t, openSQLStore)
+}
@@ -0,0 +1,272 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/models"
+)
+
+// Store is the persistence layer used by the gRPC service, the notification
+// queue and the GraphQL resolvers. MemoryStore is the reference implementation;
+// every backend is expected to pass the suite in the storetest package.
+type Store interface {
+	// GetUser retrieves a user by ID
+	GetUser(id string) (*models.User, error)
+
+	// GetUsers retrieves several users at once, keyed by ID. Unknown IDs
+	// are left out of the result.
+	GetUsers(ids []string) (map[string]*models.User, error)
+
+	// GetAllUsers returns all users
+	GetAllUsers() []*models.User
+
+	// ListUsers returns up to limit users whose IDs sort after afterID, in ID
+	// order. An empty afterID starts from the first user.
+	ListUsers(afterID string, limit int) ([]*models.User, error)
+
+	// CreateUser adds a user. Follow edges are managed with Follow, so the
+	// user starts without any. Fails with ErrUserExists if the ID is taken.
+	CreateUser(user *models.User) error
+
+	// UpdateUser updates a user's username
+	UpdateUser(user *models.User) error
+
+	// DeleteUser removes a user, every follow edge they are part of and the
+	// notifications they received. Posts and notifications they authored
+	// are kept with AuthorID cleared.
+	DeleteUser(id string) error
+
+	// GetFollowers returns all followers for a user
+	GetFollowers(userID string) ([]*models.User, error)
+
+	// Follow makes followerID follow followeeID, so the edge shows up in the
+	// followee's FollowerIDs and the follower's FollowingIDs. It is
+	// idempotent; self-follows fail with ErrSelfFollow.
+	Follow(followerID, followeeID string) error
+
+	// Unfollow removes the edge from followerID to followeeID. Removing an
+	// edge that does not exist is a no-op.
+	Unfollow(followerID, followeeID string) error
+
+	// SavePost stores a new post
+	SavePost(post *models.Post) error
+
+	// GetPost retrieves a post by ID
+	GetPost(id string) (*models.Post, error)
+
+	// GetPosts retrieves several posts at once, keyed by ID. Unknown IDs are
+	// left out of the result.
+	GetPosts(ids []string) (map[string]*models.Post, error)
+
+	// SaveNotification adds a notification for a user
+	SaveNotification(notification *models.Notification) error
+
+	// SavePostWithNotifications stores a post together with the notifications
+	// it fans out to. Either all of them are stored or none are.
+	SavePostWithNotifications(post *models.Post, notifications []*models.Notification) error
+
+	// UpdateNotification updates a notification's delivery status, attempts,
+	// delivery history, delivery time and next attempt time. Other fields,
+	// including Read, are left as stored.
+	UpdateNotification(notification *models.Notification) error
+
+	// GetNotification retrieves a notification by ID
+	GetNotification(id string) (*models.Notification, error)
+
+	// MarkNotificationsRead marks the given notifications read and returns
+	// them in the same order. If any ID is unknown nothing is changed and
+	// ErrNotificationNotFound is returned.
+	MarkNotificationsRead(ids []string) ([]*models.Notification, error)
+
+	// MarkAllNotificationsRead marks a user's unread notifications created
+	// before the given time read and returns them, oldest first
+	MarkAllNotificationsRead(userID string, before time.Time) ([]*models.Notification, error)
+
+	// CountUnreadNotifications returns the number of unread notifications
+	// for a user
+	CountUnreadNotifications(userID string) (int, error)
+
+	// GetNotificationCounts returns a user's notification counters, which
+	// are maintained as notifications are written rather than counted on
+	// read. Users without notifications get zero counts.
+	GetNotificationCounts(userID string) (*NotificationCounts, error)
+
+	// GetUserNotifications returns up to limit notifications for a user,
+	// most recent first
+	GetUserNotifications(userID string, limit int) ([]*models.Notification, error)
+
+	// QueryNotifications returns a page of a user's notifications. It fails
+	// with ErrNotificationNotFound if a cursor is not one of the user's
+	// notifications.
+	QueryNotifications(query NotificationQuery) (*NotificationPage, error)
+
+	// GetPendingNotifications returns every notification that has not reached
+	// a terminal status, oldest first, so the queue can resume them on boot
+	GetPendingNotifications() ([]*models.Notification, error)
+
+	// SaveDeadLetter stores a dead letter, replacing the one recorded for the
+	// same notification
+	SaveDeadLetter(deadLetter *models.DeadLetter) error
+
+	// GetDeadLetter retrieves the dead letter of a notification. It fails
+	// with ErrDeadLetterNotFound if there is none.
+	GetDeadLetter(notificationID string) (*models.DeadLetter, error)
+
+	// ListDeadLetters returns up to limit dead letters that pass filter, most
+	// recently dead first. Zero means no limit.
+	ListDeadLetters(filter DeadLetterFilter, limit int) ([]*models.DeadLetter, error)
+
+	// DeleteDeadLetters removes the dead letters of the given notifications
+	// and returns how many existed. Deleting a user removes the dead letters
+	// of the notifications they received as well.
+	DeleteDeadLetters(notificationIDs []string) (int, error)
+}
+
+// NotificationCounts are the counters of the notifications a user received
+type NotificationCounts struct {
+	Total  int
+	Unread int
+	// ByStatus only holds statuses with a non-zero count
+	ByStatus map[models.NotificationStatus]int
+}
+
+// Order is the order a NotificationQuery returns notifications in
+type Order int
+
+const (
+	// NewestFirst orders notifications by descending creation time
+	NewestFirst Order = iota
+	// OldestFirst orders notifications by ascending creation time
+	OldestFirst
+)
+
+// NotificationFilter restricts a NotificationQuery. Zero fields match
+// everything.
+type NotificationFilter struct {
+	// Statuses matches notifications in any of the statuses
+	Statuses []models.NotificationStatus
+	Read     *bool
+	AuthorID string
+	PostID   string
+	// CreatedSince matches notifications created at or after it
+	CreatedSince time.Time
+	// CreatedBefore matches notifications created strictly before it
+	CreatedBefore time.Time
+	// DeliveredAfter matches notifications delivered strictly after it
+	DeliveredAfter time.Time
+}
+
+// Match reports whether n passes the filter
+func (f *NotificationFilter) Match(n *models.Notification) bool {
+	if len(f.Statuses) > 0 {
+		found := false
+		for _, status := range f.Statuses {
+			if n.Status == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Read != nil && n.Read != *f.Read {
+		return false
+	}
+	if f.AuthorID != "" && n.AuthorID != f.AuthorID {
+		return false
+	}
+	if f.PostID != "" && n.PostID != f.PostID {
+		return false
+	}
+	if !f.CreatedSince.IsZero() && n.CreatedAt.Before(f.CreatedSince) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !n.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	if !f.DeliveredAfter.IsZero() && !n.DeliveredAt.After(f.DeliveredAfter) {
+		return false
+	}
+	return true
+}
+
+// NotificationQuery selects a page of the notifications of one user that
+// match Filter, in Order. After and Before are exclusive cursors holding
+// notification IDs, so pages stay put while new notifications arrive or
+// others stop matching: After selects what comes after it in Order, Before
+// what comes before it. First then keeps the first First of the range and
+// Last the last Last, as in the Relay connection spec. Zero means no limit.
+type NotificationQuery struct {
+	UserID string
+	Filter NotificationFilter
+	Order  Order
+	After  string
+	Before string
+	First  int
+	Last   int
+}
+
+// NotificationPage is the result of a NotificationQuery
+type NotificationPage struct {
+	// Notifications are in the order of the query
+	Notifications []*models.Notification
+	// HasNext reports whether matching notifications follow the page, or
+	// for an empty page, follow the After cursor
+	HasNext bool
+	// HasPrevious reports whether matching notifications precede the page,
+	// or for an empty page, precede or are the After cursor
+	HasPrevious bool
+}
+
+// DeadLetterFilter restricts ListDeadLetters. Zero fields match everything.
+type DeadLetterFilter struct {
+	UserID string
+	Reason models.DeadLetterReason
+	// Replayed matches dead letters that have or have not been replayed
+	Replayed *bool
+	// DeadSince matches dead letters recorded at or after it
+	DeadSince time.Time
+	// DeadBefore matches dead letters recorded strictly before it
+	DeadBefore time.Time
+}
+
+// Match reports whether d passes the filter
+func (f *DeadLetterFilter) Match(d *models.DeadLetter) bool {
+	if f.UserID != "" && d.UserID != f.UserID {
+		return false
+	}
+	if f.Reason != "" && d.Reason != f.Reason {
+		return false
+	}
+	if f.Replayed != nil && d.Replayed != *f.Replayed {
+		return false
+	}
+	if !f.DeadSince.IsZero() && d.DeadAt.Before(f.DeadSince) {
+		return false
+	}
+	if !f.DeadBefore.IsZero() && !d.DeadAt.Before(f.DeadBefore) {
+		return false
+	}
+	return true
+}
+
+// sortDeadLetters orders dead letters most recently dead first and keeps the
+// first limit of them; zero means no limit
+func sortDeadLetters(deadLetters []*models.DeadLetter, limit int) []*models.DeadLetter {
+	sort.SliceStable(deadLetters, func(i, j int) bool {
+		return deadLetters[i].DeadAt.After(deadLetters[j].DeadAt)
+	})
+	if limit > 0 && len(deadLetters) > limit {
+		deadLetters = deadLetters[:limit]
+	}
+	return deadLetters
+}
+
+var (
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*FileStore)(nil)
+	_ Store = (*BoltStore)(nil)
+	_ Store = (*SQLStore)(nil)
+)
@@ -0,0 +1,1327 @@
+// Package storetest provides a conformance suite that every store.Store
+// backend runs against itself.
+package storetest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/suyashXD/DNDS/internal/models"
+	"github.com/suyashXD/DNDS/internal/store"
+)
+
+// Factory returns a fresh store seeded with store.SampleData. It is called
+// once per subtest, so backends that need cleanup should register it with
+// t.Cleanup.
+type Factory func(t *testing.T) store.Store
+
+// Run exercises the full store.Store contract against the backend produced by
+// newStore.
+func Run(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s store.Store)
+	}{
+		{"GetUser", testGetUser},
+		{"GetUsers", testGetUsers},
+		{"GetAllUsers", testGetAllUsers},
+		{"GetFollowers", testGetFollowers},
+		{"FollowGraphConsistent", testFollowGraphConsistent},
+		{"Follow", testFollow},
+		{"ConcurrentFollows", testConcurrentFollows},
+		{"ListUsers", testListUsers},
+		{"CreateUser", testCreateUser},
+		{"UpdateUser", testUpdateUser},
+		{"DeleteUser", testDeleteUser},
+		{"Posts", testPosts},
+		{"GetPosts", testGetPosts},
+		{"SaveNotification", testSaveNotification},
+		{"SavePostWithNotifications", testSavePostWithNotifications},
+		{"UpdateNotification", testUpdateNotification},
+		{"UpdateNotificationKeepsRead", testUpdateNotificationKeepsRead},
+		{"GetNotification", testGetNotification},
+		{"MarkNotificationsRead", testMarkNotificationsRead},
+		{"MarkAllNotificationsRead", testMarkAllNotificationsRead},
+		{"GetUserNotificationsLimit", testGetUserNotificationsLimit},
+		{"QueryNotifications", testQueryNotifications},
+		{"QueryNotificationsFilter", testQueryNotificationsFilter},
+		{"NotificationCounts", testNotificationCounts},
+		{"GetPendingNotifications", testGetPendingNotifications},
+		{"DeadLetters", testDeadLetters},
+		{"ConcurrentWrites", testConcurrentWrites},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+func testGetUser(t *testing.T, s store.Store) {
+	user, err := s.GetUser("user1")
+	if err != nil {
+		t.Fatalf("GetUser(user1): %v", err)
+	}
+	if user.Username != "alice" {
+		t.Errorf("GetUser(user1).Username = %q, want %q", user.Username, "alice")
+	}
+
+	if _, err := s.GetUser("nobody"); !errors.Is(err, store.ErrUserNotFound) {
+		t.Errorf("GetUser(nobody) error = %v, want %v", err, store.ErrUserNotFound)
+	}
+}
+
+func testGetUsers(t *testing.T, s store.Store) {
+	users, err := s.GetUsers([]string{"user1", "nobody", "user2"})
+	if err != nil {
+		t.Fatalf("GetUsers: %v", err)
+	}
+	if len(users) != 2 || users["user1"] == nil || users["user2"] == nil {
+		t.Fatalf("GetUsers returned %v, want user1 and user2", users)
+	}
+	want, err := s.GetUser("user1")
+	if err != nil {
+		t.Fatalf("GetUser(user1): %v", err)
+	}
+	got := users["user1"]
+	if got.Username != want.Username {
+		t.Errorf("GetUsers[user1].Username = %q, want %q", got.Username, want.Username)
+	}
+	assertIDSet(t, "GetUsers[user1].FollowerIDs", got.FollowerIDs, want.FollowerIDs...)
+	assertIDSet(t, "GetUsers[user1].FollowingIDs", got.FollowingIDs, want.FollowingIDs...)
+
+	if users, err := s.GetUsers(nil); err != nil || len(users) != 0 {
+		t.Errorf("GetUsers(nil) = %v, %v, want an empty map", users, err)
+	}
+}
+
+func testGetAllUsers(t *testing.T, s store.Store) {
+	sampleUsers, _ := store.SampleData()
+
+	users := s.GetAllUsers()
+	if len(users) != len(sampleUsers) {
+		t.Fatalf("GetAllUsers returned %d users, want %d", len(users), len(sampleUsers))
+	}
+
+	seen := make(map[string]bool, len(users))
+	for _, user := range users {
+		seen[user.ID] = true
+	}
+	for _, user := range sampleUsers {
+		if !seen[user.ID] {
+			t.Errorf("GetAllUsers is missing %s", user.ID)
+		}
+	}
+}
+
+func testGetFollowers(t *testing.T, s store.Store) {
+	followers, err := s.GetFollowers("user2")
+	if err != nil {
+		t.Fatalf("GetFollowers(user2): %v", err)
+	}
+	assertUserIDs(t, followers, "user1", "user3", "user5")
+
+	followers, err = s.GetFollowers("user7")
+	if err != nil {
+		t.Fatalf("GetFollowers(user7): %v", err)
+	}
+	if len(followers) != 0 {
+		t.Errorf("GetFollowers(user7) returned %d followers, want 0", len(followers))
+	}
+
+	if _, err := s.GetFollowers("nobody"); !errors.Is(err, store.ErrUserNotFound) {
+		t.Errorf("GetFollowers(nobody) error = %v, want %v", err, store.ErrUserNotFound)
+	}
+}
+
+func testFollowGraphConsistent(t *testing.T, s store.Store) {
+	assertFollowGraphConsistent(t, s)
+
+	user2, err := s.GetUser("user2")
+	if err != nil {
+		t.Fatalf("GetUser(user2): %v", err)
+	}
+	assertIDSet(t, "user2 following", user2.FollowingIDs, "user1", "user3", "user4")
+}
+
+func testFollow(t *testing.T, s store.Store) {
+	// user6 has no followers in the sample data
+	for i := 0; i < 2; i++ {
+		if err := s.Follow("user7", "user6"); err != nil {
+			t.Fatalf("Follow #%d: %v", i, err)
+		}
+	}
+	followers, err := s.GetFollowers("user6")
+	if err != nil {
+		t.Fatalf("GetFollowers(user6): %v", err)
+	}
+	assertUserIDs(t, followers, "user7")
+	user7, err := s.GetUser("user7")
+	if err != nil {
+		t.Fatalf("GetUser(user7): %v", err)
+	}
+	assertIDSet(t, "user7 following", user7.FollowingIDs, "user1", "user4", "user6")
+	assertFollowGraphConsistent(t, s)
+
+	if err := s.Follow("user6", "user6"); !errors.Is(err, store.ErrSelfFollow) {
+		t.Errorf("Follow(user6, user6) error = %v, want %v", err, store.ErrSelfFollow)
+	}
+	if err := s.Follow("user6", "nobody"); !errors.Is(err, store.ErrUserNotFound) {
+		t.Errorf("Follow(user6, nobody) error = %v, want %v", err, store.ErrUserNotFound)
+	}
+	if err := s.Follow("nobody", "user6"); !errors.Is(err, store.ErrUserNotFound) {
+		t.Errorf("Follow(nobody, user6) error = %v, want %v", err, store.ErrUserNotFound)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := s.Unfollow("user7", "user6"); err != nil {
+			t.Fatalf("Unfollow #%d: %v", i, err)
+		}
+	}
+	followers, err = s.GetFollowers("user6")
+	if err != nil {
+		t.Fatalf("GetFollowers(user6): %v", err)
+	}
+	assertUserIDs(t, followers)
+	assertFollowGraphConsistent(t, s)
+
+	if err := s.Unfollow("user6", "user6"); !errors.Is(err, store.ErrSelfFollow) {
+		t.Errorf("Unfollow(user6, user6) error = %v, want %v", err, store.ErrSelfFollow)
+	}
+}
+
+func testConcurrentFollows(t *testing.T, s store.Store) {
+	users := s.GetAllUsers()
+
+	// Every goroutine toggles every edge and ends on a follow, so the graph
+	// must end up complete
+	var wg sync.WaitGroup
+	errs := make(chan error, len(users)*len(users)*3)
+	for _, follower := range users {
+		wg.Add(1)
+		go func(followerID string) {
+			defer wg.Done()
+			for _, followee := range users {
+				if followee.ID == followerID {
+					continue
+				}
+				for _, err := range []error{
+					s.Follow(followerID, followee.ID),
+					s.Unfollow(followerID, followee.ID),
+					s.Follow(followerID, followee.ID),
+				} {
+					if err != nil {
+						errs <- err
+					}
+				}
+			}
+		}(follower.ID)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("concurrent follow: %v", err)
+	}
+
+	for _, user := range s.GetAllUsers() {
+		if len(user.FollowerIDs) != len(users)-1 || len(user.FollowingIDs) != len(users)-1 {
+			t.Errorf("%s has %d followers and follows %d, want %d each",
+				user.ID, len(user.FollowerIDs), len(user.FollowingIDs), len(users)-1)
+		}
+	}
+	assertFollowGraphConsistent(t, s)
+}
+
+func testListUsers(t *testing.T, s store.Store) {
+	page, err := s.ListUsers("", 3)
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+	assertUserOrder(t, page, "user1", "user2", "user3")
+	if len(page[0].FollowerIDs) != 6 {
+		t.Errorf("ListUsers()[0] has %d followers, want 6", len(page[0].FollowerIDs))
+	}
+
+	page, err = s.ListUsers("user3", 3)
+	if err != nil {
+		t.Fatalf("ListUsers(user3): %v", err)
+	}
+	assertUserOrder(t, page, "user4", "user5", "user6")
+
+	page, err = s.ListUsers("user6", 3)
+	if err != nil {
+		t.Fatalf("ListUsers(user6): %v", err)
+	}
+	assertUserOrder(t, page, "user7")
+
+	// The cursor does not have to be an existing user
+	page, err = s.ListUsers("user45", 10)
+	if err != nil {
+		t.Fatalf("ListUsers(user45): %v", err)
+	}
+	assertUserOrder(t, page, "user5", "user6", "user7")
+}
+
+func testCreateUser(t *testing.T, s store.Store) {
+	user := &models.User{
+		ID:          "user8",
+		Username:    "heidi",
+		FollowerIDs: []string{"user1"}, // ignored; edges go through Follow
+	}
+	if err := s.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	got, err := s.GetUser("user8")
+	if err != nil {
+		t.Fatalf("GetUser(user8): %v", err)
+	}
+	if got.Username != "heidi" || len(got.FollowerIDs) != 0 || len(got.FollowingIDs) != 0 {
+		t.Errorf("GetUser(user8) = %+v, want heidi without edges", got)
+	}
+	assertFollowGraphConsistent(t, s)
+
+	if err := s.CreateUser(&models.User{ID: "user8", Username: "impostor"}); !errors.Is(err, store.ErrUserExists) {
+		t.Errorf("CreateUser(user8) again error = %v, want %v", err, store.ErrUserExists)
+	}
+	if got, _ := s.GetUser("user8"); got == nil || got.Username != "heidi" {
+		t.Errorf("failed CreateUser changed the user to %+v", got)
+	}
+}
+
+func testUpdateUser(t *testing.T, s store.Store) {
+	if err := s.UpdateUser(&models.User{ID: "user2", Username: "robert"}); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+
+	got, err := s.GetUser("user2")
+	if err != nil {
+		t.Fatalf("GetUser(user2): %v", err)
+	}
+	if got.Username != "robert" {
+		t.Errorf("GetUser(user2).Username = %q, want %q", got.Username, "robert")
+	}
+	// Edges are not part of the update
+	assertIDSet(t, "user2 followers", got.FollowerIDs, "user1", "user3", "user5")
+
+	if err := s.UpdateUser(&models.User{ID: "nobody", Username: "x"}); !errors.Is(err, store.ErrUserNotFound) {
+		t.Errorf("UpdateUser(nobody) error = %v, want %v", err, store.ErrUserNotFound)
+	}
+}
+
+func testDeleteUser(t *testing.T, s store.Store) {
+	// user2 follows user1, user3 and user4 and is followed by user1, user3
+	// and user5
+	received := newNotification("user2", "post1", time.Now())
+	authored := newNotification("user3", "post2", time.Now())
+	authored.AuthorID = "user2"
+	for _, n := range []*models.Notification{received, authored} {
+		if err := s.SaveNotification(n); err != nil {
+			t.Fatalf("SaveNotification: %v", err)
+		}
+	}
+
+	if err := s.DeleteUser("user2"); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+
+	if _, err := s.GetUser("user2"); !errors.Is(err, store.ErrUserNotFound) {
+		t.Errorf("GetUser(user2) after delete error = %v, want %v", err, store.ErrUserNotFound)
+	}
+	for _, user := range s.GetAllUsers() {
+		if hasID(user.FollowerIDs, "user2") || hasID(user.FollowingIDs, "user2") {
+			t.Errorf("%s still has an edge to user2: %+v", user.ID, user)
+		}
+	}
+	assertFollowGraphConsistent(t, s)
+
+	if _, err := s.GetNotification(received.ID); !errors.Is(err, store.ErrNotificationNotFound) {
+		t.Errorf("GetNotification(received) after delete error = %v, want %v", err, store.ErrNotificationNotFound)
+	}
+	got, err := s.GetNotification(authored.ID)
+	if err != nil {
+		t.Fatalf("GetNotification(authored): %v", err)
+	}
+	if got.AuthorID != "" {
+		t.Errorf("authored notification AuthorID = %q, want it cleared", got.AuthorID)
+	}
+	post, err := s.GetPost("post2")
+	if err != nil {
+		t.Fatalf("GetPost(post2): %v", err)
+	}
+	if post.AuthorID != "" {
+		t.Errorf("post2 AuthorID = %q, want it cleared", post.AuthorID)
+	}
+
+	pending, err := s.GetPendingNotifications()
+	if err != nil {
+		t.Fatalf("GetPendingNotifications: %v", err)
+	}
+	assertNotificationIDs(t, pending, authored.ID)
+
+	if err := s.DeleteUser("user2"); !errors.Is(err, store.ErrUserNotFound) {
+		t.Errorf("DeleteUser(user2) again error = %v, want %v", err, store.ErrUserNotFound)
+	}
+}
+
+func testGetPosts(t *testing.T, s store.Store) {
+	posts, err := s.GetPosts([]string{"post1", "nope", "post2"})
+	if err != nil {
+		t.Fatalf("GetPosts: %v", err)
+	}
+	if len(posts) != 2 || posts["post1"] == nil || posts["post2"] == nil {
+		t.Fatalf("GetPosts returned %v, want post1 and post2", posts)
+	}
+	want, err := s.GetPost("post1")
+	if err != nil {
+		t.Fatalf("GetPost(post1): %v", err)
+	}
+	if got := posts["post1"]; got.AuthorID != want.AuthorID || got.Content != want.Content {
+		t.Errorf("GetPosts[post1] = %+v, want %+v", got, want)
+	}
+
+	if posts, err := s.GetPosts(nil); err != nil || len(posts) != 0 {
+		t.Errorf("GetPosts(nil) = %v, %v, want an empty map", posts, err)
+	}
+}
+
+func testPosts(t *testing.T, s store.Store) {
+	post := &models.Post{
+		ID:        "conformance-post",
+		AuthorID:  "user1",
+		Content:   "conformance",
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	if err := s.SavePost(post); err != nil {
+		t.Fatalf("SavePost: %v", err)
+	}
+
+	got, err := s.GetPost(post.ID)
+	if err != nil {
+		t.Fatalf("GetPost: %v", err)
+	}
+	if got.AuthorID != post.AuthorID || got.Content != post.Content || !got.CreatedAt.Equal(post.CreatedAt) {
+		t.Errorf("GetPost = %+v, want %+v", got, post)
+	}
+
+	if _, err := s.GetPost("missing"); !errors.Is(err, store.ErrPostNotFound) {
+		t.Errorf("GetPost(missing) error = %v, want %v", err, store.ErrPostNotFound)
+	}
+}
+
+func testSaveNotification(t *testing.T, s store.Store) {
+	n := newNotification("user2", "post1", time.Now())
+	if err := s.SaveNotification(n); err != nil {
+		t.Fatalf("SaveNotification: %v", err)
+	}
+
+	got, err := s.GetUserNotifications("user2", 10)
+	if err != nil {
+		t.Fatalf("GetUserNotifications: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("GetUserNotifications returned %d notifications, want 1", len(got))
+	}
+	if got[0].ID != n.ID || got[0].PostID != n.PostID || got[0].AuthorID != n.AuthorID {
+		t.Errorf("GetUserNotifications()[0] = %+v, want %+v", got[0], n)
+	}
+	if got[0].Status != models.StatusQueued {
+		t.Errorf("saved notification status = %v, want %v", got[0].Status, models.StatusQueued)
+	}
+
+	others, err := s.GetUserNotifications("user3", 10)
+	if err != nil {
+		t.Fatalf("GetUserNotifications(user3): %v", err)
+	}
+	if len(others) != 0 {
+		t.Errorf("GetUserNotifications(user3) returned %d notifications, want 0", len(others))
+	}
+}
+
+func testSavePostWithNotifications(t *testing.T, s store.Store) {
+	post := &models.Post{ID: "fanout-post", AuthorID: "user2", Content: "fan-out", CreatedAt: time.Now()}
+	followers, err := s.GetFollowers(post.AuthorID)
+	if err != nil {
+		t.Fatalf("GetFollowers: %v", err)
+	}
+
+	notifications := make([]*models.Notification, 0, len(followers))
+	for _, follower := range followers {
+		notifications = append(notifications, newNotification(follower.ID, post.ID, post.CreatedAt))
+	}
+	if err := s.SavePostWithNotifications(post, notifications); err != nil {
+		t.Fatalf("SavePostWithNotifications: %v", err)
+	}
+
+	if _, err := s.GetPost(post.ID); err != nil {
+		t.Errorf("GetPost after fan-out: %v", err)
+	}
+	for _, n := range notifications {
+		got, err := s.GetUserNotifications(n.UserID, 1)
+		if err != nil {
+			t.Fatalf("GetUserNotifications(%s): %v", n.UserID, err)
+		}
+		assertNotificationIDs(t, got, n.ID)
+	}
+}
+
+func testUpdateNotification(t *testing.T, s store.Store) {
+	n := newNotification("user2", "post1", time.Now())
+	if err := s.SaveNotification(n); err != nil {
+		t.Fatalf("SaveNotification: %v", err)
+	}
+
+	updated := *n
+	updated.Status = models.StatusRetrying
+	updated.Attempts = 2
+	updated.NextAttemptAt = time.Now().Add(time.Minute)
+	started := time.Now().Add(-time.Second)
+	updated.DeliveryAttempts = []models.DeliveryAttempt{
+		{Attempt: 1, Channel: "webhook", StartedAt: started, FinishedAt: started.Add(time.Millisecond), Outcome: models.OutcomeRetrying, Error: "status 503", ErrorClass: "http_5xx", Backoff: 100 * time.Millisecond},
+		{Attempt: 2, Channel: "webhook", StartedAt: started.Add(500 * time.Millisecond), FinishedAt: started.Add(501 * time.Millisecond), Outcome: models.OutcomeRetrying, Error: "status 503", ErrorClass: "http_5xx", Backoff: 200 * time.Millisecond},
+	}
+	if err := s.UpdateNotification(&updated); err != nil {
+		t.Fatalf("UpdateNotification: %v", err)
+	}
+
+	got, err := s.GetUserNotifications("user2", 1)
+	if err != nil {
+		t.Fatalf("GetUserNotifications: %v", err)
+	}
+	if len(got) != 1 || got[0].Status != models.StatusRetrying || got[0].Attempts != 2 || !got[0].NextAttemptAt.Equal(updated.NextAttemptAt) {
+		t.Errorf("after UpdateNotification got %+v, want status %v with 2 attempts due at %v", got, models.StatusRetrying, updated.NextAttemptAt)
+	}
+	if len(got) == 1 && got[0].Type != models.TypeNewPost {
+		t.Errorf("after UpdateNotification got type %q, want %q", got[0].Type, models.TypeNewPost)
+	}
+	if len(got) == 1 {
+		assertDeliveryAttempts(t, got[0].DeliveryAttempts, updated.DeliveryAttempts)
+	}
+
+	missing := newNotification("user2", "post1", time.Now())
+	if err := s.UpdateNotification(missing); err == nil {
+		t.Error("UpdateNotification of an unsaved notification succeeded, want error")
+	}
+}
+
+// assertDeliveryAttempts compares delivery histories with time.Equal, since
+// backends may not keep a time's location
+func assertDeliveryAttempts(t *testing.T, got, want []models.DeliveryAttempt) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("got %d delivery attempts, want %d", len(got), len(want))
+		return
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Attempt != w.Attempt || g.Channel != w.Channel || !g.StartedAt.Equal(w.StartedAt) || !g.FinishedAt.Equal(w.FinishedAt) ||
+			g.Outcome != w.Outcome || g.Error != w.Error || g.ErrorClass != w.ErrorClass || g.Backoff != w.Backoff {
+			t.Errorf("delivery attempt %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func testUpdateNotificationKeepsRead(t *testing.T, s store.Store) {
+	n := newNotification("user2", "post1", time.Now())
+	if err := s.SaveNotification(n); err != nil {
+		t.Fatalf("SaveNotification: %v", err)
+	}
+	if _, err := s.MarkNotificationsRead([]string{n.ID}); err != nil {
+		t.Fatalf("MarkNotificationsRead: %v", err)
+	}
+
+	// n is now a stale copy that still says unread, like the one a queue
+	// worker holds
+	n.Status = models.StatusDelivered
+	n.DeliveredAt = time.Now()
+	if err := s.UpdateNotification(n); err != nil {
+		t.Fatalf("UpdateNotification: %v", err)
+	}
+
+	got, err := s.GetNotification(n.ID)
+	if err != nil {
+		t.Fatalf("GetNotification: %v", err)
+	}
+	if !got.Read || got.Status != models.StatusDelivered || !got.DeliveredAt.Equal(n.DeliveredAt) {
+		t.Errorf("after UpdateNotification got read=%v status=%v delivered at %v, want read delivered at %v", got.Read, got.Status, got.DeliveredAt, n.DeliveredAt)
+	}
+}
+
+func testGetNotification(t *testing.T, s store.Store) {
+	n := newNotification("user3", "post1", time.Now())
+	if err := s.SaveNotification(n); err != nil {
+		t.Fatalf("SaveNotification: %v", err)
+	}
+
+	got, err := s.GetNotification(n.ID)
+	if err != nil {
+		t.Fatalf("GetNotification: %v", err)
+	}
+	if got.ID != n.ID || got.UserID != n.UserID || got.PostID != n.PostID || !got.CreatedAt.Equal(n.CreatedAt) {
+		t.Errorf("GetNotification = %+v, want %+v", got, n)
+	}
+
+	if _, err := s.GetNotification("missing"); !errors.Is(err, store.ErrNotificationNotFound) {
+		t.Errorf("GetNotification(missing) error = %v, want %v", err, store.ErrNotificationNotFound)
+	}
+}
+
+func testMarkNotificationsRead(t *testing.T, s store.Store) {
+	base := time.Now().Add(-time.Hour)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		n := newNotification("user2", "post1", base.Add(time.Duration(i)*time.Second))
+		if err := s.SaveNotification(n); err != nil {
+			t.Fatalf("SaveNotification: %v", err)
+		}
+		ids = append(ids, n.ID)
+	}
+
+	got, err := s.MarkNotificationsRead([]string{ids[2], ids[0]})
+	if err != nil {
+		t.Fatalf("MarkNotificationsRead: %v", err)
+	}
+	assertNotificationIDs(t, got, ids[2], ids[0])
+	for _, n := range got {
+		if !n.Read {
+			t.Errorf("returned notification %s is not read", n.ID)
+		}
+	}
+	assertUnread(t, s, "user2", 1)
+
+	// Marking again is a no-op
+	if _, err := s.MarkNotificationsRead([]string{ids[0]}); err != nil {
+		t.Fatalf("MarkNotificationsRead again: %v", err)
+	}
+	assertUnread(t, s, "user2", 1)
+
+	// An unknown ID fails the whole call
+	if _, err := s.MarkNotificationsRead([]string{ids[1], "missing"}); !errors.Is(err, store.ErrNotificationNotFound) {
+		t.Errorf("MarkNotificationsRead with unknown ID error = %v, want %v", err, store.ErrNotificationNotFound)
+	}
+	assertUnread(t, s, "user2", 1)
+}
+
+func testMarkAllNotificationsRead(t *testing.T, s store.Store) {
+	// Truncated like newNotification's timestamps, so the cutoff below lands
+	// exactly on ids[2]
+	base := time.Now().UTC().Truncate(time.Millisecond).Add(-time.Hour)
+	var ids []string
+	for i := 0; i < 4; i++ {
+		n := newNotification("user5", "post1", base.Add(time.Duration(i)*time.Minute))
+		if err := s.SaveNotification(n); err != nil {
+			t.Fatalf("SaveNotification: %v", err)
+		}
+		ids = append(ids, n.ID)
+	}
+	other := newNotification("user6", "post1", base)
+	if err := s.SaveNotification(other); err != nil {
+		t.Fatalf("SaveNotification: %v", err)
+	}
+	if _, err := s.MarkNotificationsRead([]string{ids[1]}); err != nil {
+		t.Fatalf("MarkNotificationsRead: %v", err)
+	}
+
+	// Only unread notifications strictly before the cutoff are returned
+	got, err := s.MarkAllNotificationsRead("user5", base.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("MarkAllNotificationsRead: %v", err)
+	}
+	assertNotificationIDs(t, got, ids[0])
+	assertUnread(t, s, "user5", 2)
+
+	got, err = s.MarkAllNotificationsRead("user5", time.Now())
+	if err != nil {
+		t.Fatalf("MarkAllNotificationsRead: %v", err)
+	}
+	assertNotificationIDs(t, got, ids[2], ids[3])
+	assertUnread(t, s, "user5", 0)
+	assertUnread(t, s, "user6", 1)
+}
+
+func testGetUserNotificationsLimit(t *testing.T, s store.Store) {
+	base := time.Now().Add(-time.Hour)
+	var ids []string
+	for i := 0; i < 5; i++ {
+		n := newNotification("user3", "post1", base.Add(time.Duration(i)*time.Second))
+		if err := s.SaveNotification(n); err != nil {
+			t.Fatalf("SaveNotification #%d: %v", i, err)
+		}
+		ids = append(ids, n.ID)
+	}
+
+	got, err := s.GetUserNotifications("user3", 3)
+	if err != nil {
+		t.Fatalf("GetUserNotifications: %v", err)
+	}
+	want := []string{ids[4], ids[3], ids[2]}
+	assertNotificationIDs(t, got, want...)
+}
+
+func testQueryNotifications(t *testing.T, s store.Store) {
+	base := time.Now().UTC().Truncate(time.Millisecond).Add(-time.Hour)
+	var ids []string
+	for i := 0; i < 6; i++ {
+		n := newNotification("user3", "post1", base.Add(time.Duration(i)*time.Second))
+		if err := s.SaveNotification(n); err != nil {
+			t.Fatalf("SaveNotification #%d: %v", i, err)
+		}
+		ids = append(ids, n.ID)
+	}
+	other := newNotification("user2", "post1", base)
+	if err := s.SaveNotification(other); err != nil {
+		t.Fatalf("SaveNotification: %v", err)
+	}
+
+	tests := []struct {
+		name                 string
+		query                store.NotificationQuery
+		want                 []string
+		hasPrevious, hasNext bool
+	}{
+		{"All", store.NotificationQuery{}, []string{ids[5], ids[4], ids[3], ids[2], ids[1], ids[0]}, false, false},
+		{"First", store.NotificationQuery{First: 2}, []string{ids[5], ids[4]}, false, true},
+		{"FirstAfter", store.NotificationQuery{First: 2, After: ids[4]}, []string{ids[3], ids[2]}, true, true},
+		{"FirstAfterShort", store.NotificationQuery{First: 2, After: ids[1]}, []string{ids[0]}, true, false},
+		{"Last", store.NotificationQuery{Last: 2}, []string{ids[1], ids[0]}, true, false},
+		{"LastBefore", store.NotificationQuery{Last: 2, Before: ids[1]}, []string{ids[3], ids[2]}, true, true},
+		{"Before", store.NotificationQuery{Before: ids[4]}, []string{ids[5]}, false, true},
+		{"AfterBefore", store.NotificationQuery{After: ids[5], Before: ids[2]}, []string{ids[4], ids[3]}, true, true},
+		{"FirstAndLast", store.NotificationQuery{First: 4, Last: 2}, []string{ids[3], ids[2]}, true, true},
+		{"AfterOldest", store.NotificationQuery{After: ids[0]}, nil, true, false},
+		{"BeforeNewest", store.NotificationQuery{Before: ids[5]}, nil, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.UserID = "user3"
+			page, err := s.QueryNotifications(tt.query)
+			if err != nil {
+				t.Fatalf("QueryNotifications: %v", err)
+			}
+			assertNotificationIDs(t, page.Notifications, tt.want...)
+			if page.HasPrevious != tt.hasPrevious || page.HasNext != tt.hasNext {
+				t.Errorf("HasPrevious, HasNext = %v, %v, want %v, %v", page.HasPrevious, page.HasNext, tt.hasPrevious, tt.hasNext)
+			}
+		})
+	}
+
+	// Cursors stay put when newer notifications arrive
+	if err := s.SaveNotification(newNotification("user3", "post1", base.Add(time.Minute))); err != nil {
+		t.Fatalf("SaveNotification: %v", err)
+	}
+	page, err := s.QueryNotifications(store.NotificationQuery{UserID: "user3", First: 2, After: ids[4]})
+	if err != nil {
+		t.Fatalf("QueryNotifications after insert: %v", err)
+	}
+	assertNotificationIDs(t, page.Notifications, ids[3], ids[2])
+
+	for _, cursor := range []string{"nope", other.ID} {
+		_, err := s.QueryNotifications(store.NotificationQuery{UserID: "user3", After: cursor})
+		if !errors.Is(err, store.ErrNotificationNotFound) {
+			t.Errorf("QueryNotifications(after %s) error = %v, want %v", cursor, err, store.ErrNotificationNotFound)
+		}
+	}
+}
+
+func testQueryNotificationsFilter(t *testing.T, s store.Store) {
+	base := time.Now().UTC().Truncate(time.Millisecond).Add(-time.Hour)
+	specs := []struct {
+		postID, authorID string
+		status           models.NotificationStatus
+	}{
+		{"post1", "user1", models.StatusDelivered},
+		{"post2", "user2", models.StatusFailed},
+		{"post1", "user1", models.StatusFailed},
+		{"post3", "user2", models.StatusQueued},
+		{"post2", "user2", models.StatusFailed},
+		{"post1", "user1", models.StatusDelivered},
+	}
+	var ids []string
+	for i, spec := range specs {
+		n := newNotification("user4", spec.postID, base.Add(time.Duration(i)*time.Second))
+		n.AuthorID, n.Status = spec.authorID, spec.status
+		if spec.status == models.StatusDelivered {
+			// Delivered in the reverse of creation order
+			n.DeliveredAt = base.Add(time.Duration(10-i) * time.Second)
+		}
+		if err := s.SaveNotification(n); err != nil {
+			t.Fatalf("SaveNotification #%d: %v", i, err)
+		}
+		ids = append(ids, n.ID)
+	}
+	if _, err := s.MarkNotificationsRead([]string{ids[2], ids[4]}); err != nil {
+		t.Fatalf("MarkNotificationsRead: %v", err)
+	}
+
+	read, unread := true, false
+	failed := []models.NotificationStatus{models.StatusFailed}
+	tests := []struct {
+		name                 string
+		query                store.NotificationQuery
+		want                 []string
+		hasPrevious, hasNext bool
+	}{
+		{"Status", store.NotificationQuery{Filter: store.NotificationFilter{Statuses: failed}},
+			[]string{ids[4], ids[2], ids[1]}, false, false},
+		{"StatusOldestFirst", store.NotificationQuery{Filter: store.NotificationFilter{Statuses: failed}, Order: store.OldestFirst},
+			[]string{ids[1], ids[2], ids[4]}, false, false},
+		{"Statuses", store.NotificationQuery{Filter: store.NotificationFilter{Statuses: []models.NotificationStatus{models.StatusFailed, models.StatusDelivered}}, First: 2},
+			[]string{ids[5], ids[4]}, false, true},
+		{"NoMatch", store.NotificationQuery{Filter: store.NotificationFilter{Statuses: []models.NotificationStatus{models.StatusRetrying}}},
+			nil, false, false},
+		{"UnreadFromAuthor", store.NotificationQuery{Filter: store.NotificationFilter{Read: &unread, AuthorID: "user2"}},
+			[]string{ids[3], ids[1]}, false, false},
+		{"ReadOldestFirst", store.NotificationQuery{Filter: store.NotificationFilter{Read: &read}, Order: store.OldestFirst},
+			[]string{ids[2], ids[4]}, false, false},
+		{"Post", store.NotificationQuery{Filter: store.NotificationFilter{PostID: "post1"}},
+			[]string{ids[5], ids[2], ids[0]}, false, false},
+		{"CreatedRange", store.NotificationQuery{Filter: store.NotificationFilter{CreatedSince: base.Add(2 * time.Second), CreatedBefore: base.Add(5 * time.Second)}},
+			[]string{ids[4], ids[3], ids[2]}, false, false},
+		{"DeliveredAfter", store.NotificationQuery{Filter: store.NotificationFilter{DeliveredAfter: base.Add(4 * time.Second)}},
+			[]string{ids[5], ids[0]}, false, false},
+		{"DeliveredAfterIsExclusive", store.NotificationQuery{Filter: store.NotificationFilter{DeliveredAfter: base.Add(5 * time.Second)}},
+			[]string{ids[0]}, false, false},
+		{"DeliveredAfterOldestFirstAfter", store.NotificationQuery{Filter: store.NotificationFilter{DeliveredAfter: base.Add(4 * time.Second)}, Order: store.OldestFirst, After: ids[0]},
+			[]string{ids[5]}, true, false},
+		{"DeliveredAfterLatest", store.NotificationQuery{Filter: store.NotificationFilter{DeliveredAfter: base.Add(10 * time.Second)}},
+			nil, false, false},
+		{"OldestFirstAfter", store.NotificationQuery{Filter: store.NotificationFilter{Statuses: failed}, Order: store.OldestFirst, After: ids[1], First: 1},
+			[]string{ids[2]}, true, true},
+		{"OldestFirstLast", store.NotificationQuery{Filter: store.NotificationFilter{Statuses: failed}, Order: store.OldestFirst, Last: 1},
+			[]string{ids[4]}, true, false},
+		{"AfterNonMatching", store.NotificationQuery{Filter: store.NotificationFilter{Statuses: failed}, After: ids[3]},
+			[]string{ids[2], ids[1]}, true, false},
+		{"Before", store.NotificationQuery{Filter: store.NotificationFilter{Statuses: failed}, Before: ids[2]},
+			[]string{ids[4]}, false, true},
+		{"AfterLast", store.NotificationQuery{Filter: store.NotificationFilter{Statuses: failed}, After: ids[1]},
+			nil, true, false},
+		{"AfterBeforeFirstMatch", store.NotificationQuery{Filter: store.NotificationFilter{Statuses: failed}, After: ids[5]},
+			[]string{ids[4], ids[2], ids[1]}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.UserID = "user4"
+			page, err := s.QueryNotifications(tt.query)
+			if err != nil {
+				t.Fatalf("QueryNotifications: %v", err)
+			}
+			assertNotificationIDs(t, page.Notifications, tt.want...)
+			if page.HasPrevious != tt.hasPrevious || page.HasNext != tt.hasNext {
+				t.Errorf("HasPrevious, HasNext = %v, %v, want %v, %v", page.HasPrevious, page.HasNext, tt.hasPrevious, tt.hasNext)
+			}
+		})
+	}
+}
+
+func testNotificationCounts(t *testing.T, s store.Store) {
+	assertCounts(t, s, "user5", 0, 0, map[models.NotificationStatus]int{})
+
+	base := time.Now().UTC().Truncate(time.Millisecond).Add(-time.Hour)
+	var notifications []*models.Notification
+	for i := 0; i < 4; i++ {
+		n := newNotification("user5", "post1", base.Add(time.Duration(i)*time.Second))
+		if err := s.SaveNotification(n); err != nil {
+			t.Fatalf("SaveNotification #%d: %v", i, err)
+		}
+		notifications = append(notifications, n)
+	}
+	assertCounts(t, s, "user5", 4, 4, map[models.NotificationStatus]int{models.StatusQueued: 4})
+
+	notifications[0].Status = models.StatusDelivered
+	notifications[1].Status = models.StatusFailed
+	for _, n := range notifications[:2] {
+		if err := s.UpdateNotification(n); err != nil {
+			t.Fatalf("UpdateNotification: %v", err)
+		}
+	}
+	// Attempts alone do not move a notification between statuses
+	notifications[2].Attempts = 1
+	if err := s.UpdateNotification(notifications[2]); err != nil {
+		t.Fatalf("UpdateNotification: %v", err)
+	}
+	assertCounts(t, s, "user5", 4, 4, map[models.NotificationStatus]int{
+		models.StatusQueued:    2,
+		models.StatusDelivered: 1,
+		models.StatusFailed:    1,
+	})
+
+	// Marking twice only counts once
+	for i := 0; i < 2; i++ {
+		if _, err := s.MarkNotificationsRead([]string{notifications[0].ID}); err != nil {
+			t.Fatalf("MarkNotificationsRead: %v", err)
+		}
+	}
+	assertCounts(t, s, "user5", 4, 3, nil)
+	if _, err := s.MarkAllNotificationsRead("user5", base.Add(2*time.Second)); err != nil {
+		t.Fatalf("MarkAllNotificationsRead: %v", err)
+	}
+	assertCounts(t, s, "user5", 4, 2, nil)
+	if unread, err := s.CountUnreadNotifications("user5"); err != nil || unread != 2 {
+		t.Errorf("CountUnreadNotifications = %d, %v, want 2", unread, err)
+	}
+
+	if err := s.DeleteUser("user5"); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	assertCounts(t, s, "user5", 0, 0, map[models.NotificationStatus]int{})
+}
+
+func testGetPendingNotifications(t *testing.T, s store.Store) {
+	base := time.Now().Add(-time.Hour)
+	queued := newNotification("user2", "post1", base)
+	retrying := newNotification("user3", "post1", base.Add(time.Second))
+	delivered := newNotification("user4", "post1", base.Add(2*time.Second))
+	failed := newNotification("user5", "post1", base.Add(3*time.Second))
+
+	for _, n := range []*models.Notification{queued, retrying, delivered, failed} {
+		if err := s.SaveNotification(n); err != nil {
+			t.Fatalf("SaveNotification: %v", err)
+		}
+	}
+	for n, status := range map[*models.Notification]models.NotificationStatus{
+		retrying:  models.StatusRetrying,
+		delivered: models.StatusDelivered,
+		failed:    models.StatusFailed,
+	} {
+		n.Status = status
+		if err := s.UpdateNotification(n); err != nil {
+			t.Fatalf("UpdateNotification: %v", err)
+		}
+	}
+
+	pending, err := s.GetPendingNotifications()
+	if err != nil {
+		t.Fatalf("GetPendingNotifications: %v", err)
+	}
+	assertNotificationIDs(t, pending, queued.ID, retrying.ID)
+}
+
+func testDeadLetters(t *testing.T, s store.Store) {
+	base := time.Now().UTC().Truncate(time.Millisecond).Add(-time.Hour)
+	exhausted := &models.DeadLetter{
+		NotificationID: "dead-1",
+		UserID:         "user2",
+		Reason:         models.DeadLetterRetriesExhausted,
+		LastError:      "connection refused",
+		Attempts:       2,
+		AttemptedAt:    []time.Time{base, base.Add(time.Second)},
+		DeadAt:         base.Add(time.Second),
+	}
+	permanent := &models.DeadLetter{
+		NotificationID: "dead-2",
+		UserID:         "user3",
+		Reason:         models.DeadLetterPermanentError,
+		LastError:      "gone",
+		Attempts:       1,
+		DeadAt:         base.Add(time.Minute),
+	}
+	for _, d := range []*models.DeadLetter{exhausted, permanent} {
+		if err := s.SaveDeadLetter(d); err != nil {
+			t.Fatalf("SaveDeadLetter: %v", err)
+		}
+	}
+
+	got, err := s.GetDeadLetter(exhausted.NotificationID)
+	if err != nil {
+		t.Fatalf("GetDeadLetter: %v", err)
+	}
+	assertDeadLetter(t, got, exhausted)
+	if _, err := s.GetDeadLetter("nope"); !errors.Is(err, store.ErrDeadLetterNotFound) {
+		t.Errorf("GetDeadLetter(nope) error = %v, want %v", err, store.ErrDeadLetterNotFound)
+	}
+
+	// Saving again replaces the dead letter, replay history included
+	exhausted.Replayed = true
+	exhausted.Replays = []models.DeadLetterReplay{{At: base.Add(2 * time.Second), Reason: exhausted.Reason, LastError: exhausted.LastError, Attempts: 2}}
+	if err := s.SaveDeadLetter(exhausted); err != nil {
+		t.Fatalf("SaveDeadLetter again: %v", err)
+	}
+	got, err = s.GetDeadLetter(exhausted.NotificationID)
+	if err != nil {
+		t.Fatalf("GetDeadLetter after replace: %v", err)
+	}
+	assertDeadLetter(t, got, exhausted)
+
+	replayed, notReplayed := true, false
+	for _, tc := range []struct {
+		name   string
+		filter store.DeadLetterFilter
+		limit  int
+		want   []string
+	}{
+		{"all newest first", store.DeadLetterFilter{}, 0, []string{"dead-2", "dead-1"}},
+		{"limit", store.DeadLetterFilter{}, 1, []string{"dead-2"}},
+		{"user", store.DeadLetterFilter{UserID: "user2"}, 0, []string{"dead-1"}},
+		{"reason", store.DeadLetterFilter{Reason: models.DeadLetterPermanentError}, 0, []string{"dead-2"}},
+		{"replayed", store.DeadLetterFilter{Replayed: &replayed}, 0, []string{"dead-1"}},
+		{"not replayed", store.DeadLetterFilter{Replayed: &notReplayed}, 0, []string{"dead-2"}},
+		{"since", store.DeadLetterFilter{DeadSince: permanent.DeadAt}, 0, []string{"dead-2"}},
+		{"before", store.DeadLetterFilter{DeadBefore: permanent.DeadAt}, 0, []string{"dead-1"}},
+	} {
+		list, err := s.ListDeadLetters(tc.filter, tc.limit)
+		if err != nil {
+			t.Fatalf("ListDeadLetters(%s): %v", tc.name, err)
+		}
+		ids := make([]string, len(list))
+		for i, d := range list {
+			ids[i] = d.NotificationID
+		}
+		if !reflect.DeepEqual(ids, tc.want) {
+			t.Errorf("ListDeadLetters(%s) = %v, want %v", tc.name, ids, tc.want)
+		}
+	}
+
+	deleted, err := s.DeleteDeadLetters([]string{"dead-1", "nope"})
+	if err != nil {
+		t.Fatalf("DeleteDeadLetters: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("DeleteDeadLetters deleted %d, want 1", deleted)
+	}
+	if _, err := s.GetDeadLetter("dead-1"); !errors.Is(err, store.ErrDeadLetterNotFound) {
+		t.Errorf("GetDeadLetter(dead-1) after delete error = %v, want %v", err, store.ErrDeadLetterNotFound)
+	}
+
+	// Deleting a user takes the dead letters of their notifications along
+	if err := s.DeleteUser("user3"); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if _, err := s.GetDeadLetter("dead-2"); !errors.Is(err, store.ErrDeadLetterNotFound) {
+		t.Errorf("GetDeadLetter(dead-2) after DeleteUser error = %v, want %v", err, store.ErrDeadLetterNotFound)
+	}
+}
+
+func testConcurrentWrites(t *testing.T, s store.Store) {
+	const writers, perWriter = 8, 25
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*perWriter)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				n := newNotification("user4", "post1", time.Now())
+				if err := s.SaveNotification(n); err != nil {
+					errs <- err
+					continue
+				}
+				n.Status = models.StatusDelivered
+				if err := s.UpdateNotification(n); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("concurrent write: %v", err)
+	}
+
+	got, err := s.GetUserNotifications("user4", writers*perWriter*2)
+	if err != nil {
+		t.Fatalf("GetUserNotifications: %v", err)
+	}
+	if len(got) != writers*perWriter {
+		t.Errorf("GetUserNotifications returned %d notifications, want %d", len(got), writers*perWriter)
+	}
+}
+
+// Opener opens a durable backend rooted at dir. Opening the same dir twice
+// must observe everything written before the first store was closed.
+type Opener func(t *testing.T, dir string) store.Store
+
+// RunPersistence checks that a durable backend survives being closed and
+// reopened. Stores returned by open must implement io.Closer.
+func RunPersistence(t *testing.T, open Opener) {
+	dir := t.TempDir()
+
+	s := open(t, dir)
+	post := &models.Post{ID: "durable-post", AuthorID: "user1", Content: "durable", CreatedAt: time.Now().UTC().Truncate(time.Millisecond)}
+	if err := s.SavePost(post); err != nil {
+		t.Fatalf("SavePost: %v", err)
+	}
+
+	delivered := newNotification("user2", post.ID, time.Now().Add(-time.Minute))
+	retrying := newNotification("user2", post.ID, time.Now())
+	for _, n := range []*models.Notification{delivered, retrying} {
+		if err := s.SaveNotification(n); err != nil {
+			t.Fatalf("SaveNotification: %v", err)
+		}
+	}
+	delivered.Status = models.StatusDelivered
+	retrying.Status = models.StatusRetrying
+	retrying.Attempts = 1
+	for _, n := range []*models.Notification{delivered, retrying} {
+		if err := s.UpdateNotification(n); err != nil {
+			t.Fatalf("UpdateNotification: %v", err)
+		}
+	}
+	if _, err := s.MarkNotificationsRead([]string{delivered.ID}); err != nil {
+		t.Fatalf("MarkNotificationsRead: %v", err)
+	}
+	if err := s.Follow("user7", "user6"); err != nil {
+		t.Fatalf("Follow: %v", err)
+	}
+	if err := s.Unfollow("user2", "user1"); err != nil {
+		t.Fatalf("Unfollow: %v", err)
+	}
+	if err := s.CreateUser(&models.User{ID: "user8", Username: "heidi"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if err := s.DeleteUser("user3"); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	deadLetter := &models.DeadLetter{
+		NotificationID: delivered.ID,
+		UserID:         "user2",
+		Reason:         models.DeadLetterRetriesExhausted,
+		LastError:      "timeout",
+		Attempts:       3,
+		AttemptedAt:    []time.Time{post.CreatedAt},
+		DeadAt:         post.CreatedAt,
+		Replays:        []models.DeadLetterReplay{{At: post.CreatedAt, Reason: models.DeadLetterDropped, Attempts: 0}},
+	}
+	if err := s.SaveDeadLetter(deadLetter); err != nil {
+		t.Fatalf("SaveDeadLetter: %v", err)
+	}
+	closeStore(t, s)
+
+	s = open(t, dir)
+	defer closeStore(t, s)
+
+	if _, err := s.GetPost(post.ID); err != nil {
+		t.Errorf("GetPost after reopen: %v", err)
+	}
+	if _, err := s.GetUser("user1"); err != nil {
+		t.Errorf("GetUser after reopen: %v", err)
+	}
+	followers, err := s.GetFollowers("user6")
+	if err != nil {
+		t.Fatalf("GetFollowers after reopen: %v", err)
+	}
+	assertUserIDs(t, followers, "user7")
+	followers, err = s.GetFollowers("user1")
+	if err != nil {
+		t.Fatalf("GetFollowers after reopen: %v", err)
+	}
+	assertUserIDs(t, followers, "user4", "user5", "user6", "user7")
+	assertFollowGraphConsistent(t, s)
+	if _, err := s.GetUser("user8"); err != nil {
+		t.Errorf("GetUser(user8) after reopen: %v", err)
+	}
+	if _, err := s.GetUser("user3"); !errors.Is(err, store.ErrUserNotFound) {
+		t.Errorf("GetUser(user3) after reopen error = %v, want %v", err, store.ErrUserNotFound)
+	}
+
+	got, err := s.GetUserNotifications("user2", 10)
+	if err != nil {
+		t.Fatalf("GetUserNotifications after reopen: %v", err)
+	}
+	assertNotificationIDs(t, got, retrying.ID, delivered.ID)
+	if got[0].Status != models.StatusRetrying || got[0].Attempts != 1 {
+		t.Errorf("reopened notification = %+v, want retrying with 1 attempt", got[0])
+	}
+	if got[0].Read || !got[1].Read {
+		t.Errorf("reopened read flags = %v, %v, want false, true", got[0].Read, got[1].Read)
+	}
+
+	pending, err := s.GetPendingNotifications()
+	if err != nil {
+		t.Fatalf("GetPendingNotifications after reopen: %v", err)
+	}
+	assertNotificationIDs(t, pending, retrying.ID)
+	assertCounts(t, s, "user2", 2, 1, map[models.NotificationStatus]int{
+		models.StatusDelivered: 1,
+		models.StatusRetrying:  1,
+	})
+
+	gotDeadLetter, err := s.GetDeadLetter(deadLetter.NotificationID)
+	if err != nil {
+		t.Fatalf("GetDeadLetter after reopen: %v", err)
+	}
+	assertDeadLetter(t, gotDeadLetter, deadLetter)
+}
+
+// assertCounts checks a user's counters; a nil byStatus is not checked
+func assertCounts(t *testing.T, s store.Store, userID string, total, unread int, byStatus map[models.NotificationStatus]int) {
+	t.Helper()
+
+	counts, err := s.GetNotificationCounts(userID)
+	if err != nil {
+		t.Fatalf("GetNotificationCounts(%s): %v", userID, err)
+	}
+	if counts.Total != total || counts.Unread != unread {
+		t.Errorf("GetNotificationCounts(%s) total, unread = %d, %d, want %d, %d", userID, counts.Total, counts.Unread, total, unread)
+	}
+	if byStatus != nil && !reflect.DeepEqual(counts.ByStatus, byStatus) {
+		t.Errorf("GetNotificationCounts(%s).ByStatus = %v, want %v", userID, counts.ByStatus, byStatus)
+	}
+}
+
+// assertDeadLetter compares dead letters field by field, since stores may
+// hand times back in another location
+func assertDeadLetter(t *testing.T, got, want *models.DeadLetter) {
+	t.Helper()
+
+	same := got.NotificationID == want.NotificationID && got.UserID == want.UserID &&
+		got.Reason == want.Reason && got.LastError == want.LastError && got.Attempts == want.Attempts &&
+		got.DeadAt.Equal(want.DeadAt) && got.Replayed == want.Replayed &&
+		len(got.AttemptedAt) == len(want.AttemptedAt) && len(got.Replays) == len(want.Replays)
+	for i := 0; same && i < len(want.AttemptedAt); i++ {
+		same = got.AttemptedAt[i].Equal(want.AttemptedAt[i])
+	}
+	for i := 0; same && i < len(want.Replays); i++ {
+		g, w := got.Replays[i], want.Replays[i]
+		same = g.At.Equal(w.At) && g.Reason == w.Reason && g.LastError == w.LastError && g.Attempts == w.Attempts
+	}
+	if !same {
+		t.Errorf("dead letter = %+v, want %+v", got, want)
+	}
+}
+
+func closeStore(t *testing.T, s store.Store) {
+	t.Helper()
+
+	closer, ok := s.(io.Closer)
+	if !ok {
+		t.Fatalf("%T does not implement io.Closer", s)
+	}
+	if err := closer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+var notificationSeq struct {
+	sync.Mutex
+	n int
+}
+
+// newNotification builds a queued notification with a unique, readable ID
+func newNotification(userID, postID string, createdAt time.Time) *models.Notification {
+	notificationSeq.Lock()
+	notificationSeq.n++
+	seq := notificationSeq.n
+	notificationSeq.Unlock()
+
+	return &models.Notification{
+		ID:        fmt.Sprintf("conformance-%s-%d", userID, seq),
+		Type:      models.TypeNewPost,
+		UserID:    userID,
+		PostID:    postID,
+		AuthorID:  "user1",
+		Content:   "New post from a user you follow",
+		CreatedAt: createdAt.UTC().Truncate(time.Millisecond),
+		Status:    models.StatusQueued,
+	}
+}
+
+// assertFollowGraphConsistent checks that every follower edge has its
+// following counterpart and vice versa
+func assertFollowGraphConsistent(t *testing.T, s store.Store) {
+	t.Helper()
+
+	users := make(map[string]*models.User)
+	for _, user := range s.GetAllUsers() {
+		users[user.ID] = user
+	}
+	for _, user := range users {
+		for _, followerID := range user.FollowerIDs {
+			if follower, ok := users[followerID]; !ok || !hasID(follower.FollowingIDs, user.ID) {
+				t.Errorf("%s is followed by %s, but %s does not list it as following", user.ID, followerID, followerID)
+			}
+		}
+		for _, followeeID := range user.FollowingIDs {
+			if followee, ok := users[followeeID]; !ok || !hasID(followee.FollowerIDs, user.ID) {
+				t.Errorf("%s follows %s, but %s does not list it as a follower", user.ID, followeeID, followeeID)
+			}
+		}
+	}
+}
+
+func assertUserOrder(t *testing.T, users []*models.User, want ...string) {
+	t.Helper()
+
+	got := make([]string, len(users))
+	for i, user := range users {
+		got[i] = user.ID
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got users %v, want %v", got, want)
+	}
+}
+
+func assertIDSet(t *testing.T, what string, ids []string, want ...string) {
+	t.Helper()
+
+	if len(ids) != len(want) {
+		t.Errorf("%s = %v, want %v", what, ids, want)
+		return
+	}
+	for _, id := range want {
+		if !hasID(ids, id) {
+			t.Errorf("%s = %v, want %v", what, ids, want)
+			return
+		}
+	}
+}
+
+func hasID(ids []string, id string) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
+
+func assertUserIDs(t *testing.T, users []*models.User, want ...string) {
+	t.Helper()
+
+	got := make(map[string]bool, len(users))
+	for _, user := range users {
+		got[user.ID] = true
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d users, want %d (%v)", len(got), len(want), want)
+	}
+	for _, id := range want {
+		if !got[id] {
+			t.Errorf("missing user %s", id)
+		}
+	}
+}
+
+func assertUnread(t *testing.T, s store.Store, userID string, want int) {
+	t.Helper()
+
+	got, err := s.CountUnreadNotifications(userID)
+	if err != nil {
+		t.Fatalf("CountUnreadNotifications(%s): %v", userID, err)
+	}
+	if got != want {
+		t.Errorf("CountUnreadNotifications(%s) = %d, want %d", userID, got, want)
+	}
+}
+
+func assertNotificationIDs(t *testing.T, notifications []*models.Notification, want ...string) {
+	t.Helper()
+
+	if len(notifications) != len(want) {
+		t.Fatalf("got %d notifications, want %d", len(notifications), len(want))
+	}
+	for i, n := range notifications {
+		if n.ID != want[i] {
+			t.Errorf("notification %d = %s, want %s", i, n.ID, want[i])
+		}
+	}
+}